@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+const header = `// Code generated by "infoblox-go-client-generator"; DO NOT EDIT.`
+
+// docWidth is the width the doc comments are wrapped at, "// " excluded
+const docWidth = 76
+
+// typeNames are the Go names of the object and struct types
+// which don't follow goName, kept for compatibility
+var typeNames = map[string]string{
+	"capacityreport":                    "CapacityReport",
+	"extensibleattributedef":            "EADefinition",
+	"extensibleattributedef:listvalues": "EADefListValue",
+	"extserver":                         "NameServer",
+	"fixedaddress":                      "Ipv4FixedAddress",
+	"grid:ntp":                          "NTPSetting",
+	"ipv4address":                       "IPv4Address",
+	"ipv6address":                       "IPv6Address",
+	"ipv6fixedaddress":                  "Ipv6FixedAddress",
+	"ipv6network":                       "Ipv6Network",
+	"ipv6networkcontainer":              "Ipv6NetworkContainer",
+	"ipv6networktemplate":               "IPv6NetworkTemplate",
+	"ipv6range":                         "IPv6Range",
+	"ipv6sharednetwork":                 "IPv6SharedNetwork",
+	"macfilteraddress":                  "MACFilterAddress",
+	"member:dhcpproperties":             "MemberDHCPProperties",
+	"network":                           "Ipv4Network",
+	"networkcontainer":                  "Ipv4NetworkContainer",
+	"networktemplate":                   "NetworkTemplate",
+	"networkview":                       "NetworkView",
+	"ntpserver":                         "NTPserver",
+	"record:aaaa":                       "RecordAAAA",
+	"record:cname":                      "RecordCNAME",
+	"record:host":                       "HostRecord",
+	"record:host_ipv4addr":              "HostRecordIpv4Addr",
+	"record:host_ipv6addr":              "HostRecordIpv6Addr",
+	"record:mx":                         "RecordMX",
+	"record:ns":                         "RecordNS",
+	"record:ptr":                        "RecordPTR",
+	"record:srv":                        "RecordSRV",
+	"record:txt":                        "RecordTXT",
+	"roaminghost":                       "RoamingHost",
+	"scheduledtask":                     "ScheduledTask",
+	"sharednetwork":                     "SharedNetwork",
+	"sharedrecord:a":                    "SharedRecordA",
+	"sharedrecord:aaaa":                 "SharedRecordAAAA",
+	"sharedrecord:mx":                   "SharedRecordMX",
+	"sharedrecord:txt":                  "SharedRecordTXT",
+	"snmpuser":                          "SNMPUser",
+	"upgradestatus":                     "UpgradeStatus",
+	"userprofile":                       "UserProfile",
+	"zonenameserver":                    "ZoneNameServer",
+}
+
+// fieldNames are the Go names of the fields which don't follow goName:
+// object_type would clash with the ObjectType method
+var fieldNames = map[string]string{
+	"configure_for_dhcp": "EnableDhcp",
+	"configure_for_dns":  "EnableDns",
+	"enable_ntp":         "EnableNTP",
+	"iburst":             "IBurst",
+	"ipv4addr":           "Ipv4Addr",
+	"ipv4addrs":          "Ipv4Addrs",
+	"ipv6addr":           "Ipv6Addr",
+	"ipv6addrs":          "Ipv6Addrs",
+	"ntp_acl":            "NTPAcl",
+	"ntp_keys":           "NTPKeys",
+	"ntp_kod":            "NTPKod",
+	"ntp_servers":        "NTPServers",
+	"ntp_setting":        "NTPSetting",
+	"object_type":        "ObjectTypeField",
+	"ptrdname":           "PtrdName",
+}
+
+// ObjectSchema is the schema of a WAPI object type, as returned by
+// <type>?_schema&_get_doc=1&_schema_struct=1
+type ObjectSchema struct {
+	Type    string        `json:"type"`
+	Version string        `json:"version"`
+	Doc     string        `json:"doc"`
+	Fields  []FieldSchema `json:"fields"`
+}
+
+// FieldSchema is the schema of a field of an object or of a struct.
+type FieldSchema struct {
+	Name          string        `json:"name"`
+	Type          []string      `json:"type"`
+	Supports      string        `json:"supports"`
+	IsArray       bool          `json:"is_array"`
+	Standard      bool          `json:"standard_field"`
+	WapiPrimitive string        `json:"wapi_primitive"`
+	Doc           string        `json:"doc"`
+	Schema        *StructSchema `json:"schema"`
+}
+
+// StructSchema is the schema of a struct field, with _schema_struct=1.
+type StructSchema struct {
+	Fields []FieldSchema `json:"fields"`
+}
+
+// ParseSchemas parses a dump of object schemas: a JSON list of the
+// schemas returned by WAPI for each object type.
+func ParseSchemas(data []byte) ([]ObjectSchema, error) {
+	var schemas []ObjectSchema
+	if err := json.Unmarshal(data, &schemas); err != nil {
+		return nil, fmt.Errorf("cannot parse the schemas: %s", err)
+	}
+	for i, s := range schemas {
+		if s.Type == "" {
+			return nil, fmt.Errorf("the schema #%d has no object type", i)
+		}
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return typeName(schemas[i].Type) < typeName(schemas[j].Type)
+	})
+	return schemas, nil
+}
+
+// Generator generates the Go code of WAPI objects from their schemas.
+type Generator struct {
+	Package string
+	Version string
+}
+
+// structDef is a struct type used by object fields
+type structDef struct {
+	wapiType string
+	fields   []FieldSchema
+}
+
+// Generate returns the code of the objects, and the code of their tests.
+func (g *Generator) Generate(schemas []ObjectSchema) ([]byte, []byte, error) {
+	version := g.Version
+	if version == "" && len(schemas) > 0 {
+		version = schemas[0].Version
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n\npackage %s\n\n", header, g.Package)
+	fmt.Fprintf(&buf, "// WAPI_VERSION is a version of WAPI, which was used to generate infoblox-go-client.\n")
+	fmt.Fprintf(&buf, "const WAPI_VERSION = %q\n", version)
+
+	structs := make(map[string]*structDef)
+	for _, s := range schemas {
+		g.writeObject(&buf, s, structs)
+	}
+
+	names := make([]string, 0, len(structs))
+	for name := range structs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.writeStruct(&buf, name, structs[name])
+	}
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot format the generated code: %s", err)
+	}
+	tests, err := format.Source(g.tests(schemas))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot format the generated tests: %s", err)
+	}
+	return code, tests, nil
+}
+
+func (g *Generator) writeObject(buf *bytes.Buffer, s ObjectSchema, structs map[string]*structDef) {
+	name := typeName(s.Type)
+	fmt.Fprintf(buf, "\n// %s represents Infoblox object %s.\n", name, s.Type)
+	writeDoc(buf, "", s.Doc)
+	fmt.Fprintf(buf, "type %s struct {\n", name)
+	fmt.Fprintf(buf, "\tIBBase `json:\"-\"`\n\n")
+	fmt.Fprintf(buf, "\tRef string `json:\"_ref,omitempty\"`\n")
+
+	fields := objectFields(s)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	for _, f := range fields {
+		buf.WriteString("\n")
+		writeDoc(buf, "\t", f.Doc)
+		if f.Name == "extattrs" {
+			fmt.Fprintf(buf, "\tEa EA `json:\"extattrs\"`\n")
+			continue
+		}
+		fmt.Fprintf(buf, "\t%s %s `json:\"%s,omitempty\"`\n", fieldName(f.Name), fieldType(f, false, structs), f.Name)
+	}
+	buf.WriteString("}\n")
+
+	fmt.Fprintf(buf, "\nfunc (%s) ObjectType() string {\n\treturn %q\n}\n", name, s.Type)
+	fmt.Fprintf(buf, "\nfunc (obj %s) ReturnFields() []string {\n", name)
+	fmt.Fprintf(buf, "\tif obj.returnFields == nil {\n\t\tobj.returnFields = %s\n\t}\n", stringList(returnFields(s)))
+	fmt.Fprintf(buf, "\treturn obj.returnFields\n}\n")
+}
+
+func (g *Generator) writeStruct(buf *bytes.Buffer, name string, def *structDef) {
+	fmt.Fprintf(buf, "\n// %s represents Infoblox struct %s\n", name, def.wapiType)
+	fmt.Fprintf(buf, "type %s struct {\n", name)
+	for i, f := range def.fields {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		writeDoc(buf, "\t", f.Doc)
+		// the nested structs are collected while writing the objects
+		fmt.Fprintf(buf, "\t%s %s `json:\"%s,omitempty\"`\n", fieldName(f.Name), fieldType(f, true, nil), f.Name)
+	}
+	buf.WriteString("}\n")
+}
+
+func (g *Generator) tests(schemas []ObjectSchema) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n\npackage %s\n\n", header, g.Package)
+	buf.WriteString("import (\n\t. \"github.com/onsi/ginkgo/v2\"\n\t. \"github.com/onsi/gomega\"\n)\n\n")
+	buf.WriteString("// This test checks if common methods for each\n// generated Infoblox object works as expected\n")
+	buf.WriteString("var _ = DescribeTable(\"Common methods of WAPI Objects\",\n")
+	buf.WriteString("\tfunc(obj IBObject, expectedReturnFields []string, expectedObjectType string) {\n")
+	buf.WriteString("\t\t// Check if default return fields are valid\n")
+	buf.WriteString("\t\tExpect(obj.ReturnFields()).To(Equal(expectedReturnFields))\n")
+	buf.WriteString("\t\t// Check if ObjectType is valid\n")
+	buf.WriteString("\t\tExpect(obj.ObjectType()).To(Equal(expectedObjectType))\n")
+	buf.WriteString("\t},\n")
+	for _, s := range schemas {
+		name := typeName(s.Type)
+		fmt.Fprintf(&buf, "\tEntry(\n\t\t%q,\n\t\t&%s{},\n\t\t%s,\n\t\t%q,\n\t),\n",
+			name, name, stringList(returnFields(s)), s.Type)
+	}
+	buf.WriteString(")\n")
+	return buf.Bytes()
+}
+
+// objectFields returns the fields of an object, functions excluded
+func objectFields(s ObjectSchema) []FieldSchema {
+	var res []FieldSchema
+	for _, f := range s.Fields {
+		if f.WapiPrimitive != "funccall" {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// returnFields returns the standard fields of an object, which WAPI
+// returns by default
+func returnFields(s ObjectSchema) []string {
+	res := []string{}
+	for _, f := range objectFields(s) {
+		if f.Standard {
+			res = append(res, f.Name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// fieldType returns the Go type of a field. The fields of objects
+// which can be written are pointers, so that zero values can be sent;
+// read-only fields, enums and the fields of structs are values.
+func fieldType(f FieldSchema, inStruct bool, structs map[string]*structDef) string {
+	wapiType := "string"
+	if len(f.Type) > 0 {
+		wapiType = f.Type[0]
+	}
+
+	if f.WapiPrimitive == "struct" || f.Schema != nil {
+		name := typeName(wapiType)
+		if _, ok := structs[name]; !ok && structs != nil && f.Schema != nil {
+			structs[name] = &structDef{wapiType: wapiType, fields: f.Schema.Fields}
+			for _, sf := range f.Schema.Fields {
+				// registers the nested structs
+				fieldType(sf, true, structs)
+			}
+		}
+		if f.IsArray {
+			return "[]*" + name
+		}
+		return "*" + name
+	}
+
+	var goType string
+	switch wapiType {
+	case "bool":
+		goType = "bool"
+	case "uint":
+		goType = "uint32"
+	case "int":
+		goType = "int"
+	case "timestamp":
+		goType = "UnixTime"
+	default:
+		goType = "string"
+	}
+	if f.IsArray {
+		return "[]" + goType
+	}
+	if goType == "UnixTime" {
+		return "*UnixTime"
+	}
+	writable := strings.ContainsAny(f.Supports, "wu")
+	if inStruct || !writable || wapiType == "enum" {
+		return goType
+	}
+	return "*" + goType
+}
+
+// goName returns the Go name of a WAPI name, e.g. RecordA for record:a
+// and UseTtl for use_ttl
+func goName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == ':' || r == '-'
+	})
+	var sb strings.Builder
+	for _, p := range parts {
+		sb.WriteString(strings.ToUpper(p[:1]) + p[1:])
+	}
+	return sb.String()
+}
+
+func typeName(wapiType string) string {
+	if name, ok := typeNames[wapiType]; ok {
+		return name
+	}
+	return goName(wapiType)
+}
+
+func fieldName(name string) string {
+	if goField, ok := fieldNames[name]; ok {
+		return goField
+	}
+	return goName(name)
+}
+
+func stringList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+// writeDoc writes doc as a comment wrapped at docWidth columns
+func writeDoc(buf *bytes.Buffer, indent string, doc string) {
+	line := ""
+	for _, word := range strings.Fields(doc) {
+		if line != "" && len(line)+1+len(word) > docWidth {
+			fmt.Fprintf(buf, "%s// %s\n", indent, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		fmt.Fprintf(buf, "%s// %s\n", indent, line)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// go test ./cmd/infoblox-go-client-generator -update rewrites the golden files
+var update = flag.Bool("update", false, "update the golden files")
+
+// typeBlock returns the declaration of a type in code, with its doc comment
+// and methods, up to the next documented declaration.
+func typeBlock(code string, name string) string {
+	start := strings.Index(code, "\n// "+name+" represents ")
+	if start < 0 {
+		return ""
+	}
+	block := code[start+1:]
+	if end := strings.Index(block, "\n\n// "); end >= 0 {
+		block = block[:end+1]
+	}
+	return block
+}
+
+var _ = Describe("Generator", func() {
+	var schemas []ObjectSchema
+
+	BeforeEach(func() {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "schema.json"))
+		Expect(err).To(BeNil())
+		schemas, err = ParseSchemas(data)
+		Expect(err).To(BeNil())
+	})
+
+	It("should generate the golden files", func() {
+		gen := &Generator{Package: "ibclient"}
+		code, tests, err := gen.Generate(schemas)
+		Expect(err).To(BeNil())
+
+		for file, generated := range map[string][]byte{
+			"objects_generated.go.golden":      code,
+			"objects_generated_test.go.golden": tests,
+		} {
+			path := filepath.Join("testdata", file)
+			if *update {
+				Expect(ioutil.WriteFile(path, generated, 0644)).To(Succeed())
+			}
+			golden, err := ioutil.ReadFile(path)
+			Expect(err).To(BeNil())
+			Expect(string(generated)).To(Equal(string(golden)), file)
+		}
+	})
+
+	It("should generate the objects the way objects_generated.go has them", func() {
+		gen := &Generator{Package: "ibclient"}
+		code, _, err := gen.Generate(schemas)
+		Expect(err).To(BeNil())
+		current, err := ioutil.ReadFile(filepath.Join("..", "..", "objects_generated.go"))
+		Expect(err).To(BeNil())
+
+		for _, name := range []string{"AdAuthService", "Adminrole", "AdAuthServer"} {
+			block := typeBlock(string(code), name)
+			Expect(block).NotTo(BeEmpty(), name)
+			Expect(string(current)).To(ContainSubstring(block), name)
+		}
+	})
+
+	It("should map the WAPI types to Go types", func() {
+		gen := &Generator{Package: "ibclient", Version: "2.13"}
+		code, _, err := gen.Generate(schemas)
+		Expect(err).To(BeNil())
+
+		Expect(string(code)).To(ContainSubstring(`const WAPI_VERSION = "2.13"`))
+		recordA := typeBlock(string(code), "RecordA")
+		Expect(recordA).To(ContainSubstring("Ttl *uint32 `json:\"ttl,omitempty\"`"))
+		Expect(recordA).To(ContainSubstring("CreationTime *UnixTime `json:\"creation_time,omitempty\"`"))
+		Expect(recordA).To(ContainSubstring("Creator string `json:\"creator,omitempty\"`"))
+		Expect(recordA).To(ContainSubstring("DnsName string `json:\"dns_name,omitempty\"`"))
+		Expect(recordA).To(ContainSubstring("CloudInfo *GridCloudapiInfo `json:\"cloud_info,omitempty\"`"))
+		Expect(recordA).To(ContainSubstring("Ea EA `json:\"extattrs\"`"))
+		Expect(recordA).NotTo(ContainSubstring("reclaim"))
+		Expect(recordA).To(ContainSubstring(`obj.returnFields = []string{"ipv4addr", "name", "view"}`))
+		Expect(typeBlock(string(code), "GridCloudapiInfo")).To(ContainSubstring("DelegatedMember *Dhcpmember"))
+		Expect(typeBlock(string(code), "Dhcpmember")).To(ContainSubstring("Name string"))
+	})
+
+	It("should reject schemas without object type", func() {
+		_, err := ParseSchemas([]byte(`[{"version": "2.12.1", "fields": []}]`))
+		Expect(err).To(MatchError("the schema #0 has no object type"))
+
+		_, err = ParseSchemas([]byte(`{"type": "record:a"}`))
+		Expect(err).NotTo(BeNil())
+	})
+})
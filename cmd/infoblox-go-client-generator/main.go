@@ -0,0 +1,56 @@
+// Command infoblox-go-client-generator generates objects_generated.go and
+// objects_generated_test.go from a dump of WAPI object schemas, so that
+// the structs can be regenerated for other WAPI versions without a grid.
+//
+// The dump is a JSON list of the object schemas returned by WAPI for
+// <type>?_schema&_get_doc=1&_schema_struct=1, e.g. built with:
+//
+//	for t in adminrole record:a; do
+//		curl -sku admin:infoblox "https://grid/wapi/v2.12.1/$t?_schema&_get_doc=1&_schema_struct=1"
+//	done | jq -s . > schema.json
+//
+// Usage:
+//
+//	infoblox-go-client-generator -schema schema.json -out .
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+)
+
+func main() {
+	schemaFile := flag.String("schema", "", "the JSON dump of the WAPI object schemas")
+	outDir := flag.String("out", ".", "the directory to write the generated files to")
+	pkg := flag.String("package", "ibclient", "the package of the generated files")
+	version := flag.String("version", "", "the WAPI version, the version of the schemas by default")
+	flag.Parse()
+
+	if *schemaFile == "" {
+		flag.Usage()
+		log.Fatal("the schema file is required")
+	}
+	data, err := ioutil.ReadFile(*schemaFile)
+	if err != nil {
+		log.Fatalf("cannot read the schema file: %s", err)
+	}
+	schemas, err := ParseSchemas(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	gen := &Generator{Package: *pkg, Version: *version}
+	code, tests, err := gen.Generate(schemas)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(*outDir, "objects_generated.go"), code, 0644); err != nil {
+		log.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(*outDir, "objects_generated_test.go"), tests, 0644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("generated %d objects in %s", len(schemas), *outDir)
+}
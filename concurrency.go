@@ -0,0 +1,128 @@
+package ibclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// maxMergeAttempts is the number of times UpdateIfUnchanged merges
+// the changes of others before giving up
+const maxMergeAttempts = 3
+
+// ConcurrentUpdateError is returned by ObjectManager.UpdateIfUnchanged when
+// the object was modified by someone else since it was read. Current is the
+// object as it is now.
+type ConcurrentUpdateError struct {
+	Ref     string
+	Current IBObject
+}
+
+func (e *ConcurrentUpdateError) Error() string {
+	return fmt.Sprintf("the object '%s' was modified since it was read", e.Ref)
+}
+
+// IsConcurrentUpdate reports whether err means that an object
+// was modified by someone else since it was read.
+func IsConcurrentUpdate(err error) bool {
+	var concurrentErr *ConcurrentUpdateError
+	return errors.As(err, &concurrentErr)
+}
+
+// MergeFunc returns the object to update, given the current version of an
+// object which was modified since it was read, e.g. the current object
+// with the caller's changes applied again. Returning an error gives up.
+type MergeFunc func(current IBObject) (IBObject, error)
+
+// ObjectFingerprint returns a digest of the fields of obj, which differs
+// when the object was modified.
+func ObjectFingerprint(obj IBObject) (string, error) {
+	fields, err := objectFields(obj)
+	if err != nil {
+		return "", err
+	}
+	// maps are marshaled with sorted keys
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// UpdateIfUnchanged updates the object with the given reference with the
+// fields set in updated, unless it was modified since original was read:
+// the object is read again right before the update and its fingerprint
+// compared to the one of original. original must be the object as it was
+// fetched, e.g. by GetHostRecordByRef, so that the same fields are read.
+//
+// When the object was modified, a *ConcurrentUpdateError is returned if
+// merge is nil. Otherwise merge is given the current object and returns the
+// object to update instead, which is checked again against later changes.
+//
+// WAPI has no conditional update, so the check narrows the window in which
+// the changes of others are overwritten but can't close it.
+func (objMgr *ObjectManager) UpdateIfUnchanged(ref string, original IBObject, updated IBObject, merge MergeFunc) (string, error) {
+	for attempt := 0; ; attempt++ {
+		current, err := objMgr.refetch(ref, original)
+		if err != nil {
+			return "", err
+		}
+		same, err := sameFingerprint(original, current)
+		if err != nil {
+			return "", err
+		}
+		if same {
+			return objMgr.connector.UpdateObject(updated, ref)
+		}
+		if merge == nil || attempt == maxMergeAttempts {
+			return "", &ConcurrentUpdateError{Ref: ref, Current: current}
+		}
+		objMgr.log().Info("the object was modified since it was read, merging the changes", "ref", ref)
+		if updated, err = merge(current); err != nil {
+			return "", err
+		}
+		original = current
+	}
+}
+
+// refetch reads the object with the given reference again, with the
+// return fields original was read with, into an object of its type
+func (objMgr *ObjectManager) refetch(ref string, original IBObject) (IBObject, error) {
+	v := reflect.ValueOf(original)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot read again a %T object", original)
+	}
+
+	// the copy keeps the unexported fields, e.g. the object type of
+	// networks, and the return fields, but not the exported ones
+	current := reflect.New(v.Elem().Type())
+	current.Elem().Set(v.Elem())
+	for i := 0; i < current.Elem().NumField(); i++ {
+		field := current.Elem().Type().Field(i)
+		if field.IsExported() && jsonName(field) != "-" {
+			current.Elem().Field(i).Set(reflect.Zero(field.Type))
+		}
+	}
+
+	obj := current.Interface().(IBObject)
+	if err := objMgr.connector.GetObject(obj, ref, NewQueryParams(false, nil), obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func sameFingerprint(a IBObject, b IBObject) (bool, error) {
+	fa, err := ObjectFingerprint(a)
+	if err != nil {
+		return false, err
+	}
+	fb, err := ObjectFingerprint(b)
+	if err != nil {
+		return false, err
+	}
+	return fa == fb, nil
+}
@@ -0,0 +1,82 @@
+package ibclient_test
+
+import (
+	"fmt"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Optimistic concurrency", func() {
+	var (
+		server *fakewapi.Server
+		conn   *ibclient.Connector
+		objMgr *ibclient.ObjectManager
+		ref    string
+	)
+
+	BeforeEach(func() {
+		server, conn = newFakeConnector()
+		objMgr = ibclient.NewObjectManager(conn, "cmp", "tenant").(*ibclient.ObjectManager)
+		ref = server.Add("nsgroup", map[string]interface{}{"name": "ns-group", "comment": "first"})
+	})
+
+	It("should update an object which wasn't modified", func() {
+		original, err := ibclient.Get[ibclient.Nsgroup](conn, ref)
+		Expect(err).To(BeNil())
+
+		newRef, err := objMgr.UpdateIfUnchanged(ref, original, &ibclient.Nsgroup{Comment: utils.StringPtr("second")}, nil)
+		Expect(err).To(BeNil())
+		stored, _ := server.Get(newRef)
+		Expect(stored["comment"]).To(Equal("second"))
+	})
+
+	It("should return a conflict error when the object was modified", func() {
+		original, err := ibclient.Get[ibclient.Nsgroup](conn, ref)
+		Expect(err).To(BeNil())
+		_, err = ibclient.PatchObject(conn, ref, &ibclient.Nsgroup{Comment: utils.StringPtr("changed by others")})
+		Expect(err).To(BeNil())
+
+		_, err = objMgr.UpdateIfUnchanged(ref, original, &ibclient.Nsgroup{Comment: utils.StringPtr("second")}, nil)
+		Expect(ibclient.IsConcurrentUpdate(fmt.Errorf("update: %w", err))).To(BeTrue())
+		concurrentErr := err.(*ibclient.ConcurrentUpdateError)
+		Expect(*concurrentErr.Current.(*ibclient.Nsgroup).Comment).To(Equal("changed by others"))
+
+		stored, _ := server.Get(ref)
+		Expect(stored["comment"]).To(Equal("changed by others"))
+	})
+
+	It("should merge the changes of others", func() {
+		original, err := ibclient.Get[ibclient.Nsgroup](conn, ref)
+		Expect(err).To(BeNil())
+		_, err = ibclient.PatchObject(conn, ref, &ibclient.Nsgroup{Comment: utils.StringPtr("changed")})
+		Expect(err).To(BeNil())
+
+		var merged *ibclient.Nsgroup
+		newRef, err := objMgr.UpdateIfUnchanged(ref, original, &ibclient.Nsgroup{Comment: utils.StringPtr("second")},
+			func(current ibclient.IBObject) (ibclient.IBObject, error) {
+				merged = current.(*ibclient.Nsgroup)
+				return &ibclient.Nsgroup{Comment: utils.StringPtr(*merged.Comment + ", second")}, nil
+			})
+		Expect(err).To(BeNil())
+		Expect(merged).NotTo(BeNil())
+		stored, _ := server.Get(newRef)
+		Expect(stored["comment"]).To(Equal("changed, second"))
+	})
+
+	It("should fingerprint the fields of objects", func() {
+		a, err := ibclient.ObjectFingerprint(&ibclient.Nsgroup{Name: utils.StringPtr("a")})
+		Expect(err).To(BeNil())
+		b, err := ibclient.ObjectFingerprint(&ibclient.Nsgroup{Name: utils.StringPtr("b")})
+		Expect(err).To(BeNil())
+		Expect(a).NotTo(Equal(b))
+
+		again, err := ibclient.ObjectFingerprint(&ibclient.Nsgroup{Name: utils.StringPtr("a")})
+		Expect(err).To(BeNil())
+		Expect(again).To(Equal(a))
+	})
+})
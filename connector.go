@@ -2,18 +2,19 @@ package ibclient
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"crypto/x509"
 	"encoding/json"
-	"errors"
-	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/net/publicsuffix"
@@ -23,8 +24,24 @@ type AuthConfig struct {
 	Username string
 	Password string
 
+	// Credentials, when set, provides the username and password
+	// instead of Username and Password.
+	Credentials CredentialProvider
+
+	// ReuseSession makes the requests authenticate with the ibapauth
+	// session cookie once a request authenticated with the credentials,
+	// which are sent again when the session expires. It is implemented
+	// by WapiHttpRequestor.
+	ReuseSession bool
+
 	ClientCert []byte
 	ClientKey  []byte
+
+	// ClientCertFile and ClientKeyFile are the PEM files of the client
+	// certificate, used instead of ClientCert and ClientKey. They are read
+	// again when they change, so that the certificate can be rotated.
+	ClientCertFile string
+	ClientKeyFile  string
 }
 
 type HostConfig struct {
@@ -32,6 +49,13 @@ type HostConfig struct {
 	Host    string
 	Version string
 	Port    string
+
+	// Candidates are the Grid Master Candidates, "host" or "host:port",
+	// the requests are sent to in order when Host can't be connected to or
+	// isn't the Grid Master, e.g. while a candidate is promoted. The
+	// connector fails back to Host once it recovers, see
+	// TransportConfig.HealthCheckInterval and Connector.ActiveEndpoint.
+	Candidates []string
 }
 
 type TransportConfig struct {
@@ -40,6 +64,27 @@ type TransportConfig struct {
 	HttpRequestTimeout  time.Duration // in seconds
 	HttpPoolConnections int
 	ProxyUrl            *url.URL
+	// RetryPolicy decides whether failed requests are attempted again,
+	// every request is sent once when it is nil.
+	RetryPolicy RetryPolicy
+	// RateLimit caps the number of requests per second sent by a Connector
+	// and RateBurst is the number of requests which may be sent at once
+	// above it, counting the retries of the requests as requests too.
+	// Rate limiting is disabled when RateLimit is zero.
+	RateLimit float64
+	RateBurst int
+	// MaxConcurrentRequests caps the number of requests a Connector
+	// has in flight, there is no cap when it is zero.
+	MaxConcurrentRequests int
+	// TLS configures the TLS connections to the grid.
+	TLS TLSConfig
+	// HealthCheckInterval is the interval at which a connector which
+	// failed over to a Grid Master Candidate checks whether the endpoints
+	// before it recovered, 30 seconds when it is zero.
+	HealthCheckInterval time.Duration
+
+	// err is the error met by NewTransportConfig, returned by NewConnector
+	err error
 }
 
 func NewTransportConfig(sslVerify string, httpRequestTimeout int, httpPoolConnections int) (cfg TransportConfig) {
@@ -50,13 +95,9 @@ func NewTransportConfig(sslVerify string, httpRequestTimeout int, httpPoolConnec
 		cfg.SslVerify = true
 	default:
 		caPool := x509.NewCertPool()
-		cert, err := ioutil.ReadFile(sslVerify)
-		if err != nil {
-			log.Printf("Cannot load certificate file '%s'", sslVerify)
-			return
-		}
-		if !caPool.AppendCertsFromPEM(cert) {
-			err = fmt.Errorf("cannot append certificate from file '%s'", sslVerify)
+		if err := appendCAFile(caPool, sslVerify); err != nil {
+			slog.Error("cannot load the certificate file", "path", sslVerify, "error", err)
+			cfg.err = err
 			return
 		}
 		cfg.certPool = caPool
@@ -76,6 +117,9 @@ type HttpRequestBuilder interface {
 	BuildRequest(r RequestType, obj IBObject, ref string, queryParams *QueryParams) (req *http.Request, err error)
 }
 
+// HttpRequestor sends requests built by an HttpRequestBuilder.
+// Implementations are expected to honour the request's context,
+// which carries the cancellation and deadline of the calling *Ctx method.
 type HttpRequestor interface {
 	Init(AuthConfig, TransportConfig)
 	SendRequest(*http.Request) ([]byte, error)
@@ -84,6 +128,7 @@ type HttpRequestor interface {
 type WapiRequestBuilder struct {
 	hostCfg HostConfig
 	authCfg AuthConfig
+	logger  *slog.Logger
 }
 
 type WapiRequestBuilderWithHeaders struct {
@@ -112,7 +157,13 @@ func (wrbh *WapiRequestBuilderWithHeaders) BuildRequest(r RequestType, obj IBObj
 }
 
 type WapiHttpRequestor struct {
-	client http.Client
+	client      http.Client
+	retryPolicy RetryPolicy
+	session     *sessionAuth
+	logger      *slog.Logger
+
+	// credentials authenticate the requests when sessions are not reused
+	credentials CredentialProvider
 }
 
 type IBConnector interface {
@@ -122,12 +173,34 @@ type IBConnector interface {
 	UpdateObject(obj IBObject, ref string) (refRes string, err error)
 }
 
+// IBContextConnector is an IBConnector whose calls can be cancelled
+// or bounded by a deadline through a context.Context.
+type IBContextConnector interface {
+	IBConnector
+	CreateObjectCtx(ctx context.Context, obj IBObject) (ref string, err error)
+	GetObjectCtx(ctx context.Context, obj IBObject, ref string, queryParams *QueryParams, res interface{}) error
+	DeleteObjectCtx(ctx context.Context, ref string) (refRes string, err error)
+	UpdateObjectCtx(ctx context.Context, obj IBObject, ref string) (refRes string, err error)
+}
+
+// Compile-time interface checks
+var _ IBContextConnector = new(Connector)
+
 type Connector struct {
 	hostCfg        HostConfig
 	authCfg        AuthConfig
 	transportCfg   TransportConfig
 	requestBuilder HttpRequestBuilder
 	requestor      HttpRequestor
+	throttle       *throttle
+
+	middlewares *middlewareChain
+	schemas     *schemaCache
+	logger      *slog.Logger
+	metrics     MetricsRecorder
+	tracer      Tracer
+	dryRun      atomic.Pointer[dryRun]
+	endpoints   *endpoints
 }
 
 type RequestType int
@@ -157,36 +230,29 @@ func (r RequestType) toMethod() string {
 func getHTTPResponseError(resp *http.Response) error {
 	defer resp.Body.Close()
 	content, _ := ioutil.ReadAll(resp.Body)
-	msg := fmt.Sprintf("WAPI request error: %d('%s')\nContents:\n%s\n", resp.StatusCode, resp.Status, content)
+	wapiErr := newWapiError(resp, content)
 	if resp.StatusCode == http.StatusNotFound {
-		return NewNotFoundError(msg)
+		return &NotFoundError{msg: wapiErr.Error(), err: wapiErr}
 	}
-	return errors.New(msg)
+	return wapiErr
 }
 
 func (whr *WapiHttpRequestor) Init(authCfg AuthConfig, trCfg TransportConfig) {
-	var certList []tls.Certificate
-
-	clientAuthType := tls.NoClientCert
-
-	if authCfg.ClientKey != nil && authCfg.ClientCert != nil {
-		cert, err := tls.X509KeyPair(authCfg.ClientCert, authCfg.ClientKey)
-		if err != nil {
-			log.Fatal("Invalid certificate key pair (PEM format error): ", err)
-		}
+	if err := whr.InitWithError(authCfg, trCfg); err != nil {
+		whr.log().Error("cannot initialize the requestor", "error", err)
+	}
+}
 
-		certList = []tls.Certificate{cert}
-		clientAuthType = tls.RequestClientCert
+// InitWithError is the same as Init but returns the configuration errors,
+// e.g. an invalid client certificate.
+func (whr *WapiHttpRequestor) InitWithError(authCfg AuthConfig, trCfg TransportConfig) error {
+	tlsCfg, err := newTLSConfig(authCfg, trCfg, whr.log)
+	if err != nil {
+		return err
 	}
 
 	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			RootCAs:            trCfg.certPool,
-			ClientAuth:         clientAuthType,
-			Certificates:       certList,
-			InsecureSkipVerify: !trCfg.SslVerify,
-			Renegotiation:      tls.RenegotiateOnceAsClient,
-		},
+		TLSClientConfig:     tlsCfg,
 		MaxIdleConnsPerHost: trCfg.HttpPoolConnections,
 		Proxy:               http.ProxyFromEnvironment,
 	}
@@ -198,7 +264,7 @@ func (whr *WapiHttpRequestor) Init(authCfg AuthConfig, trCfg TransportConfig) {
 	// All users of cookiejar should import "golang.org/x/net/publicsuffix"
 	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	whr.client = http.Client{
@@ -206,23 +272,77 @@ func (whr *WapiHttpRequestor) Init(authCfg AuthConfig, trCfg TransportConfig) {
 		Transport: tr,
 		Timeout:   trCfg.HttpRequestTimeout * time.Second,
 	}
+	whr.retryPolicy = trCfg.RetryPolicy
+
+	whr.session, whr.credentials = nil, nil
+	if provider := authCfg.credentialProvider(); authCfg.ReuseSession && provider != nil {
+		whr.session = &sessionAuth{provider: provider, log: whr.log}
+	} else {
+		whr.credentials = provider
+	}
+	return nil
+}
+
+// do sends req, authenticating it with the session cookie when sessions are
+// reused, and with fresh credentials when WAPI rejected the cached ones
+func (whr *WapiHttpRequestor) do(req *http.Request) (*http.Response, error) {
+	if whr.session != nil {
+		return whr.session.do(&whr.client, req)
+	}
+	resp, err := whr.client.Do(req)
+	if err != nil || whr.credentials == nil {
+		return resp, err
+	}
+	return refreshCredentials(&whr.client, req, resp, whr.credentials, whr.log())
+}
+
+func isSuccessfulResponse(req *http.Request, resp *http.Response) bool {
+	return resp.StatusCode == http.StatusOK ||
+		(resp.StatusCode == http.StatusCreated &&
+			req.Method == CREATE.toMethod())
 }
 
 func (whr *WapiHttpRequestor) SendRequest(req *http.Request) (res []byte, err error) {
 	var resp *http.Response
-	resp, err = whr.client.Do(req)
+	for attempt := 1; ; attempt++ {
+		var attemptReq *http.Request
+		attemptReq, err = requestForAttempt(req, attempt)
+		if err != nil {
+			return
+		}
+		resp, err = whr.do(attemptReq)
+		recordAttempt(req, resp)
+		if whr.retryPolicy == nil || retriesDisabled(req) || (err == nil && isSuccessfulResponse(req, resp)) {
+			break
+		}
+		delay, retry := whr.retryPolicy.ShouldRetry(req, attempt, resp, err)
+		if !retry {
+			break
+		}
+		whr.log().Debug("retrying the WAPI request", "method", req.Method, "url", req.URL.Path,
+			"attempt", attempt, "delay", delay)
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err = sleepContext(req.Context(), delay); err != nil {
+			return
+		}
+		if err = waitForRetry(req); err != nil {
+			return
+		}
+	}
+
 	if err != nil {
 		return
-	} else if !(resp.StatusCode == http.StatusOK ||
-		(resp.StatusCode == http.StatusCreated &&
-			req.Method == CREATE.toMethod())) {
+	} else if !isSuccessfulResponse(req, resp) {
 		err := getHTTPResponseError(resp)
 		return nil, err
 	}
 	defer resp.Body.Close()
 	res, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("Http Reponse ioutil.ReadAll() Error: '%s'", err)
+		whr.log().Error("cannot read the WAPI response", "error", err)
 		return
 	}
 
@@ -253,11 +373,26 @@ func (wrb *WapiRequestBuilder) BuildUrl(t RequestType, objType string, ref strin
 
 	qry := ""
 	vals := url.Values{}
-	if t == GET {
-		if len(returnFields) > 0 {
+	if t == GET && queryParams != nil && queryParams.pageId != "" {
+		// Subsequent pages are identified by the page ID alone
+		vals.Set("_page_id", queryParams.pageId)
+		vals.Set("_return_as_object", "1")
+		if queryParams.forceProxy {
+			vals.Set("_proxy_search", "GM")
+		}
+		qry = vals.Encode()
+	} else if t == GET {
+		if queryParams != nil && len(queryParams.returnFieldsPlus) > 0 {
+			vals.Set("_return_fields+", strings.Join(mergeFields(returnFields, queryParams.returnFieldsPlus), ","))
+		} else if len(returnFields) > 0 {
 			vals.Set("_return_fields", strings.Join(returnFields, ","))
 		}
 		if queryParams != nil {
+			if queryParams.paging {
+				vals.Set("_paging", "1")
+				vals.Set("_max_results", strconv.Itoa(queryParams.maxResults))
+				vals.Set("_return_as_object", "1")
+			}
 			// TODO need to get this from individual objects in future
 			if queryParams.forceProxy {
 				vals.Set("_proxy_search", "GM")
@@ -271,9 +406,20 @@ func (wrb *WapiRequestBuilder) BuildUrl(t RequestType, objType string, ref strin
 					vals.Set(k, v)
 				}
 			}
+			for k, values := range queryParams.search {
+				for _, v := range values {
+					vals.Add(k, v)
+				}
+			}
 		}
 
 		qry = vals.Encode()
+		if queryParams != nil && queryParams.schema {
+			if qry != "" {
+				qry += "&"
+			}
+			qry += "_schema"
+		}
 	}
 
 	scheme := "https"
@@ -317,7 +463,7 @@ func (wrb *WapiRequestBuilder) BuildBody(t RequestType, obj IBObject) []byte {
 
 	objJSON, err = json.Marshal(obj)
 	if err != nil {
-		log.Printf("Cannot marshal object '%s': %s", obj, err)
+		loggerOrDefault(wrb.logger).Error("cannot marshal the object", "object_type", obj.ObjectType(), "error", err)
 		return nil
 	}
 
@@ -325,7 +471,7 @@ func (wrb *WapiRequestBuilder) BuildBody(t RequestType, obj IBObject) []byte {
 	if t == GET && len(eaSearch) > 0 {
 		eaSearchJSON, err := json.Marshal(eaSearch)
 		if err != nil {
-			log.Printf("Cannot marshal EA Search attributes. '%s'\n", err)
+			loggerOrDefault(wrb.logger).Error("cannot marshal the EA search attributes", "error", err)
 			return nil
 		}
 		objJSON = append(append(objJSON[:len(objJSON)-1], byte(',')), eaSearchJSON[1:]...)
@@ -352,33 +498,120 @@ func (wrb *WapiRequestBuilder) BuildRequest(t RequestType, obj IBObject, ref str
 
 	req, err = http.NewRequest(t.toMethod(), urlStr, bytes.NewBuffer(bodyStr))
 	if err != nil {
-		log.Printf("cannot build request: '%s'", err)
+		loggerOrDefault(wrb.logger).Error("cannot build the request", "error", err)
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
-	if wrb.authCfg.Username != "" {
-		req.SetBasicAuth(wrb.authCfg.Username, wrb.authCfg.Password)
+	// the requestor authenticates the requests of reused sessions
+	if provider := wrb.authCfg.credentialProvider(); provider != nil && !wrb.authCfg.ReuseSession {
+		if err = setBasicAuth(req, provider); err != nil {
+			return nil, err
+		}
 	}
 
 	return
 }
 
-func (c *Connector) makeRequest(t RequestType, obj IBObject, ref string, queryParams *QueryParams) (res []byte, err error) {
+// shouldProxyToGM reports whether a failed request is worth sending again
+// with _proxy_search=GM: only searches which the member failed to serve
+// are, validation, authentication and not found errors are final.
+func shouldProxyToGM(t RequestType, err error) bool {
+	if t != GET {
+		return false
+	}
+	wapiErr, ok := asWapiError(err)
+	return ok && wapiErr.StatusCode >= http.StatusInternalServerError
+}
+
+// requestWithContext binds req to ctx, copying it only when necessary.
+func requestWithContext(req *http.Request, ctx context.Context) *http.Request {
+	if req.Context() == ctx {
+		return req
+	}
+	return req.WithContext(ctx)
+}
+
+// sendRequest sends req bound to ctx through the connector's middlewares,
+// once the throttle lets it through. Its retries wait for the rate limit too.
+func (c *Connector) sendRequest(ctx context.Context, req *http.Request) ([]byte, error) {
+	if c.throttle != nil {
+		release, err := c.throttle.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		ctx = c.throttle.withRetries(ctx)
+	}
+	req = requestWithContext(req, ctx)
+	c.injectTraceContext(ctx, req)
+	// the requests are only timed and their stats recorded
+	// when they are logged or their metrics are recorded
+	debug := c.log().Enabled(ctx, slog.LevelDebug)
+	if !debug && c.metrics == nil {
+		return c.sendToEndpoints(req)
+	}
+	req, stats := withRequestStats(req)
+	start := time.Now()
+	res, err := c.sendToEndpoints(req)
+	duration := time.Since(start)
+	if debug {
+		c.logRequest(ctx, req, stats, duration, res, err)
+	}
+	if c.metrics != nil {
+		c.observeRequest(req, stats, duration, res, err)
+	}
+	return res, err
+}
+
+// ThrottleStats returns the time the connector's requests spent waiting
+// for its rate limit and concurrency cap.
+func (c *Connector) ThrottleStats() ThrottleStats {
+	if c.throttle == nil {
+		return ThrottleStats{}
+	}
+	return c.throttle.snapshot()
+}
+
+func (c *Connector) makeRequest(ctx context.Context, t RequestType, obj IBObject, ref string, queryParams *QueryParams) (res []byte, err error) {
+	ctx, span := c.startSpan(ctx, t, obj, ref, queryParams)
+	defer func() { endSpan(span, err) }()
+	return c.makeSpanRequest(ctx, span, t, obj, ref, queryParams)
+}
+
+// makeSpanRequest sends a request in span, which is nil when the connector
+// has no tracer, and sends it again through the Grid Master when it failed
+func (c *Connector) makeSpanRequest(ctx context.Context, span Span, t RequestType, obj IBObject, ref string, queryParams *QueryParams) (res []byte, err error) {
 	var req *http.Request
 	req, err = c.requestBuilder.BuildRequest(t, obj, ref, queryParams)
 	if err != nil {
 		return
 	}
-	res, err = c.requestor.SendRequest(req)
+	if err = c.applySchemaPolicy(ctx, t, obj, req); err != nil {
+		return
+	}
+	if d := c.dryRun.Load(); d != nil {
+		var dryRun bool
+		if res, dryRun, err = c.dryRunResponse(ctx, d, t, obj, ref, req); dryRun {
+			return
+		}
+	}
+	res, err = c.sendRequest(ctx, req)
 	if err != nil {
-		if queryParams != nil {
+		if queryParams != nil && !queryParams.forceProxy && shouldProxyToGM(t, err) {
 			/* Forcing the request to redirect to Grid Master by making forcedProxy=true */
 			queryParams.forceProxy = true
+			c.observeProxyFallback(t, obj, ref)
+			if span != nil {
+				span.AddEvent(ProxyRetryEvent, map[string]string{"error": err.Error()})
+			}
 			req, err = c.requestBuilder.BuildRequest(t, obj, ref, queryParams)
 			if err != nil {
 				return
 			}
-			res, err = c.requestor.SendRequest(req)
+			if err = c.applySchemaPolicy(ctx, t, obj, req); err != nil {
+				return
+			}
+			res, err = c.sendRequest(ctx, req)
 		} else {
 			return nil, err
 		}
@@ -388,17 +621,22 @@ func (c *Connector) makeRequest(t RequestType, obj IBObject, ref string, queryPa
 }
 
 func (c *Connector) CreateObject(obj IBObject) (ref string, err error) {
+	return c.CreateObjectCtx(context.Background(), obj)
+}
+
+// CreateObjectCtx is the same as CreateObject but the request is bound to ctx.
+func (c *Connector) CreateObjectCtx(ctx context.Context, obj IBObject) (ref string, err error) {
 	ref = ""
 	queryParams := NewQueryParams(false, nil)
-	resp, err := c.makeRequest(CREATE, obj, "", queryParams)
+	resp, err := c.makeRequest(ctx, CREATE, obj, "", queryParams)
 	if err != nil || len(resp) == 0 {
-		log.Printf("CreateObject request error: '%s'\n", err)
+		c.log().Error("CreateObject request error", "object_type", obj.ObjectType(), "error", err)
 		return
 	}
 
 	err = json.Unmarshal(resp, &ref)
 	if err != nil {
-		log.Printf("cannot unmarshall '%s', err: '%s'\n", string(resp), err)
+		c.log().Error("cannot unmarshal the response", "response", string(resp), "error", err)
 		return
 	}
 
@@ -409,15 +647,33 @@ func (c *Connector) GetObject(
 	obj IBObject, ref string,
 	queryParams *QueryParams, res interface{}) (err error) {
 
-	resp, err := c.makeRequest(GET, obj, ref, queryParams)
+	return c.GetObjectCtx(context.Background(), obj, ref, queryParams, res)
+}
+
+// GetObjectCtx is the same as GetObject but the request is bound to ctx.
+func (c *Connector) GetObjectCtx(
+	ctx context.Context, obj IBObject, ref string,
+	queryParams *QueryParams, res interface{}) (err error) {
+
+	if ref == "" && queryParams != nil && queryParams.paging {
+		return c.getAllPages(ctx, obj, queryParams, res)
+	}
+
+	// the request sent again through the Grid Master is in the same span
+	ctx, span := c.startSpan(ctx, GET, obj, ref, queryParams)
+	var reqErr error
+	defer func() { endSpan(span, reqErr) }()
+
+	resp, err := c.makeSpanRequest(ctx, span, GET, obj, ref, queryParams)
 	if err != nil {
+		reqErr = err
 		return
 	}
 	//to check empty underlying value of interface
 	var result interface{}
 	err = json.Unmarshal(resp, &result)
 	if err != nil {
-		log.Printf("cannot unmarshall to check empty value '%s': '%s'\n", string(resp), err)
+		c.log().Error("cannot unmarshal the response to check for an empty value", "response", string(resp), "error", err)
 	}
 
 	var data []interface{}
@@ -427,14 +683,19 @@ func (c *Connector) GetObject(
 			return
 		}
 		queryParams.forceProxy = true
-		resp, err = c.makeRequest(GET, obj, ref, queryParams)
+		c.observeProxyFallback(GET, obj, ref)
+		if span != nil {
+			span.AddEvent(ProxyRetryEvent, map[string]string{"reason": "empty result"})
+		}
+		resp, err = c.makeSpanRequest(ctx, span, GET, obj, ref, queryParams)
+		reqErr = err
 	}
 	if err != nil {
-		log.Printf("GetObject request error: '%s'\n", err)
+		c.log().Error("GetObject request error", "object_type", obj.ObjectType(), "ref", ref, "error", err)
 	}
 	err = json.Unmarshal(resp, res)
 	if err != nil {
-		log.Printf("cannot unmarshall '%s', err: '%s'\n", string(resp), err)
+		c.log().Error("cannot unmarshal the response", "response", string(resp), "error", err)
 		return
 	}
 
@@ -446,17 +707,22 @@ func (c *Connector) GetObject(
 }
 
 func (c *Connector) DeleteObject(ref string) (refRes string, err error) {
+	return c.DeleteObjectCtx(context.Background(), ref)
+}
+
+// DeleteObjectCtx is the same as DeleteObject but the request is bound to ctx.
+func (c *Connector) DeleteObjectCtx(ctx context.Context, ref string) (refRes string, err error) {
 	refRes = ""
 	queryParams := NewQueryParams(false, nil)
-	resp, err := c.makeRequest(DELETE, nil, ref, queryParams)
+	resp, err := c.makeRequest(ctx, DELETE, nil, ref, queryParams)
 	if err != nil {
-		log.Printf("DeleteObject request error: '%s'\n", err)
+		c.log().Error("DeleteObject request error", "ref", ref, "error", err)
 		return
 	}
 
 	err = json.Unmarshal(resp, &refRes)
 	if err != nil {
-		log.Printf("cannot unmarshall '%s': '%s'\n", string(resp), err)
+		c.log().Error("cannot unmarshal the response", "response", string(resp), "error", err)
 		return
 	}
 
@@ -464,17 +730,22 @@ func (c *Connector) DeleteObject(ref string) (refRes string, err error) {
 }
 
 func (c *Connector) UpdateObject(obj IBObject, ref string) (refRes string, err error) {
+	return c.UpdateObjectCtx(context.Background(), obj, ref)
+}
+
+// UpdateObjectCtx is the same as UpdateObject but the request is bound to ctx.
+func (c *Connector) UpdateObjectCtx(ctx context.Context, obj IBObject, ref string) (refRes string, err error) {
 	queryParams := NewQueryParams(false, nil)
 	refRes = ""
-	resp, err := c.makeRequest(UPDATE, obj, ref, queryParams)
+	resp, err := c.makeRequest(ctx, UPDATE, obj, ref, queryParams)
 	if err != nil {
-		log.Printf("failed to update object %s: %s", obj.ObjectType(), err)
+		c.log().Error("UpdateObject request error", "object_type", obj.ObjectType(), "ref", ref, "error", err)
 		return
 	}
 
 	err = json.Unmarshal(resp, &refRes)
 	if err != nil {
-		log.Printf("cannot unmarshall update object response'%s', err: '%s'\n", string(resp), err)
+		c.log().Error("cannot unmarshal the response", "response", string(resp), "error", err)
 		return
 	}
 	return
@@ -485,9 +756,9 @@ func (c *Connector) UpdateObject(obj IBObject, ref string) (refRes string, err e
 // initialized.
 func (c *Connector) Logout() (err error) {
 	queryParams := NewQueryParams(false, nil)
-	_, err = c.makeRequest(CREATE, nil, "logout", queryParams)
+	_, err = c.makeRequest(context.Background(), CREATE, nil, "logout", queryParams)
 	if err != nil {
-		log.Printf("Logout request error: '%s'\n", err)
+		c.log().Error("Logout request error", "error", err)
 	}
 
 	return
@@ -510,11 +781,18 @@ func validateConnector(c *Connector) (err error) {
 func NewConnector(hostConfig HostConfig, authCfg AuthConfig, transportConfig TransportConfig,
 	requestBuilder HttpRequestBuilder, requestor HttpRequestor) (res *Connector, err error) {
 	res = nil
+	if transportConfig.err != nil {
+		return nil, transportConfig.err
+	}
 
 	connector := &Connector{
 		hostCfg:      hostConfig,
 		authCfg:      authCfg,
 		transportCfg: transportConfig,
+		throttle:     newThrottle(transportConfig),
+		middlewares:  &middlewareChain{},
+		schemas:      &schemaCache{},
+		endpoints:    newEndpoints(hostConfig, transportConfig),
 	}
 
 	//connector.requestBuilder = WapiRequestBuilder{WaipHostConfig: connector.hostCfg}
@@ -522,7 +800,9 @@ func NewConnector(hostConfig HostConfig, authCfg AuthConfig, transportConfig Tra
 	connector.requestBuilder.Init(connector.hostCfg, connector.authCfg)
 
 	connector.requestor = requestor
-	connector.requestor.Init(connector.authCfg, connector.transportCfg)
+	if err = initRequestor(connector.requestor, connector.authCfg, connector.transportCfg); err != nil {
+		return nil, err
+	}
 
 	res = connector
 	err = ValidateConnector(connector)
@@ -0,0 +1,104 @@
+package ibclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// DefaultPageSize is the number of objects requested per page when
+// paging is enabled without an explicit page size.
+const DefaultPageSize = 1000
+
+// pagedResult is the WAPI response to a paged search,
+// requested with _return_as_object=1.
+type pagedResult struct {
+	Result     json.RawMessage `json:"result"`
+	NextPageId string          `json:"next_page_id"`
+}
+
+// ForEachPage searches for objects of obj's type page by page.
+// Each page is unmarshalled into page, which must be a pointer to a slice,
+// and then fn is called. Iteration stops when there are no more pages
+// or fn returns an error, which is then returned by ForEachPage.
+// The page size is taken from queryParams (see QueryParams.SetPaging),
+// DefaultPageSize is used when paging is not enabled there.
+func (c *Connector) ForEachPage(obj IBObject, queryParams *QueryParams, page interface{}, fn func() error) error {
+	return c.ForEachPageCtx(context.Background(), obj, queryParams, page, fn)
+}
+
+// ForEachPageCtx is the same as ForEachPage but the requests are bound to ctx.
+func (c *Connector) ForEachPageCtx(
+	ctx context.Context, obj IBObject,
+	queryParams *QueryParams, page interface{}, fn func() error) error {
+
+	pageVal := reflect.ValueOf(page)
+	if pageVal.Kind() != reflect.Ptr || pageVal.IsNil() || pageVal.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("page must be a non-nil pointer to a slice, got %T", page)
+	}
+
+	// Work on a copy, so that the caller's query parameters are left intact
+	pageParams := NewQueryParams(false, nil)
+	if queryParams != nil {
+		qp := *queryParams
+		pageParams = &qp
+	}
+	if !pageParams.paging {
+		pageParams.SetPaging(DefaultPageSize)
+	}
+	pageParams.pageId = ""
+
+	for {
+		resp, err := c.makeRequest(ctx, GET, obj, "", pageParams)
+		if err != nil {
+			return err
+		}
+
+		var res pagedResult
+		if err = json.Unmarshal(resp, &res); err != nil {
+			return fmt.Errorf("cannot unmarshall paged result '%s': %s", string(resp), err)
+		}
+
+		// Reset the page, json.Unmarshal would otherwise reuse its elements
+		pageVal.Elem().Set(reflect.Zero(pageVal.Elem().Type()))
+		if err = json.Unmarshal(res.Result, page); err != nil {
+			return fmt.Errorf("cannot unmarshall '%s', err: '%s'", string(res.Result), err)
+		}
+
+		if err = fn(); err != nil {
+			return err
+		}
+
+		if res.NextPageId == "" {
+			return nil
+		}
+		pageParams.pageId = res.NextPageId
+	}
+}
+
+// getAllPages fetches every page of a paged search and accumulates the
+// objects into res, which must be a pointer to a slice.
+func (c *Connector) getAllPages(ctx context.Context, obj IBObject, queryParams *QueryParams, res interface{}) error {
+	resVal := reflect.ValueOf(res)
+	if resVal.Kind() != reflect.Ptr || resVal.IsNil() || resVal.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("paged search results require a pointer to a slice, got %T", res)
+	}
+
+	all := reflect.MakeSlice(resVal.Elem().Type(), 0, 0)
+	page := reflect.New(resVal.Elem().Type())
+	err := c.ForEachPageCtx(ctx, obj, queryParams, page.Interface(), func() error {
+		all = reflect.AppendSlice(all, page.Elem())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	resVal.Elem().Set(all)
+	if all.Len() == 0 {
+		return NewNotFoundError("not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package ibclient
+
+import (
+	"errors"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// pagingHttpRequestor serves paged results keyed by the _page_id of the request.
+type pagingHttpRequestor struct {
+	pages map[string]string
+	urls  []string
+}
+
+func (hr *pagingHttpRequestor) Init(authCfg AuthConfig, trCfg TransportConfig) {}
+
+func (hr *pagingHttpRequestor) SendRequest(req *http.Request) ([]byte, error) {
+	hr.urls = append(hr.urls, req.URL.String())
+	res, ok := hr.pages[req.URL.Query().Get("_page_id")]
+	if !ok {
+		return nil, errors.New("unexpected page")
+	}
+	return []byte(res), nil
+}
+
+var _ = Describe("Connector paging", func() {
+	hostCfg := HostConfig{Host: "172.22.18.66", Version: "2.12.1", Port: "443"}
+	authCfg := AuthConfig{Username: "myname", Password: "mysecrete!"}
+	transportConfig := NewTransportConfig("false", 120, 100)
+
+	var (
+		hr   *pagingHttpRequestor
+		conn *Connector
+	)
+
+	BeforeEach(func() {
+		hr = &pagingHttpRequestor{pages: map[string]string{
+			"":      `{"result":[{"_ref":"record:a/1","name":"a1.test.com"}],"next_page_id":"page2"}`,
+			"page2": `{"result":[{"_ref":"record:a/2","name":"a2.test.com"}],"next_page_id":"page3"}`,
+			"page3": `{"result":[{"_ref":"record:a/3","name":"a3.test.com"}]}`,
+		}}
+		wrb, _ := NewWapiRequestBuilder(hostCfg, authCfg)
+
+		OrigValidateConnector := ValidateConnector
+		ValidateConnector = MockValidateConnector
+		defer func() { ValidateConnector = OrigValidateConnector }()
+
+		var err error
+		conn, err = NewConnector(hostCfg, authCfg, transportConfig, wrb, hr)
+		Expect(err).To(BeNil())
+	})
+
+	Describe("BuildUrl", func() {
+		wrb, _ := NewWapiRequestBuilder(hostCfg, authCfg)
+
+		It("should request the first page with paging arguments", func() {
+			qp := NewQueryParams(false, map[string]string{"view": "default"})
+			qp.SetPaging(500)
+			urlStr := wrb.BuildUrl(GET, "record:a", "", []string{"name"}, qp)
+			Expect(urlStr).To(Equal("https://172.22.18.66:443/wapi/v2.12.1/record:a?" +
+				"_max_results=500&_paging=1&_return_as_object=1&_return_fields=name&view=default"))
+		})
+
+		It("should request subsequent pages by page ID only", func() {
+			qp := NewQueryParams(false, map[string]string{"view": "default"})
+			qp.SetPaging(500)
+			qp.pageId = "page2"
+			urlStr := wrb.BuildUrl(GET, "record:a", "", []string{"name"}, qp)
+			Expect(urlStr).To(Equal("https://172.22.18.66:443/wapi/v2.12.1/record:a?" +
+				"_page_id=page2&_return_as_object=1"))
+		})
+	})
+
+	Describe("GetObject", func() {
+		It("should accumulate all pages when paging is enabled", func() {
+			qp := NewQueryParams(false, nil)
+			qp.SetPaging(1)
+			var res []RecordA
+			err := conn.GetObject(NewEmptyRecordA(), "", qp, &res)
+			Expect(err).To(BeNil())
+			Expect(res).To(HaveLen(3))
+			Expect(res[2].Ref).To(Equal("record:a/3"))
+			Expect(hr.urls).To(HaveLen(3))
+			Expect(qp.pageId).To(BeEmpty())
+		})
+
+		It("should return NotFoundError when there are no results", func() {
+			hr.pages = map[string]string{"": `{"result":[]}`}
+			qp := NewQueryParams(false, nil)
+			qp.SetPaging(0)
+			Expect(qp.maxResults).To(Equal(DefaultPageSize))
+			var res []RecordA
+			err := conn.GetObject(NewEmptyRecordA(), "", qp, &res)
+			Expect(err).To(BeAssignableToTypeOf(&NotFoundError{}))
+			Expect(res).To(BeEmpty())
+		})
+
+		It("should reject a result which is not a slice", func() {
+			qp := NewQueryParams(false, nil)
+			qp.SetPaging(1)
+			res := NewEmptyRecordA()
+			err := conn.GetObject(NewEmptyRecordA(), "", qp, res)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("ForEachPage", func() {
+		It("should call fn once per page", func() {
+			var (
+				page  []*RecordA
+				names []string
+			)
+			err := conn.ForEachPage(NewEmptyRecordA(), nil, &page, func() error {
+				Expect(page).To(HaveLen(1))
+				names = append(names, *page[0].Name)
+				return nil
+			})
+			Expect(err).To(BeNil())
+			Expect(names).To(Equal([]string{"a1.test.com", "a2.test.com", "a3.test.com"}))
+		})
+
+		It("should stop when fn returns an error", func() {
+			var page []RecordA
+			stop := errors.New("stop")
+			calls := 0
+			err := conn.ForEachPage(NewEmptyRecordA(), nil, &page, func() error {
+				calls++
+				return stop
+			})
+			Expect(err).To(Equal(stop))
+			Expect(calls).To(Equal(1))
+			Expect(hr.urls).To(HaveLen(1))
+		})
+	})
+})
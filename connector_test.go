@@ -2,6 +2,7 @@ package ibclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -69,6 +70,22 @@ func (hr *FakeHttpRequestor) SendRequest(req *http.Request) ([]byte, error) {
 	return hr.res, nil
 }
 
+// ctxHttpRequestor records the context of each request it is given.
+type ctxHttpRequestor struct {
+	ctxs []context.Context
+	res  []byte
+}
+
+func (hr *ctxHttpRequestor) Init(authCfg AuthConfig, trCfg TransportConfig) {}
+
+func (hr *ctxHttpRequestor) SendRequest(req *http.Request) ([]byte, error) {
+	hr.ctxs = append(hr.ctxs, req.Context())
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	return hr.res, nil
+}
+
 func MockValidateConnector(c *Connector) (err error) {
 	return
 }
@@ -479,6 +496,56 @@ var _ = Describe("Connector", func() {
 				Expect(actual).To(Equal(expectObj))
 			})
 		})
+		Describe("Context-aware methods", func() {
+			type ctxKey struct{}
+			netViewObj := NewNetworkView("private-view", "", nil, "")
+			expectRef := "networkview/ZG5zLm5ldHdvcmtfdmlldyQyMw:global_view/false"
+
+			var (
+				hr   *ctxHttpRequestor
+				conn *Connector
+			)
+			BeforeEach(func() {
+				wrb, _ := NewWapiRequestBuilder(hostCfg, authCfg)
+				hr = &ctxHttpRequestor{res: []byte(`"` + expectRef + `"`)}
+
+				OrigValidateConnector := ValidateConnector
+				ValidateConnector = MockValidateConnector
+				defer func() { ValidateConnector = OrigValidateConnector }()
+
+				var err error
+				conn, err = NewConnector(hostCfg, authCfg, transportConfig, wrb, hr)
+				Expect(err).To(BeNil())
+			})
+
+			It("should pass the given context to the requestor", func() {
+				ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+				ref, err := conn.CreateObjectCtx(ctx, netViewObj)
+				Expect(err).To(BeNil())
+				Expect(ref).To(Equal(expectRef))
+				Expect(hr.ctxs).To(HaveLen(1))
+				Expect(hr.ctxs[0].Value(ctxKey{})).To(Equal("value"))
+			})
+
+			It("should not retry through the Grid Master once the context is cancelled", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				var res []NetworkView
+				err := conn.GetObjectCtx(ctx, netViewObj, "", NewQueryParams(false, nil), &res)
+				Expect(err).To(MatchError(context.Canceled))
+				Expect(hr.ctxs).To(HaveLen(1))
+			})
+
+			It("should bind ObjectManager calls to the given context", func() {
+				ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+				objMgr := NewObjectManager(conn, "Heka", "0123").(IBContextObjectManager).WithContext(ctx)
+				_, err := objMgr.DeleteNetworkView(expectRef)
+				Expect(err).To(BeNil())
+				Expect(hr.ctxs).To(HaveLen(1))
+				Expect(hr.ctxs[0].Value(ctxKey{})).To(Equal("value"))
+			})
+		})
+
 		Describe("makeRequest", func() {
 			Context("for GET request", func() {
 				netviewName := "private-view"
@@ -531,14 +598,14 @@ var _ = Describe("Connector", func() {
 				actual := NewEmptyNetworkView()
 				It("should return expected object when forceProxy is false", func() {
 					queryParams.forceProxy = false //disable proxy
-					res, err := conn.makeRequest(GET, netViewObj, ref, queryParams)
+					res, err := conn.makeRequest(context.Background(), GET, netViewObj, ref, queryParams)
 					err = json.Unmarshal(res, &actual)
 					Expect(err).To(BeNil())
 					Expect(actual).To(Equal(expectObj))
 				})
 				It("should return expected object when forceProxy is true", func() {
 					queryParams.forceProxy = true //enable proxy
-					res, err := conn.makeRequest(GET, netViewObj, ref, queryParams)
+					res, err := conn.makeRequest(context.Background(), GET, netViewObj, ref, queryParams)
 					err = json.Unmarshal(res, &actual)
 					Expect(err).To(BeNil())
 					Expect(actual).To(Equal(expectObj))
@@ -0,0 +1,278 @@
+package ibclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// sessionCookie is the cookie WAPI authenticates sessions with
+const sessionCookie = "ibapauth"
+
+// Credentials are the username and password to authenticate to WAPI with.
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// CredentialProvider returns the credentials to authenticate to WAPI with,
+// see AuthConfig.Credentials. It is called each time a request authenticates,
+// which is once per session when AuthConfig.ReuseSession is set, so that
+// rotated credentials are picked up.
+type CredentialProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// StaticCredentials always returns the same credentials.
+type StaticCredentials Credentials
+
+func (c StaticCredentials) Credentials() (Credentials, error) {
+	return Credentials(c), nil
+}
+
+// EnvCredentials reads the credentials from environment variables,
+// INFOBLOX_USERNAME and INFOBLOX_PASSWORD when the names are empty.
+type EnvCredentials struct {
+	UsernameVar string
+	PasswordVar string
+}
+
+func (c EnvCredentials) Credentials() (Credentials, error) {
+	usernameVar, passwordVar := c.UsernameVar, c.PasswordVar
+	if usernameVar == "" {
+		usernameVar = "INFOBLOX_USERNAME"
+	}
+	if passwordVar == "" {
+		passwordVar = "INFOBLOX_PASSWORD"
+	}
+	username, ok := os.LookupEnv(usernameVar)
+	if !ok {
+		return Credentials{}, fmt.Errorf("the environment variable %s is not set", usernameVar)
+	}
+	return Credentials{Username: username, Password: os.Getenv(passwordVar)}, nil
+}
+
+// FileCredentials reads the credentials from a JSON file such as
+// {"username": "admin", "password": "infoblox"}, e.g. a mounted secret.
+// The file is read again when its modification time or size changes,
+// so that rotated credentials are used for the next authentication.
+type FileCredentials struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	creds   Credentials
+}
+
+// NewFileCredentials returns a provider reading the credentials from path.
+func NewFileCredentials(path string) *FileCredentials {
+	return &FileCredentials{path: path}
+}
+
+func (c *FileCredentials) Credentials() (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("cannot read the credentials: %s", err)
+	}
+	if info.ModTime().Equal(c.modTime) && info.Size() == c.size {
+		return c.creds, nil
+	}
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("cannot read the credentials: %s", err)
+	}
+	var creds Credentials
+	if err = json.Unmarshal(data, &creds); err != nil {
+		return Credentials{}, fmt.Errorf("cannot parse the credentials in %s: %s", c.path, err)
+	}
+	c.creds, c.modTime, c.size = creds, info.ModTime(), info.Size()
+	return creds, nil
+}
+
+// defaultCommandTTL is how long the output of a CommandCredentials command
+// is used when its TTL is zero
+const defaultCommandTTL = 5 * time.Minute
+
+// defaultCommandTimeout is the maximum duration of a CommandCredentials
+// command when its Timeout is zero
+const defaultCommandTimeout = 30 * time.Second
+
+// CredentialRefresher is implemented by the credential providers caching
+// their credentials. Refresh drops the cached credentials when WAPI rejects
+// them, and the request is sent again with the credentials read anew.
+type CredentialRefresher interface {
+	Refresh()
+}
+
+// CommandCredentials runs a command, e.g. a secret manager's CLI, which
+// prints the credentials as JSON: {"username": "admin", "password": "..."}.
+// Its output is cached for TTL, 5 minutes when it is zero, and dropped
+// when WAPI rejects the credentials, so that the command runs again.
+type CommandCredentials struct {
+	Command string
+	Args    []string
+
+	// TTL is how long the output of the command is used
+	TTL time.Duration
+
+	// Timeout is the maximum duration of the command, 30 seconds when
+	// it is zero
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	creds   Credentials
+	expires time.Time
+}
+
+func (c *CommandCredentials) Credentials() (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Now().Before(c.expires) {
+		return c.creds, nil
+	}
+	creds, err := c.run()
+	if err != nil {
+		return Credentials{}, err
+	}
+	ttl := c.TTL
+	if ttl == 0 {
+		ttl = defaultCommandTTL
+	}
+	c.creds, c.expires = creds, time.Now().Add(ttl)
+	return creds, nil
+}
+
+func (c *CommandCredentials) Refresh() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.creds, c.expires = Credentials{}, time.Time{}
+}
+
+func (c *CommandCredentials) run() (Credentials, error) {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Command, c.Args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return Credentials{}, fmt.Errorf("the credentials command %s timed out after %s", c.Command, timeout)
+	}
+	if err != nil {
+		return Credentials{}, fmt.Errorf("the credentials command %s failed: %s: %s", c.Command, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	var creds Credentials
+	if err = json.Unmarshal(out, &creds); err != nil {
+		return Credentials{}, fmt.Errorf("cannot parse the output of the credentials command %s: %s", c.Command, err)
+	}
+	return creds, nil
+}
+
+// credentialProvider returns the provider of the credentials of cfg:
+// Credentials, or Username and Password when it is nil
+func (cfg AuthConfig) credentialProvider() CredentialProvider {
+	if cfg.Credentials != nil {
+		return cfg.Credentials
+	}
+	if cfg.Username != "" {
+		return StaticCredentials{Username: cfg.Username, Password: cfg.Password}
+	}
+	return nil
+}
+
+// setBasicAuth sets the basic authentication of req with the provider's credentials
+func setBasicAuth(req *http.Request, provider CredentialProvider) error {
+	creds, err := provider.Credentials()
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(creds.Username, creds.Password)
+	return nil
+}
+
+// sessionAuth authenticates requests with the ibapauth cookie of the
+// client's session, and with the provider's credentials when there is
+// no session yet or it expired
+type sessionAuth struct {
+	provider CredentialProvider
+	log      func() *slog.Logger
+}
+
+func (a *sessionAuth) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if client.Jar != nil && hasCookie(client.Jar.Cookies(req.URL), sessionCookie) {
+		resp, err := client.Do(req)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized {
+			return resp, err
+		}
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		a.log().Debug("the WAPI session expired, authenticating again", "url", req.URL.Path)
+
+		// the body was sent, rewind it
+		if req, err = requestForAttempt(req, 2); err != nil {
+			return nil, err
+		}
+	}
+
+	authReq := req.Clone(req.Context())
+	if err := setBasicAuth(authReq, a.provider); err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(authReq)
+	if err != nil {
+		return nil, err
+	}
+	return refreshCredentials(client, req, resp, a.provider, a.log())
+}
+
+// refreshCredentials sends req again with fresh credentials when WAPI
+// rejected the credentials cached by the provider with resp
+func refreshCredentials(client *http.Client, req *http.Request, resp *http.Response, provider CredentialProvider, log *slog.Logger) (*http.Response, error) {
+	refresher, ok := provider.(CredentialRefresher)
+	if !ok || resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	log.Debug("WAPI rejected the credentials, reading them again", "url", req.URL.Path)
+	refresher.Refresh()
+
+	// the body was sent, rewind it
+	req, err := requestForAttempt(req, 2)
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	if err = setBasicAuth(req, provider); err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+func hasCookie(cookies []*http.Cookie, name string) bool {
+	for _, c := range cookies {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
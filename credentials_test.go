@@ -0,0 +1,155 @@
+package ibclient_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type failingCredentials struct{}
+
+func (failingCredentials) Credentials() (ibclient.Credentials, error) {
+	return ibclient.Credentials{}, errors.New("vault is sealed")
+}
+
+var _ = Describe("Credentials", func() {
+	var server *fakewapi.Server
+
+	newConnector := func(authCfg ibclient.AuthConfig) *ibclient.Connector {
+		return connectToFake(server, authCfg, server.Version)
+	}
+
+	getViews := func(conn *ibclient.Connector) error {
+		_, err := ibclient.List[ibclient.View](conn, nil)
+		return err
+	}
+
+	BeforeEach(func() {
+		server = fakewapi.NewServer()
+		DeferCleanup(server.Close)
+		server.SetCredentials("admin", "infoblox")
+	})
+
+	It("should send the credentials with every request by default", func() {
+		conn := newConnector(ibclient.AuthConfig{Username: "admin", Password: "infoblox"})
+		Expect(getViews(conn)).To(Succeed())
+		Expect(getViews(conn)).To(Succeed())
+		Expect(server.Logins()).To(Equal(2))
+	})
+
+	It("should authenticate once when sessions are reused", func() {
+		conn := newConnector(ibclient.AuthConfig{
+			Credentials:  ibclient.StaticCredentials{Username: "admin", Password: "infoblox"},
+			ReuseSession: true,
+		})
+		Expect(getViews(conn)).To(Succeed())
+		Expect(getViews(conn)).To(Succeed())
+		Expect(getViews(conn)).To(Succeed())
+		Expect(server.Logins()).To(Equal(1))
+	})
+
+	It("should authenticate again when the session expires", func() {
+		conn := newConnector(ibclient.AuthConfig{Username: "admin", Password: "infoblox", ReuseSession: true})
+		Expect(getViews(conn)).To(Succeed())
+
+		server.ExpireSessions()
+		nsg, err := ibclient.Create(conn, &ibclient.Nsgroup{Name: utils.StringPtr("ns-group")})
+		Expect(err).To(BeNil())
+		Expect(*nsg.Name).To(Equal("ns-group"))
+		Expect(server.Logins()).To(Equal(2))
+	})
+
+	It("should read rotated credentials from a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "credentials.json")
+		Expect(os.WriteFile(path, []byte(`{"username": "admin", "password": "infoblox"}`), 0600)).To(Succeed())
+		conn := newConnector(ibclient.AuthConfig{Credentials: ibclient.NewFileCredentials(path), ReuseSession: true})
+		Expect(getViews(conn)).To(Succeed())
+
+		server.SetCredentials("admin", "rotated-password")
+		Expect(os.WriteFile(path, []byte(`{"username": "admin", "password": "rotated-password"}`), 0600)).To(Succeed())
+		server.ExpireSessions()
+		Expect(getViews(conn)).To(Succeed())
+	})
+
+	It("should read the credentials from environment variables", func() {
+		GinkgoT().Setenv("WAPI_USER", "admin")
+		GinkgoT().Setenv("WAPI_PASSWORD", "infoblox")
+
+		creds, err := ibclient.EnvCredentials{UsernameVar: "WAPI_USER", PasswordVar: "WAPI_PASSWORD"}.Credentials()
+		Expect(err).To(BeNil())
+		Expect(creds).To(Equal(ibclient.Credentials{Username: "admin", Password: "infoblox"}))
+
+		_, err = ibclient.EnvCredentials{UsernameVar: "WAPI_NO_SUCH_USER"}.Credentials()
+		Expect(err).To(MatchError("the environment variable WAPI_NO_SUCH_USER is not set"))
+	})
+
+	It("should read the credentials printed by a command", func() {
+		creds, err := (&ibclient.CommandCredentials{
+			Command: "echo", Args: []string{`{"username": "admin", "password": "infoblox"}`},
+		}).Credentials()
+		Expect(err).To(BeNil())
+		Expect(creds).To(Equal(ibclient.Credentials{Username: "admin", Password: "infoblox"}))
+
+		_, err = (&ibclient.CommandCredentials{Command: "false"}).Credentials()
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should cache the output of the credentials command", func() {
+		runs := filepath.Join(GinkgoT().TempDir(), "runs")
+		provider := &ibclient.CommandCredentials{
+			Command: "sh",
+			Args:    []string{"-c", `echo run >> "$0"; echo '{"username": "admin", "password": "infoblox"}'`, runs},
+		}
+		for i := 0; i < 3; i++ {
+			_, err := provider.Credentials()
+			Expect(err).To(BeNil())
+		}
+		Expect(os.ReadFile(runs)).To(Equal([]byte("run\n")))
+
+		provider.Refresh()
+		_, err := provider.Credentials()
+		Expect(err).To(BeNil())
+		Expect(os.ReadFile(runs)).To(Equal([]byte("run\nrun\n")))
+	})
+
+	It("should stop the credentials command after its timeout", func() {
+		_, err := (&ibclient.CommandCredentials{
+			Command: "sleep", Args: []string{"5"}, Timeout: 50 * time.Millisecond,
+		}).Credentials()
+		Expect(err).To(MatchError("the credentials command sleep timed out after 50ms"))
+	})
+
+	It("should run the credentials command again when WAPI rejects its output", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "credentials.json")
+		Expect(os.WriteFile(path, []byte(`{"username": "admin", "password": "infoblox"}`), 0600)).To(Succeed())
+		provider := &ibclient.CommandCredentials{Command: "cat", Args: []string{path}, TTL: time.Hour}
+
+		for _, reuseSession := range []bool{false, true} {
+			conn := newConnector(ibclient.AuthConfig{Credentials: provider, ReuseSession: reuseSession})
+			Expect(getViews(conn)).To(Succeed())
+
+			password := fmt.Sprintf("rotated-%t", reuseSession)
+			server.SetCredentials("admin", password)
+			Expect(os.WriteFile(path, []byte(`{"username": "admin", "password": "`+password+`"}`), 0600)).To(Succeed())
+			server.ExpireSessions()
+			Expect(getViews(conn)).To(Succeed())
+		}
+	})
+
+	It("should fail the requests when the credentials can't be read", func() {
+		conn := newConnector(ibclient.AuthConfig{Credentials: failingCredentials{}})
+		Expect(getViews(conn)).To(MatchError("vault is sealed"))
+
+		conn = newConnector(ibclient.AuthConfig{Credentials: failingCredentials{}, ReuseSession: true})
+		Expect(getViews(conn)).To(MatchError(ContainSubstring("vault is sealed")))
+	})
+})
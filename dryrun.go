@@ -0,0 +1,198 @@
+package ibclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// DryRunRequest is a request which changes objects and which a connector
+// in dry-run mode built but didn't send, see Connector.SetDryRun.
+type DryRunRequest struct {
+	Method string
+	URL    string
+	Body   []byte
+
+	// Ref is the reference returned for the request: a synthetic
+	// reference for the objects it would have created
+	Ref string
+}
+
+// dryRun holds the requests of a connector in dry-run mode, and the
+// objects they would have created, which are read by their references.
+// The connector keeps it in an atomic pointer, so that the mode can be
+// switched while requests are made.
+type dryRun struct {
+	mu       sync.Mutex
+	count    int
+	requests []DryRunRequest
+	objects  map[string]map[string]interface{}
+}
+
+// SetDryRun enables or disables the dry-run mode of the connector.
+//
+// In dry-run mode, the requests changing objects, i.e. CreateObject,
+// UpdateObject, DeleteObject and multi-requests, are built and logged but
+// not sent: see DryRunRequests. CreateObject returns a synthetic reference,
+// which GetObject reads the object built by the request from. The other
+// GET requests are sent, so that the flow of the callers stays realistic.
+// Calls to WAPI functions are not sent either, except the ones which only
+// read, e.g. next_available_ip, and Logout.
+func (c *Connector) SetDryRun(enabled bool) {
+	if !enabled {
+		c.dryRun.Store(nil)
+		return
+	}
+	c.dryRun.CompareAndSwap(nil, &dryRun{objects: map[string]map[string]interface{}{}})
+}
+
+// DryRunRequests returns the requests the connector didn't send since its
+// dry-run mode was enabled, in the order they were made.
+func (c *Connector) DryRunRequests() []DryRunRequest {
+	d := c.dryRun.Load()
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]DryRunRequest(nil), d.requests...)
+}
+
+// SetDryRun enables or disables the dry-run mode of the object manager's
+// connector, see Connector.SetDryRun.
+func (objMgr *ObjectManager) SetDryRun(enabled bool) error {
+	base, _ := unwrapConnector(objMgr.connector)
+	conn, ok := base.(*Connector)
+	if !ok {
+		return fmt.Errorf("the dry-run mode needs a *Connector, not %T", base)
+	}
+	conn.SetDryRun(enabled)
+	return nil
+}
+
+// readOnlyFunctions are the WAPI functions which change no object, the
+// calls to which are sent in dry-run mode
+var readOnlyFunctions = map[string]bool{
+	"next_available_ip":      true,
+	"next_available_network": true,
+	"next_available_vlan":    true,
+}
+
+// sentInDryRun reports whether a POST request is sent in dry-run mode:
+// a logout, or a call to a function which changes no object
+func sentInDryRun(ref string, req *http.Request) bool {
+	return ref == "logout" || readOnlyFunctions[req.URL.Query().Get("_function")]
+}
+
+// dryRunResponse returns the response to a request in dry-run mode,
+// and false for the requests which are sent
+func (c *Connector) dryRunResponse(ctx context.Context, d *dryRun, t RequestType, obj IBObject, ref string, req *http.Request) ([]byte, bool, error) {
+	if t == CREATE && sentInDryRun(ref, req) {
+		return nil, false, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if t == GET {
+		fields, ok := d.objects[ref]
+		if !ok {
+			return nil, false, nil
+		}
+		res, err := json.Marshal(fields)
+		return res, true, err
+	}
+
+	var body []byte
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err != nil {
+			return nil, true, err
+		}
+		body, err = ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, true, err
+		}
+	}
+
+	var res interface{}
+	resRef := ref
+	switch {
+	case t == CREATE && obj != nil && ref == "":
+		if multi, ok := obj.(*MultiRequest); ok {
+			res = d.multiResults(multi)
+			break
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, true, err
+		}
+		resRef = d.newRef(obj.ObjectType(), fields)
+		fields["_ref"] = resRef
+		d.objects[resRef] = fields
+		res = resRef
+	case t == CREATE:
+		// a call to a function changing objects, e.g. restartservices
+		resRef = ""
+	case t == UPDATE:
+		if fields, ok := d.objects[ref]; ok {
+			if err := json.Unmarshal(body, &fields); err != nil {
+				return nil, true, err
+			}
+		}
+		res = ref
+	case t == DELETE:
+		delete(d.objects, ref)
+		res = ref
+	}
+
+	d.requests = append(d.requests, DryRunRequest{Method: req.Method, URL: req.URL.String(), Body: body, Ref: resRef})
+	c.log().InfoContext(ctx, "dry run, the WAPI request was not sent", "method", req.Method,
+		"url", req.URL.String(), "body", string(scrubJSON(body, DefaultScrubbedFields)), "ref", resRef)
+
+	data, err := json.Marshal(res)
+	return data, true, err
+}
+
+// newRef returns a synthetic reference for an object of objType
+func (d *dryRun) newRef(objType string, fields map[string]interface{}) string {
+	d.count++
+	name, _ := fields["name"].(string)
+	view, _ := fields["view"].(string)
+	if view == "" {
+		view, _ = fields["network_view"].(string)
+	}
+	return Ref{
+		ObjectType: objType,
+		ID:         base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("dryrun$%d", d.count))),
+		Name:       name,
+		View:       view,
+	}.String()
+}
+
+// multiResults returns the results of the steps of a multi-request which
+// are not discarded: synthetic references for the objects it creates, the
+// references of the objects it changes and no objects for its searches
+func (d *dryRun) multiResults(req *MultiRequest) []interface{} {
+	res := []interface{}{}
+	for _, body := range req.Body {
+		if body.Discard {
+			continue
+		}
+		switch body.Method {
+		case "POST":
+			res = append(res, d.newRef(body.Object, body.Data))
+		case "PUT", "DELETE":
+			res = append(res, body.Object)
+		case "GET":
+			res = append(res, []interface{}{})
+		default:
+			res = append(res, map[string]interface{}{})
+		}
+	}
+	return res
+}
@@ -0,0 +1,51 @@
+package ibclient
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dry run of function calls", func() {
+	hostCfg := HostConfig{Host: "172.22.18.66", Version: "2.12.1", Port: "443"}
+	networkRef := "network/ZG5zLm5ldHdvcmskMTAuMC4wLjAvMjQvMA:10.0.0.0%2F24/default"
+
+	var conn *Connector
+
+	BeforeEach(func() {
+		OrigValidateConnector := ValidateConnector
+		ValidateConnector = MockValidateConnector
+		defer func() { ValidateConnector = OrigValidateConnector }()
+
+		wrb, _ := NewWapiRequestBuilder(hostCfg, AuthConfig{})
+		var err error
+		conn, err = NewConnector(hostCfg, AuthConfig{}, NewTransportConfig("false", 10, 1), wrb, &recordingHttpRequestor{})
+		Expect(err).To(BeNil())
+		conn.SetDryRun(true)
+	})
+
+	call := func(function string) (bool, error) {
+		req, err := http.NewRequest("POST", "https://172.22.18.66:443/wapi/v2.12.1/"+networkRef+"?_function="+function,
+			bytes.NewBufferString(`{"num": 2}`))
+		Expect(err).To(BeNil())
+		_, dryRun, err := conn.dryRunResponse(context.Background(), conn.dryRun.Load(), CREATE, nil, networkRef, req)
+		return dryRun, err
+	}
+
+	It("should send the calls to the functions which only read", func() {
+		Expect(call("next_available_ip")).To(BeFalse())
+		Expect(call("next_available_network")).To(BeFalse())
+		Expect(conn.DryRunRequests()).To(BeEmpty())
+	})
+
+	It("should not send the calls to the functions which change objects", func() {
+		Expect(call("restartservices")).To(BeTrue())
+		requests := conn.DryRunRequests()
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].URL).To(HaveSuffix("?_function=restartservices"))
+		Expect(requests[0].Ref).To(BeEmpty())
+	})
+})
@@ -0,0 +1,144 @@
+package ibclient_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dry run", func() {
+	var (
+		server *fakewapi.Server
+		conn   *ibclient.Connector
+		objMgr *ibclient.ObjectManager
+	)
+
+	BeforeEach(func() {
+		server, conn = newFakeConnector()
+		objMgr = ibclient.NewObjectManager(conn, "cmp", "tenant").(*ibclient.ObjectManager)
+		Expect(objMgr.SetDryRun(true)).To(Succeed())
+	})
+
+	It("should not send the requests creating objects", func() {
+		rec, err := objMgr.CreateARecord("", "default", "web.example.com", "", "10.0.0.1", 0, false, "web", nil)
+		Expect(err).To(BeNil())
+		Expect(rec.Ref).To(HavePrefix("record:a/"))
+		Expect(*rec.Name).To(Equal("web.example.com"))
+		Expect(*rec.Ipv4Addr).To(Equal("10.0.0.1"))
+		Expect(server.Objects("record:a")).To(BeEmpty())
+
+		requests := conn.DryRunRequests()
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Method).To(Equal("POST"))
+		Expect(requests[0].URL).To(HaveSuffix("/wapi/v" + server.Version + "/record:a"))
+		Expect(requests[0].Ref).To(Equal(rec.Ref))
+		var body map[string]interface{}
+		Expect(json.Unmarshal(requests[0].Body, &body)).To(Succeed())
+		Expect(body).To(HaveKeyWithValue("name", "web.example.com"))
+		Expect(body).To(HaveKeyWithValue("comment", "web"))
+
+		ref, err := ibclient.ParseRef(rec.Ref)
+		Expect(err).To(BeNil())
+		Expect(ref.Name).To(Equal("web.example.com"))
+		Expect(ref.View).To(Equal("default"))
+	})
+
+	It("should not send the requests updating and deleting objects", func() {
+		ref := server.Add("networkview", map[string]interface{}{"name": "private", "comment": "first"})
+
+		nv, err := objMgr.GetNetworkView("private")
+		Expect(err).To(BeNil())
+		Expect(nv.Ref).To(Equal(ref))
+
+		updated, err := conn.UpdateObject(&ibclient.NetworkView{Comment: utils.StringPtr("second")}, ref)
+		Expect(err).To(BeNil())
+		Expect(updated).To(Equal(ref))
+		deleted, err := conn.DeleteObject(ref)
+		Expect(err).To(BeNil())
+		Expect(deleted).To(Equal(ref))
+
+		stored, ok := server.Get(ref)
+		Expect(ok).To(BeTrue())
+		Expect(stored["comment"]).To(Equal("first"))
+
+		requests := conn.DryRunRequests()
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[0].Method).To(Equal("PUT"))
+		Expect(string(requests[0].Body)).To(ContainSubstring(`"comment":"second"`))
+		Expect(requests[1].Method).To(Equal("DELETE"))
+		Expect(requests[1].URL).To(ContainSubstring(ref))
+	})
+
+	It("should read the objects it would have created", func() {
+		ref, err := conn.CreateObject(&ibclient.NetworkView{Name: utils.StringPtr("private")})
+		Expect(err).To(BeNil())
+		_, err = conn.UpdateObject(&ibclient.NetworkView{Comment: utils.StringPtr("updated")}, ref)
+		Expect(err).To(BeNil())
+
+		nv, err := ibclient.Get[ibclient.NetworkView](conn, ref)
+		Expect(err).To(BeNil())
+		Expect(*nv.Name).To(Equal("private"))
+		Expect(*nv.Comment).To(Equal("updated"))
+
+		_, err = conn.DeleteObject(ref)
+		Expect(err).To(BeNil())
+		_, err = ibclient.Get[ibclient.NetworkView](conn, ref)
+		Expect(ibclient.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should not send the multi-requests", func() {
+		b := ibclient.NewMultiRequestBuilder()
+		b.Get(&ibclient.NetworkView{}, ibclient.NewQueryParams(false, map[string]string{"name": "default"})).
+			AssignState("NV_REF", "_ref").Discard()
+		rec := b.Create(&ibclient.RecordA{Name: utils.StringPtr("web.example.com"),
+			Ipv4Addr: utils.StringPtr("10.0.0.1"), View: "default"})
+		res, err := objMgr.ExecuteMultiRequest(b)
+		Expect(err).To(BeNil())
+		ref, err := res.Ref(rec)
+		Expect(err).To(BeNil())
+		Expect(ref).To(HavePrefix("record:a/"))
+		Expect(server.Objects("record:a")).To(BeEmpty())
+		Expect(conn.DryRunRequests()).To(HaveLen(1))
+	})
+
+	It("should send the logouts", func() {
+		Expect(conn.Logout()).To(Succeed())
+		Expect(conn.DryRunRequests()).To(BeEmpty())
+	})
+
+	It("should send the requests once it is disabled", func() {
+		conn.SetDryRun(false)
+		_, err := conn.CreateObject(&ibclient.NetworkView{Name: utils.StringPtr("private")})
+		Expect(err).To(BeNil())
+		Expect(server.Objects("networkview")).To(HaveLen(2))
+		Expect(conn.DryRunRequests()).To(BeEmpty())
+	})
+
+	It("should let the mode be switched during requests", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer GinkgoRecover()
+				for j := 0; j < 10; j++ {
+					name := fmt.Sprintf("view-%d-%d", i, j)
+					_, err := conn.CreateObject(&ibclient.NetworkView{Name: utils.StringPtr(name)})
+					Expect(err).To(BeNil())
+				}
+			}(i)
+		}
+		for i := 0; i < 20; i++ {
+			conn.SetDryRun(i%2 == 1)
+			conn.DryRunRequests()
+		}
+		wg.Wait()
+	})
+})
@@ -433,7 +433,7 @@ var _ = Describe("Objects", func() {
 			Expect(err).To(BeNil())
 		})
 
-		It("Should update view comment to empty string", Label("RW", "DNS View"), func() {
+		It("Should update view comment to empty string", Label(GridOnly, "RW", "DNS View"), func() {
 			v := &ibclient.View{
 				Name:    utils.StringPtr("e2e_test_dns_view"),
 				Comment: utils.StringPtr("DNS View created by e2e test"),
@@ -553,7 +553,7 @@ var _ = Describe("Objects", func() {
 			})
 
 			Describe("A Record", func() {
-				It("Should properly serialize/deserialize", Label("RW"), func() {
+				It("Should properly serialize/deserialize", Label(GridOnly, "RW"), func() {
 					a := &ibclient.RecordA{
 						View:     "e2e_test_dns_view",
 						Name:     utils.StringPtr("e2e_test_a_record.e2e-test.com"),
@@ -585,7 +585,7 @@ var _ = Describe("Objects", func() {
 					Expect(err).To(BeNil())
 				})
 
-				It("Should support search by zone field", Label("RW"), func() {
+				It("Should support search by zone field", Label(GridOnly, "RW"), func() {
 					a := &ibclient.RecordA{
 						View:     "e2e_test_dns_view",
 						Name:     utils.StringPtr("e2e_test_a_record.e2e-test.com"),
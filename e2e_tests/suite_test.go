@@ -2,9 +2,11 @@ package e2e_tests
 
 import (
 	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"log"
+	"os"
 	"testing"
 	"time"
 )
@@ -13,9 +15,43 @@ func TestE2EInfobloxGoClient(t *testing.T) {
 	RegisterFailHandler(Fail)
 	suiteConfig, reporterConfig := GinkgoConfiguration()
 	suiteConfig.PollProgressInterval = time.Second * 10
+	if os.Getenv("INFOBLOX_FAKE_WAPI") != "" {
+		suiteConfig.LabelFilter = fakeLabelFilter(suiteConfig.LabelFilter)
+	}
 	RunSpecs(t, "InfobloxGoClient E2E Test Suite", suiteConfig, reporterConfig)
 }
 
+// GridOnly labels the specs which need a real grid: objects every grid
+// has, e.g. the admin user, fields NIOS computes and the validation of
+// the objects, which the fake WAPI server doesn't implement.
+const GridOnly = "GridOnly"
+
+// fakeLabelFilter returns the label filter of the specs run against the
+// fake WAPI server, which excludes the GridOnly specs
+func fakeLabelFilter(filter string) string {
+	if filter == "" {
+		return "!" + GridOnly
+	}
+	return "(" + filter + ") && !" + GridOnly
+}
+
+// When INFOBLOX_FAKE_WAPI is set, the tests are run against
+// an in-memory fake WAPI server instead of a grid.
+var _ = BeforeSuite(func() {
+	if os.Getenv("INFOBLOX_FAKE_WAPI") == "" {
+		return
+	}
+	server := fakewapi.NewTLSServer()
+	server.SetCredentials("admin", "infoblox")
+	DeferCleanup(server.Close)
+
+	os.Setenv("INFOBLOX_SERVER", server.Host())
+	os.Setenv("PORT", server.Port())
+	os.Setenv("WAPI_VERSION", server.Version)
+	os.Setenv("INFOBLOX_USERNAME", "admin")
+	os.Setenv("INFOBLOX_PASSWORD", "infoblox")
+})
+
 // ConnectorFacadeE2E is an end-to-end test facade for the ibclient.Connector.
 // Its purpose is to delete objects created by test, when the test is done.
 type ConnectorFacadeE2E struct {
@@ -36,7 +36,7 @@ var _ = Describe("Go Client", func() {
 		Expect(err).To(BeNil())
 	})
 
-	It("Should get the Grid object", Label("ID: 1", "RO"), func() {
+	It("Should get the Grid object", Label(GridOnly, "ID: 1", "RO"), func() {
 		var res []ibclient.Grid
 		search := &ibclient.Grid{}
 		err := connector.GetObject(search, "", nil, &res)
@@ -44,7 +44,7 @@ var _ = Describe("Go Client", func() {
 		Expect(res[0].Ref).To(Equal("grid/b25lLmNsdXN0ZXIkMA:Infoblox"))
 	})
 
-	It("Should get the Member object", Label("ID: 2", "RO"), func() {
+	It("Should get the Member object", Label(GridOnly, "ID: 2", "RO"), func() {
 		var res []ibclient.Member
 		search := &ibclient.Member{}
 		search.SetReturnFields([]string{"host_name"})
@@ -54,7 +54,7 @@ var _ = Describe("Go Client", func() {
 		Expect(*res[0].HostName).To(HavePrefix("infoblox."))
 	})
 
-	It("Should get the Admin User [admin]", Label("ID: 3", "RO"), func() {
+	It("Should get the Admin User [admin]", Label(GridOnly, "ID: 3", "RO"), func() {
 		var res []ibclient.Adminuser
 		search := &ibclient.Adminuser{}
 		err := connector.GetObject(search, "", nil, &res)
@@ -63,7 +63,7 @@ var _ = Describe("Go Client", func() {
 		Expect(res[0].AdminGroups[0]).To(Equal("admin-group"))
 	})
 
-	It("Should get the Admin Group [admin-group]", Label("ID: 4", "RO"), func() {
+	It("Should get the Admin Group [admin-group]", Label(GridOnly, "ID: 4", "RO"), func() {
 		var res []ibclient.Admingroup
 		search := &ibclient.Admingroup{}
 		qp := ibclient.NewQueryParams(false, map[string]string{"name": "admin-group"})
@@ -157,7 +157,7 @@ var _ = Describe("Go Client", func() {
 		Expect(err).To(MatchError(ibclient.NewNotFoundError("requested object not found")))
 	})
 
-	It("Should get the DTC monitor object", Label("ID: 16", "RO"), func() {
+	It("Should get the DTC monitor object", Label(GridOnly, "ID: 16", "RO"), func() {
 		var res []ibclient.DtcMonitor
 		search := &ibclient.DtcMonitor{}
 		err := connector.GetObject(search, "", nil, &res)
@@ -188,7 +188,7 @@ var _ = Describe("Go Client", func() {
 		Expect(res[4].Type).To(Equal("PDP"))
 	})
 
-	It("Should get the DTC HTTP monitor object", Label("ID: 17", "RO"), func() {
+	It("Should get the DTC HTTP monitor object", Label(GridOnly, "ID: 17", "RO"), func() {
 		var res []ibclient.DtcMonitorHttp
 		search := &ibclient.DtcMonitorHttp{}
 		err := connector.GetObject(search, "", nil, &res)
@@ -202,7 +202,7 @@ var _ = Describe("Go Client", func() {
 		Expect(res[1].Ref).To(Equal("dtc:monitor:http/ZG5zLmlkbnNfbW9uaXRvcl9odHRwJGh0dHBz:https"))
 	})
 
-	It("Should get the DTC ICMP monitor object", Label("ID: 18", "RO"), func() {
+	It("Should get the DTC ICMP monitor object", Label(GridOnly, "ID: 18", "RO"), func() {
 		var res []ibclient.DtcMonitorIcmp
 		search := &ibclient.DtcMonitorIcmp{}
 		err := connector.GetObject(search, "", nil, &res)
@@ -212,7 +212,7 @@ var _ = Describe("Go Client", func() {
 		Expect(res[0].Ref).To(Equal("dtc:monitor:icmp/ZG5zLmlkbnNfbW9uaXRvcl9pY21wJGljbXA:icmp"))
 	})
 
-	It("Should get the DTC PDP monitor object", Label("ID: 19", "RO"), func() {
+	It("Should get the DTC PDP monitor object", Label(GridOnly, "ID: 19", "RO"), func() {
 		var res []ibclient.DtcMonitorPdp
 		search := &ibclient.DtcMonitorPdp{}
 		err := connector.GetObject(search, "", nil, &res)
@@ -222,7 +222,7 @@ var _ = Describe("Go Client", func() {
 		Expect(res[0].Ref).To(Equal("dtc:monitor:pdp/ZG5zLmlkbnNfbW9uaXRvcl9wZHAkcGRw:pdp"))
 	})
 
-	It("Should get the DTC SIP monitor object", Label("ID: 20", "RO"), func() {
+	It("Should get the DTC SIP monitor object", Label(GridOnly, "ID: 20", "RO"), func() {
 		var res []ibclient.DtcMonitorSip
 		search := &ibclient.DtcMonitorSip{}
 		err := connector.GetObject(search, "", nil, &res)
@@ -274,7 +274,7 @@ var _ = Describe("Go Client", func() {
 		Expect(err).To(MatchError(ibclient.NewNotFoundError("requested object not found")))
 	})
 
-	It("Should get the Extensible Attribute Definition object", Label("ID: 27", "RO"), func() {
+	It("Should get the Extensible Attribute Definition object", Label(GridOnly, "ID: 27", "RO"), func() {
 		var res []ibclient.EADefinition
 		search := &ibclient.EADefinition{}
 		qp := ibclient.NewQueryParams(false, map[string]string{"name": "Site"})
@@ -293,7 +293,7 @@ var _ = Describe("Go Client", func() {
 		// TODO Check the error string
 	})
 
-	It("Should get the Grid Cloud API object", Label("ID: 29", "RO"), func() {
+	It("Should get the Grid Cloud API object", Label(GridOnly, "ID: 29", "RO"), func() {
 		var res []ibclient.GridCloudapi
 		search := &ibclient.GridCloudapi{}
 		err := connector.GetObject(search, "", nil, &res)
@@ -304,7 +304,7 @@ var _ = Describe("Go Client", func() {
 		Expect(res[0].Ref).To(Equal("grid:cloudapi/b25lLnZjb25uZWN0b3JfY2x1c3RlciQw:grid"))
 	})
 
-	It("Should get the Grid Cloud Statistics object", Label("ID: 30", "RO"), func() {
+	It("Should get the Grid Cloud Statistics object", Label(GridOnly, "ID: 30", "RO"), func() {
 		var res []ibclient.GridCloudapiCloudstatistics
 		search := &ibclient.GridCloudapiCloudstatistics{}
 		err := connector.GetObject(search, "", nil, &res)
@@ -333,7 +333,7 @@ var _ = Describe("Go Client", func() {
 		Expect(err).To(MatchError(ibclient.NewNotFoundError("requested object not found")))
 	})
 
-	It("Should get the Grid DHCP properties object", Label("ID: 33", "RO"), func() {
+	It("Should get the Grid DHCP properties object", Label(GridOnly, "ID: 33", "RO"), func() {
 		var res []ibclient.GridDhcpproperties
 		search := &ibclient.GridDhcpproperties{}
 		err := connector.GetObject(search, "", nil, &res)
@@ -342,7 +342,7 @@ var _ = Describe("Go Client", func() {
 		Expect(res[0].Ref).To(Equal("grid:dhcpproperties/ZG5zLmNsdXN0ZXJfZGhjcF9wcm9wZXJ0aWVzJDA:Infoblox"))
 	})
 
-	It("Should get the Grid Dns object", Label("ID: 34", "RO"), func() {
+	It("Should get the Grid Dns object", Label(GridOnly, "ID: 34", "RO"), func() {
 		var res []ibclient.GridDns
 		search := &ibclient.GridDns{}
 		err := connector.GetObject(search, "", nil, &res)
@@ -514,7 +514,7 @@ var _ = Describe("Go Client", func() {
 			Expect(ref).To(MatchRegexp("^zone_delegated.*"))
 		})
 
-		It("Should fail to create a DNS Zone-delegated without mandatory parameters", func() {
+		It("Should fail to create a DNS Zone-delegated without mandatory parameters", Label(GridOnly), func() {
 			zone := &ibclient.ZoneDelegated{
 				// Missing mandatory parameters like Fqdn and DelegatedTo oe NsGroup
 				Comment:      utils.StringPtr("wapi added"),
@@ -599,7 +599,7 @@ var _ = Describe("Go Client", func() {
 			})
 
 			It("Should modify the CNAME Record [cname.wapi.com] of the fields [comment, disable, ttl]",
-				Label("ID: 110", "ID: 148", "RW"), func() {
+				Label(GridOnly, "ID: 110", "ID: 148", "RW"), func() {
 					r := &ibclient.RecordCNAME{
 						Comment: utils.StringPtr("Modified CNAME Record"),
 						Disable: utils.BoolPtr(true),
@@ -875,7 +875,7 @@ var _ = Describe("Go Client", func() {
 					Expect(ref).To(MatchRegexp("^record:host.*h1\\.wapi\\.com/default$"))
 				})
 
-				It("Should get the DNS Host record object", Label("ID: 91", "RO"), func() {
+				It("Should get the DNS Host record object", Label(GridOnly, "ID: 91", "RO"), func() {
 					var res []ibclient.HostRecord
 					search := &ibclient.HostRecord{}
 					qp := ibclient.NewQueryParams(false, map[string]string{
@@ -913,7 +913,7 @@ var _ = Describe("Go Client", func() {
 					Expect(*res[0].View).To(Equal("default"))
 				})
 
-				It("Should get the IPv4 Host address object", Label("ID: 92", "RO"), func() {
+				It("Should get the IPv4 Host address object", Label(GridOnly, "ID: 92", "RO"), func() {
 					var res []ibclient.HostRecordIpv4Addr
 					search := &ibclient.HostRecordIpv4Addr{}
 					qp := ibclient.NewQueryParams(false, map[string]string{
@@ -950,7 +950,7 @@ var _ = Describe("Go Client", func() {
 
 				})
 
-				It("Should get the IPv6 Host address object", Label("ID: 93", "RO"), func() {
+				It("Should get the IPv6 Host address object", Label(GridOnly, "ID: 93", "RO"), func() {
 					var res []ibclient.HostRecordIpv6Addr
 					search := &ibclient.HostRecordIpv6Addr{}
 					qp := ibclient.NewQueryParams(false, map[string]string{
@@ -1413,7 +1413,7 @@ var _ = Describe("Go Client", func() {
 			})
 
 			It("Should add IPv4 Range with mandatory fields start_addr [92.0.0.10] end_addr [92.0.0.20]",
-				Label("ID: 49", "ID: 80", "ID: 119", "ID: 139", "RW"), func() {
+				Label(GridOnly, "ID: 49", "ID: 80", "ID: 119", "ID: 139", "RW"), func() {
 					r := &ibclient.Range{
 						StartAddr:   utils.StringPtr("92.0.0.10"),
 						EndAddr:     utils.StringPtr("92.0.0.20"),
@@ -1662,7 +1662,7 @@ var _ = Describe("Go Client", func() {
 				Expect(refRange).To(MatchRegexp("^ipv6range.*1%3A%3A1/1%3A%3A20/default$"))
 			})
 
-			It("Should get the IPAM IPv6Address object", Label("ID: 63", "RO"), func() {
+			It("Should get the IPAM IPv6Address object", Label(GridOnly, "ID: 63", "RO"), func() {
 				var res []ibclient.IPv6Address
 				search := &ibclient.IPv6Address{}
 				qp := ibclient.NewQueryParams(false, map[string]string{"ip_address": "1::1"})
@@ -1863,7 +1863,7 @@ var _ = Describe("Go Client", func() {
 				Expect(networkRef).To(MatchRegexp("^network.*78\\.0\\.0\\.0/30/default$"))
 			})
 
-			It("Should get the IPAM IPv4Address object", Label("ID: 62", "RO"), func() {
+			It("Should get the IPAM IPv4Address object", Label(GridOnly, "ID: 62", "RO"), func() {
 				var res []ibclient.IPv4Address
 				search := &ibclient.IPv4Address{}
 				qp := ibclient.NewQueryParams(false, map[string]string{"ip_address": "78.0.0.1"})
@@ -2112,7 +2112,7 @@ var _ = Describe("Go Client", func() {
 		Expect(err).To(MatchError(ibclient.NewNotFoundError("requested object not found")))
 	})
 
-	It("Should get the Member DHCP properties object", Label("ID: 69", "RO"), func() {
+	It("Should get the Member DHCP properties object", Label(GridOnly, "ID: 69", "RO"), func() {
 		var res []ibclient.MemberDHCPProperties
 		search := &ibclient.MemberDHCPProperties{}
 		search.SetReturnFields([]string{"host_name"})
@@ -2122,7 +2122,7 @@ var _ = Describe("Go Client", func() {
 		Expect(res[0].Ref).To(MatchRegexp("^member:dhcpproperties.*infoblox\\..*"))
 	})
 
-	It("Should get the Member DNS object", Label("ID: 70", "RO"), func() {
+	It("Should get the Member DNS object", Label(GridOnly, "ID: 70", "RO"), func() {
 		var res []ibclient.MemberDns
 		search := &ibclient.MemberDns{}
 		search.SetReturnFields([]string{"host_name"})
@@ -2146,7 +2146,7 @@ var _ = Describe("Go Client", func() {
 		Expect(err).To(MatchError(ibclient.NewNotFoundError("requested object not found")))
 	})
 
-	It("Should get the Permissions object", Label("ID: 73", "RO"), func() {
+	It("Should get the Permissions object", Label(GridOnly, "ID: 73", "RO"), func() {
 		var res []ibclient.Permission
 		search := &ibclient.Permission{}
 		err := connector.GetObject(search, "", nil, &res)
@@ -2444,7 +2444,7 @@ var _ = Describe("DNS Forward Zone", func() {
 		Expect(ref).To(MatchRegexp("^zone_forward.*"))
 	})
 
-	It("Should fail to create a DNS Forward Zone with invalid data", func() {
+	It("Should fail to create a DNS Forward Zone with invalid data", Label(GridOnly), func() {
 		zone := &ibclient.ZoneForward{
 			Fqdn: "invalid..com", // Invalid FQDN
 			ForwardTo: ibclient.NullableNameServers{
@@ -2490,7 +2490,7 @@ var _ = Describe("DNS Forward Zone", func() {
 		Expect(err).NotTo(BeNil())
 	})
 
-	It("Should fail to create a DNS Forward Zone without mandatory parameters", func() {
+	It("Should fail to create a DNS Forward Zone without mandatory parameters", Label(GridOnly), func() {
 		zone := &ibclient.ZoneForward{
 			// Missing mandatory parameters like Fqdn and ForwardTo
 			Comment:        utils.StringPtr("wapi added"),
@@ -2500,7 +2500,7 @@ var _ = Describe("DNS Forward Zone", func() {
 		Expect(err).NotTo(BeNil())
 	})
 
-	It("Should fail to create a DNS Forward Zone without fqdn parameter", func() {
+	It("Should fail to create a DNS Forward Zone without fqdn parameter", Label(GridOnly), func() {
 		zone := &ibclient.ZoneForward{
 			// Missing mandatory parameter Fqdn
 			ForwardTo: ibclient.NullableNameServers{
@@ -2517,7 +2517,7 @@ var _ = Describe("DNS Forward Zone", func() {
 		Expect(err).NotTo(BeNil())
 	})
 
-	It("Should fail to create a DNS Forward Zone without forward_to parameter", func() {
+	It("Should fail to create a DNS Forward Zone without forward_to parameter", Label(GridOnly), func() {
 		zone := &ibclient.ZoneForward{
 			// Missing mandatory parameter ForwardTo
 			Fqdn:           "example.com",
@@ -3005,7 +3005,7 @@ var _ = Describe("DTC Object pools", func() {
 		Expect(ref).To(MatchRegexp("dtc:pool/*"))
 	})
 
-	It("Should create a dtc pool with DYNAMIC_RATIO method", func() {
+	It("Should create a dtc pool with DYNAMIC_RATIO method", Label(GridOnly), func() {
 		eaMap := ibclient.EA{"Site": "Burma"}
 		sf := map[string]string{"name": "http"}
 		queryParams := ibclient.NewQueryParams(false, sf)
@@ -3038,7 +3038,7 @@ var _ = Describe("DTC Object pools", func() {
 		Expect(ref).To(MatchRegexp("dtc:pool/*"))
 	})
 
-	It("Should create a dtc pool with TOPOLOGY method", func() {
+	It("Should create a dtc pool with TOPOLOGY method", Label(GridOnly), func() {
 		eaMap := ibclient.EA{"Site": "Burma"}
 		sf := map[string]string{"name": "http"}
 		queryParams := ibclient.NewQueryParams(false, sf)
@@ -3072,7 +3072,7 @@ var _ = Describe("DTC Object pools", func() {
 		Expect(err).To(BeNil())
 		Expect(ref).To(MatchRegexp("dtc:pool/*"))
 	})
-	It("should update the dtc pool", func() {
+	It("should update the dtc pool", Label(GridOnly), func() {
 		var gridMembers []ibclient.Member
 		err := connector.GetObject(&ibclient.Member{}, "", nil, &gridMembers)
 		authZoneCreate := ibclient.ZoneAuth{
@@ -3216,7 +3216,7 @@ var _ = Describe("DTC Object pools", func() {
 		Expect(res[0].LbPreferredMethod).To(Equal("ROUND_ROBIN"))
 		Expect(res[0].Ref).To(MatchRegexp("dtc:pool/*"))
 	})
-	It("Should fail to create a DTC pool without mandatory parameters", func() {
+	It("Should fail to create a DTC pool without mandatory parameters", Label(GridOnly), func() {
 		dtcPool := &ibclient.DtcPool{
 			Comment: utils.StringPtr("wapi added"),
 			Name:    utils.StringPtr("dtc_pool_1.com"),
@@ -3293,7 +3293,7 @@ var _ = Describe("DTC LBDN and server object", func() {
 		Expect(ref).To(MatchRegexp("^dtc:lbdn.*"))
 	})
 
-	It("Should create a DTC LBDN object with maximum parameters", func() {
+	It("Should create a DTC LBDN object with maximum parameters", Label(GridOnly), func() {
 
 		var (
 			topologyRef, poolRef string
@@ -3421,7 +3421,7 @@ var _ = Describe("DTC LBDN and server object", func() {
 		Expect(err).To(BeNil())
 	})
 
-	It("Should fail to create a DTC LBDN object TestLBDN33", func() {
+	It("Should fail to create a DTC LBDN object TestLBDN33", Label(GridOnly), func() {
 		lbdn := ibclient.DtcLbdn{
 			Name:     utils.StringPtr("TestLBDN33"),
 			Comment:  utils.StringPtr("sample comment"),
@@ -3547,7 +3547,7 @@ var _ = Describe("DTC LBDN and server object", func() {
 	})
 
 	// create DTC server object, -ve scenario
-	It("Should fail to create DTC server object with minimum params", func() {
+	It("Should fail to create DTC server object with minimum params", Label(GridOnly), func() {
 		server := ibclient.DtcServer{
 			Host: utils.StringPtr("12.12.1.1"),
 		}
@@ -3731,7 +3731,7 @@ var _ = Describe("Record Alias", func() {
 		Expect(err).To(BeNil())
 	})
 
-	It("Should fail to create alias record alias222.wapi.com", func() {
+	It("Should fail to create alias record alias222.wapi.com", Label(GridOnly), func() {
 		recordAlias := ibclient.RecordAlias{
 			Name:       utils.StringPtr("alias222.wapi.com"),
 			TargetType: "NAPTR",
@@ -3898,7 +3898,7 @@ var _ = Describe("NS Record", func() {
 		Expect(delRef).To(MatchRegexp("record:ns/*"))
 	})
 
-	It("Should fail to create a NS Record object", func() {
+	It("Should fail to create a NS Record object", Label(GridOnly), func() {
 		nsRecord := ibclient.RecordNS{
 			Name: "wapi_test.com",
 			Addresses: []*ibclient.ZoneNameServer{
@@ -3930,7 +3930,7 @@ var _ = Describe("NS Record", func() {
 		_, err = connector.UpdateObject(&nsRecord, "nonexistent_ref")
 		Expect(err).NotTo(BeNil())
 	})
-	It("Should fail to update a NS record", func() {
+	It("Should fail to update a NS record", Label(GridOnly), func() {
 		nsRecord := ibclient.RecordNS{
 			Name:       "wapi_test.com",
 			Nameserver: utils.StringPtr("ns3.wapi_test.com"),
@@ -4112,7 +4112,7 @@ var _ = Describe("Record Range Template", func() {
 		Expect(err).To(BeNil())
 	})
 
-	It("Should fail to create Range Template record template333", func() {
+	It("Should fail to create Range Template record template333", Label(GridOnly), func() {
 		rangeTemplate := ibclient.Rangetemplate{
 			Name:              utils.StringPtr("template333"),
 			NumberOfAddresses: utils.Uint32Ptr(33),
@@ -4209,7 +4209,7 @@ var _ = Describe("IPV4 fixed address", func() {
 		Expect(ref).To(MatchRegexp("fixedaddress/*"))
 	})
 	// get IPV4 fixed address
-	It("Should get IPV4 fixed address", func() {
+	It("Should get IPV4 fixed address", Label(GridOnly), func() {
 		ea := ibclient.EA{"Site": "India"}
 		options := []*ibclient.Dhcpoption{
 			{
@@ -4374,7 +4374,7 @@ var _ = Describe("SharedNetwork Record", func() {
 		Expect(ipv4SharedNetwork).NotTo(BeNil())
 	})
 
-	It("Should fail to create SharedNetwork record", func() {
+	It("Should fail to create SharedNetwork record", Label(GridOnly), func() {
 
 		// Create a sharedNetwork object without mandatory fields
 		sharedNetwork := ibclient.SharedNetwork{
@@ -4482,7 +4482,7 @@ var _ = Describe("SharedNetwork Record", func() {
 		Expect(len(sharedNetworkUpdated.Options)).To(Equal(0))
 	})
 
-	It("Should fail to update SharedNetwork record", func() {
+	It("Should fail to update SharedNetwork record", Label(GridOnly), func() {
 		// Create a sharedNetwork record and try to update network_view field
 		ipv4Network1 := ibclient.NewNetwork("default", "26.23.24.0/24", false, "ipv4 network", nil)
 		ipv4NetworkRef1, err := connector.CreateObject(ipv4Network1)
@@ -4636,7 +4636,7 @@ var _ = Describe("Network Range Object", func() {
 		Expect(err).To(BeNil())
 		Expect(ref).To(MatchRegexp("range/*"))
 	})
-	It("should get the Network Range object", func() {
+	It("should get the Network Range object", Label(GridOnly), func() {
 		options := []*ibclient.Dhcpoption{
 			{
 				Name:        "routers",
@@ -4718,7 +4718,7 @@ var _ = Describe("Network Range Object", func() {
 		_, err = connector.UpdateObject(&networkRange, "nonexistent_ref")
 		Expect(err).NotTo(BeNil())
 	})
-	It("Should fail to update a range", func() {
+	It("Should fail to update a range", Label(GridOnly), func() {
 		networkRange := ibclient.Range{
 			StartAddr: utils.StringPtr("60.0.0.10"),
 			EndAddr:   utils.StringPtr("60.0.0.20"),
@@ -4735,7 +4735,7 @@ var _ = Describe("Network Range Object", func() {
 		ref, err = connector.UpdateObject(&networkRangeUpdate, res[0].Ref)
 		Expect(err).NotTo(BeNil())
 	})
-	It("Should fail to create a range object", func() {
+	It("Should fail to create a range object", Label(GridOnly), func() {
 		networkRange := ibclient.Range{
 			StartAddr: utils.StringPtr("60.0.0.10"),
 		}
@@ -4824,7 +4824,7 @@ var _ = Describe("SVCB Record", func() {
 		Expect(svcbRecord).NotTo(BeNil())
 	})
 
-	It("Should fail to create SVCB record", func() {
+	It("Should fail to create SVCB record", Label(GridOnly), func() {
 
 		// Create a SVCB Record without mandatory fields
 		recordSvcb := ibclient.RecordSVCB{
@@ -4953,7 +4953,7 @@ var _ = Describe("SVCB Record", func() {
 		Expect(recordSvcbUpdated.Reclaimable).To(BeFalse())
 	})
 
-	It("Should fail to update SVCB Record", func() {
+	It("Should fail to update SVCB Record", Label(GridOnly), func() {
 		// Create a SVCB Record and try to update view field
 		recordSvcb := ibclient.RecordSVCB{
 			Name:        "svcb-record-1143.test.com",
@@ -5170,7 +5170,7 @@ var _ = Describe("HTTPS Record Object", func() {
 		_, err = connector.UpdateObject(&httpsRecord, "nonexistent_ref")
 		Expect(err).NotTo(BeNil())
 	})
-	It("Should fail to create a https object", func() {
+	It("Should fail to create a https object", Label(GridOnly), func() {
 		httpsRecord := ibclient.RecordHttps{
 			Name:       "a1.testing-https.com",
 			TargetName: "testing-https.com",
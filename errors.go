@@ -0,0 +1,139 @@
+package ibclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WAPI error codes returned in the "code" field of an error response.
+const (
+	WapiCodeConflict         = "Client.Ibap.Data.Conflict"
+	WapiCodeNotFound         = "Client.Ibap.Data.NotFound"
+	WapiCodePermissionDenied = "Client.Ibap.Data.PermissionDenied"
+	WapiCodeAuthFailure      = "Client.Ibap.Proto.AuthFailure"
+)
+
+// WAPI error types, which prefix the "Error" field of an error response.
+const (
+	WapiErrorTypeDataError         = "AdmConDataError"
+	WapiErrorTypeDataNotFoundError = "AdmConDataNotFoundError"
+	WapiErrorTypeProtoError        = "AdmConProtoError"
+)
+
+// WapiError describes a WAPI request which failed with a non-2xx status.
+// Callers can retrieve it with errors.As; a 404 response is returned as
+// a NotFoundError which unwraps to the WapiError.
+type WapiError struct {
+	StatusCode int
+	Status     string
+
+	// Method and Object identify the failed request,
+	// Object is the object type or reference the request was made for.
+	Method string
+	Object string
+
+	// Type is the error type from the WAPI "Error" field, e.g. AdmConDataError,
+	// Code and Text are the WAPI "code" and "text" fields.
+	Type string
+	Code string
+	Text string
+
+	Body []byte
+}
+
+// wapiErrorBody is the body of a WAPI error response
+type wapiErrorBody struct {
+	Error string `json:"Error"`
+	Code  string `json:"code"`
+	Text  string `json:"text"`
+}
+
+func (e *WapiError) Error() string {
+	return fmt.Sprintf("WAPI request error: %d('%s')\nContents:\n%s\n", e.StatusCode, e.Status, e.Body)
+}
+
+// newWapiError builds a WapiError from a failed response and its body.
+func newWapiError(resp *http.Response, body []byte) *WapiError {
+	wapiErr := &WapiError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       body,
+	}
+	if resp.Request != nil {
+		wapiErr.Method = resp.Request.Method
+		wapiErr.Object = wapiObjectFromURL(resp.Request.URL)
+	}
+
+	var errBody wapiErrorBody
+	if err := json.Unmarshal(body, &errBody); err == nil {
+		wapiErr.Code = errBody.Code
+		wapiErr.Text = errBody.Text
+		// e.g. "AdmConDataError: None (IBDataConflictError: ...)"
+		if i := strings.Index(errBody.Error, ":"); i > 0 {
+			wapiErr.Type = errBody.Error[:i]
+		} else {
+			wapiErr.Type = errBody.Error
+		}
+	}
+
+	return wapiErr
+}
+
+// wapiObjectFromURL returns the object type or reference
+// following the WAPI version in a request URL.
+func wapiObjectFromURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	// /wapi/v2.12.1/record:a/ZG5zLmJpbmRfYSQ:a.test.com/default
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 3)
+	if len(parts) < 3 || parts[0] != "wapi" {
+		return ""
+	}
+	return parts[2]
+}
+
+// asWapiError returns the WapiError wrapped by err, if any
+func asWapiError(err error) (*WapiError, bool) {
+	var wapiErr *WapiError
+	if errors.As(err, &wapiErr) {
+		return wapiErr, true
+	}
+	return nil, false
+}
+
+// IsNotFound reports whether err means that the requested object does not exist.
+func IsNotFound(err error) bool {
+	var notFoundErr *NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return true
+	}
+	wapiErr, ok := asWapiError(err)
+	return ok && (wapiErr.StatusCode == http.StatusNotFound ||
+		wapiErr.Code == WapiCodeNotFound ||
+		wapiErr.Type == WapiErrorTypeDataNotFoundError)
+}
+
+// IsConflict reports whether err means that the object conflicts with
+// an existing one, e.g. a duplicate record.
+func IsConflict(err error) bool {
+	wapiErr, ok := asWapiError(err)
+	return ok && (wapiErr.StatusCode == http.StatusConflict || wapiErr.Code == WapiCodeConflict)
+}
+
+// IsAuthFailure reports whether err means that the credentials were rejected.
+func IsAuthFailure(err error) bool {
+	wapiErr, ok := asWapiError(err)
+	return ok && (wapiErr.StatusCode == http.StatusUnauthorized || wapiErr.Code == WapiCodeAuthFailure)
+}
+
+// IsPermissionDenied reports whether err means that the user
+// is not allowed to perform the request.
+func IsPermissionDenied(err error) bool {
+	wapiErr, ok := asWapiError(err)
+	return ok && (wapiErr.StatusCode == http.StatusForbidden || wapiErr.Code == WapiCodePermissionDenied)
+}
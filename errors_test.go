@@ -0,0 +1,100 @@
+package ibclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WAPI errors", func() {
+	var (
+		status int
+		body   string
+		server *httptest.Server
+		whr    *WapiHttpRequestor
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			fmt.Fprint(w, body)
+		}))
+		whr = &WapiHttpRequestor{}
+		whr.Init(AuthConfig{}, NewTransportConfig("false", 10, 1))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	send := func(method string, path string) error {
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		Expect(err).To(BeNil())
+		_, err = whr.SendRequest(req)
+		return err
+	}
+
+	It("should decode a duplicate object error", func() {
+		status = http.StatusBadRequest
+		body = `{ "Error": "AdmConDataError: None (IBDataConflictError: IB.Data.Conflict:Duplicate object 'a.test.com' of type 'host' already exists in the database.)",
+  "code": "Client.Ibap.Data.Conflict",
+  "text": "Duplicate object 'a.test.com' of type 'host' already exists in the database."
+}`
+		err := send("POST", "/wapi/v2.12.1/record:host")
+
+		var wapiErr *WapiError
+		Expect(errors.As(err, &wapiErr)).To(BeTrue())
+		Expect(wapiErr.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(wapiErr.Method).To(Equal("POST"))
+		Expect(wapiErr.Object).To(Equal("record:host"))
+		Expect(wapiErr.Type).To(Equal(WapiErrorTypeDataError))
+		Expect(wapiErr.Code).To(Equal(WapiCodeConflict))
+		Expect(wapiErr.Text).To(HavePrefix("Duplicate object"))
+		Expect(err.Error()).To(HavePrefix("WAPI request error: 400('400 Bad Request')\nContents:\n"))
+
+		Expect(IsConflict(err)).To(BeTrue())
+		Expect(IsNotFound(err)).To(BeFalse())
+		Expect(IsAuthFailure(err)).To(BeFalse())
+		Expect(IsPermissionDenied(err)).To(BeFalse())
+	})
+
+	It("should return a NotFoundError wrapping the WapiError on 404", func() {
+		status = http.StatusNotFound
+		body = `{ "Error": "AdmConDataNotFoundError: Reference record:a/ZG5zLmJpbmRfYSQ not found", "code": "Client.Ibap.Data.NotFound", "text": "Reference record:a/ZG5zLmJpbmRfYSQ not found"}`
+		err := send("GET", "/wapi/v2.12.1/record:a/ZG5zLmJpbmRfYSQ:a.test.com/default")
+
+		Expect(err).To(BeAssignableToTypeOf(&NotFoundError{}))
+		var wapiErr *WapiError
+		Expect(errors.As(err, &wapiErr)).To(BeTrue())
+		Expect(wapiErr.Object).To(Equal("record:a/ZG5zLmJpbmRfYSQ:a.test.com/default"))
+		Expect(wapiErr.Type).To(Equal(WapiErrorTypeDataNotFoundError))
+		Expect(IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should tell authentication failures from permission errors", func() {
+		status = http.StatusUnauthorized
+		body = "Authorization Required"
+		err := send("GET", "/wapi/v2.12.1/network")
+		Expect(IsAuthFailure(err)).To(BeTrue())
+		Expect(IsPermissionDenied(err)).To(BeFalse())
+
+		status = http.StatusForbidden
+		body = `{ "Error": "AdmConProtoError: Permission denied", "code": "Client.Ibap.Data.PermissionDenied", "text": "Permission denied"}`
+		err = send("GET", "/wapi/v2.12.1/network")
+		Expect(IsAuthFailure(err)).To(BeFalse())
+		Expect(IsPermissionDenied(err)).To(BeTrue())
+		var wapiErr *WapiError
+		Expect(errors.As(err, &wapiErr)).To(BeTrue())
+		Expect(wapiErr.Type).To(Equal(WapiErrorTypeProtoError))
+	})
+
+	It("should treat plain NotFoundErrors as not found", func() {
+		Expect(IsNotFound(NewNotFoundError("not found"))).To(BeTrue())
+		Expect(IsNotFound(errors.New("not found"))).To(BeFalse())
+		Expect(IsConflict(nil)).To(BeFalse())
+	})
+})
@@ -0,0 +1,207 @@
+package ibclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHealthCheckInterval is the interval of the health checks
+// of the endpoints when TransportConfig.HealthCheckInterval is zero
+const defaultHealthCheckInterval = 30 * time.Second
+
+// healthCheckTimeout is the timeout of the health checks of an endpoint
+// made in the background to fail back to it
+const healthCheckTimeout = 5 * time.Second
+
+// endpoints are the Grid Master and the Grid Master Candidates a
+// connector sends its requests to, see HostConfig.Candidates. It is kept
+// behind a pointer so that copies of a Connector share it.
+type endpoints struct {
+	hosts    []string // host:port
+	interval time.Duration
+
+	mu        sync.Mutex
+	active    int
+	lastCheck time.Time
+	checking  bool
+}
+
+func newEndpoints(hostCfg HostConfig, trCfg TransportConfig) *endpoints {
+	if len(hostCfg.Candidates) == 0 {
+		return nil
+	}
+	e := &endpoints{
+		hosts:    []string{net.JoinHostPort(hostCfg.Host, hostCfg.Port)},
+		interval: trCfg.HealthCheckInterval,
+	}
+	if e.interval == 0 {
+		e.interval = defaultHealthCheckInterval
+	}
+	for _, host := range hostCfg.Candidates {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, hostCfg.Port)
+		}
+		e.hosts = append(e.hosts, host)
+	}
+	return e
+}
+
+func (e *endpoints) activeIndex() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.active
+}
+
+// setActive makes the i-th endpoint active, the endpoints before it
+// are checked once the health check interval elapsed
+func (e *endpoints) setActive(i int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.active, e.lastCheck = i, time.Now()
+}
+
+// ActiveEndpoint returns the host:port the connector sends its requests to:
+// the Grid Master, or the Grid Master Candidate it failed over to.
+func (c *Connector) ActiveEndpoint() string {
+	if c.endpoints == nil {
+		return net.JoinHostPort(c.hostCfg.Host, c.hostCfg.Port)
+	}
+	return c.endpoints.hosts[c.endpoints.activeIndex()]
+}
+
+// CheckEndpoints checks the health of the connector's endpoints in order,
+// and makes the first healthy one active. An endpoint is healthy when it
+// answers as the Grid Master.
+func (c *Connector) CheckEndpoints(ctx context.Context) error {
+	if c.endpoints == nil {
+		return nil
+	}
+	var errs []error
+	for i := range c.endpoints.hosts {
+		err := c.checkEndpoint(ctx, i)
+		if err == nil {
+			c.activateEndpoint(i)
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("no healthy endpoint: %w", errors.Join(errs...))
+}
+
+// checkEndpoint sends a request for the grid to the i-th endpoint, once
+func (c *Connector) checkEndpoint(ctx context.Context, i int) error {
+	req, err := c.requestBuilder.BuildRequest(GET, NewGrid(Grid{}), "", nil)
+	if err != nil {
+		return err
+	}
+	req = requestWithContext(req, withoutRetries(ctx))
+	req.URL.Host = c.endpoints.hosts[i]
+	if _, err = c.handler()(req); err != nil {
+		return fmt.Errorf("%s: %w", c.endpoints.hosts[i], err)
+	}
+	return nil
+}
+
+func (c *Connector) activateEndpoint(i int) {
+	if previous := c.endpoints.activeIndex(); previous != i {
+		c.endpoints.setActive(i)
+		c.log().Warn("switched the WAPI endpoint",
+			"from", c.endpoints.hosts[previous], "to", c.endpoints.hosts[i])
+	}
+}
+
+// failBack checks in the background, once per health check interval,
+// whether the endpoints before the active one recovered, and makes the
+// first one which did active
+func (c *Connector) failBack() {
+	e := c.endpoints
+	e.mu.Lock()
+	if e.active == 0 || e.checking || time.Since(e.lastCheck) < e.interval {
+		e.mu.Unlock()
+		return
+	}
+	e.checking, e.lastCheck = true, time.Now()
+	active := e.active
+	e.mu.Unlock()
+
+	go func() {
+		defer func() {
+			e.mu.Lock()
+			e.checking = false
+			e.mu.Unlock()
+		}()
+		for i := 0; i < active; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+			err := c.checkEndpoint(ctx, i)
+			cancel()
+			if err == nil {
+				c.activateEndpoint(i)
+				return
+			}
+		}
+	}()
+}
+
+// sendToEndpoints sends req to the active endpoint, and to the next ones
+// when it can't be reached or isn't the Grid Master
+func (c *Connector) sendToEndpoints(req *http.Request) ([]byte, error) {
+	e := c.endpoints
+	if e == nil {
+		return c.handler()(req)
+	}
+	c.failBack()
+
+	start := e.activeIndex()
+	var (
+		res []byte
+		err error
+	)
+	for attempt := 1; attempt <= len(e.hosts); attempt++ {
+		i := (start + attempt - 1) % len(e.hosts)
+		attemptReq, reqErr := requestForAttempt(req, attempt)
+		if reqErr != nil {
+			return nil, reqErr
+		}
+		if attemptReq == req {
+			attemptReq = req.Clone(req.Context())
+		}
+		attemptReq.URL.Host = e.hosts[i]
+
+		res, err = c.handler()(attemptReq)
+		if !shouldFailOver(req.Context(), err) {
+			if i != start {
+				c.activateEndpoint(i)
+			}
+			return res, err
+		}
+		c.log().Warn("the WAPI endpoint failed", "endpoint", e.hosts[i], "error", err)
+	}
+	return res, err
+}
+
+// shouldFailOver reports whether a request which failed with err should be
+// sent to the next endpoint. Only the requests which the endpoint didn't
+// process are: it couldn't be connected to, or it isn't the Grid Master
+// anymore, e.g. while a Grid Master Candidate is promoted. The others,
+// e.g. a POST whose connection broke, may have changed the grid.
+func shouldFailOver(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if wapiErr, ok := asWapiError(err); ok {
+		return isNotGridMaster(wapiErr)
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func isNotGridMaster(err *WapiError) bool {
+	text := strings.ToLower(string(err.Body))
+	return strings.Contains(text, "not grid master") || strings.Contains(text, "not the grid master")
+}
@@ -0,0 +1,191 @@
+package ibclient_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	primaryUp = iota
+	primaryBroken
+	primaryNotGridMaster
+	primaryUnavailable
+)
+
+var _ = Describe("Failover", func() {
+	var (
+		primary   *fakewapi.Server
+		candidate *fakewapi.Server
+		front     *httptest.Server
+		frontAddr string
+		frontReqs atomic.Int32
+		state     atomic.Int32
+		hostCfg   ibclient.HostConfig
+		trCfg     ibclient.TransportConfig
+	)
+
+	// startFront serves the requests to the Grid Master on frontAddr
+	startFront := func() {
+		front = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			frontReqs.Add(1)
+			switch state.Load() {
+			case primaryBroken:
+				conn, _, err := w.(http.Hijacker).Hijack()
+				Expect(err).To(BeNil())
+				conn.Close()
+			case primaryNotGridMaster:
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"Error": "AdmConProtoError: This member is not grid master", "code": "Client.Ibap.Proto", "text": "This member is not grid master"}`))
+			case primaryUnavailable:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			default:
+				primary.ServeHTTP(w, r)
+			}
+		}))
+		if frontAddr != "" {
+			listener, err := net.Listen("tcp", frontAddr)
+			Expect(err).To(BeNil())
+			front.Listener.Close()
+			front.Listener = listener
+		}
+		front.Start()
+		frontAddr = front.Listener.Addr().String()
+		DeferCleanup(front.Close)
+	}
+
+	BeforeEach(func() {
+		primary = fakewapi.NewServer()
+		DeferCleanup(primary.Close)
+		candidate = fakewapi.NewServer()
+		DeferCleanup(candidate.Close)
+
+		state.Store(primaryUp)
+		frontAddr = ""
+		startFront()
+
+		host, port, err := net.SplitHostPort(front.Listener.Addr().String())
+		Expect(err).To(BeNil())
+		hostCfg = ibclient.HostConfig{
+			Scheme:     "http",
+			Host:       host,
+			Port:       port,
+			Version:    primary.Version,
+			Candidates: []string{net.JoinHostPort(candidate.Host(), candidate.Port())},
+		}
+		trCfg = ibclient.NewTransportConfig("false", 10, 1)
+	})
+
+	newConnector := func() *ibclient.Connector {
+		conn, err := ibclient.NewConnector(hostCfg, ibclient.AuthConfig{}, trCfg,
+			&ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+		Expect(err).To(BeNil())
+		return conn
+	}
+
+	createView := func(conn *ibclient.Connector, name string) error {
+		_, err := conn.CreateObject(&ibclient.NetworkView{Name: utils.StringPtr(name)})
+		return err
+	}
+
+	It("should send the requests to the Grid Master", func() {
+		conn := newConnector()
+		Expect(createView(conn, "private")).To(Succeed())
+		Expect(conn.ActiveEndpoint()).To(Equal(front.Listener.Addr().String()))
+		Expect(primary.Objects("networkview")).To(HaveLen(2))
+		Expect(candidate.Objects("networkview")).To(HaveLen(1))
+	})
+
+	It("should fail over when the Grid Master can't be reached", func() {
+		conn := newConnector()
+		front.Close()
+
+		Expect(createView(conn, "private")).To(Succeed())
+		Expect(conn.ActiveEndpoint()).To(Equal(hostCfg.Candidates[0]))
+		Expect(candidate.Objects("networkview")).To(HaveLen(2))
+
+		// the next requests go to the candidate directly
+		startFront()
+		Expect(createView(conn, "other")).To(Succeed())
+		Expect(candidate.Objects("networkview")).To(HaveLen(3))
+		Expect(primary.Objects("networkview")).To(HaveLen(1))
+	})
+
+	It("should fail over when the endpoint isn't the Grid Master", func() {
+		conn := newConnector()
+		state.Store(primaryNotGridMaster)
+
+		Expect(createView(conn, "private")).To(Succeed())
+		Expect(conn.ActiveEndpoint()).To(Equal(hostCfg.Candidates[0]))
+	})
+
+	It("should not fail over on the other errors", func() {
+		conn := newConnector()
+		Expect(createView(conn, "private")).To(Succeed())
+		Expect(createView(conn, "private")).NotTo(Succeed())
+		Expect(conn.ActiveEndpoint()).To(Equal(front.Listener.Addr().String()))
+		Expect(candidate.Objects("networkview")).To(HaveLen(1))
+	})
+
+	It("should not fail over when the request may have been processed", func() {
+		conn := newConnector()
+		state.Store(primaryBroken)
+		Expect(createView(conn, "private")).NotTo(Succeed())
+		Expect(conn.ActiveEndpoint()).To(Equal(front.Listener.Addr().String()))
+		Expect(candidate.Objects("networkview")).To(HaveLen(1))
+	})
+
+	It("should fail back once the Grid Master recovers", func() {
+		trCfg.HealthCheckInterval = 10 * time.Millisecond
+		trCfg.RetryPolicy = ibclient.NewExponentialBackoff(5)
+		conn := newConnector()
+		front.Close()
+		Expect(createView(conn, "private")).To(Succeed())
+		Expect(conn.ActiveEndpoint()).To(Equal(hostCfg.Candidates[0]))
+
+		// the health checks don't block the requests, and are not retried
+		state.Store(primaryUnavailable)
+		startFront()
+		frontReqs.Store(0)
+		time.Sleep(trCfg.HealthCheckInterval)
+		start := time.Now()
+		_, err := ibclient.List[ibclient.NetworkView](conn, nil)
+		Expect(err).To(BeNil())
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		Eventually(frontReqs.Load).Should(BeEquivalentTo(1))
+		Consistently(frontReqs.Load, 1500*time.Millisecond).Should(BeEquivalentTo(1))
+		Expect(conn.ActiveEndpoint()).To(Equal(hostCfg.Candidates[0]))
+
+		state.Store(primaryUp)
+		Eventually(func() string {
+			_, err := ibclient.List[ibclient.NetworkView](conn, nil)
+			Expect(err).To(BeNil())
+			return conn.ActiveEndpoint()
+		}).Should(Equal(front.Listener.Addr().String()))
+	})
+
+	It("should check the health of the endpoints", func() {
+		conn := newConnector()
+		state.Store(primaryNotGridMaster)
+		Expect(conn.CheckEndpoints(context.Background())).To(Succeed())
+		Expect(conn.ActiveEndpoint()).To(Equal(hostCfg.Candidates[0]))
+
+		state.Store(primaryUp)
+		Expect(conn.CheckEndpoints(context.Background())).To(Succeed())
+		Expect(conn.ActiveEndpoint()).To(Equal(front.Listener.Addr().String()))
+
+		front.Close()
+		candidate.Close()
+		Expect(conn.CheckEndpoints(context.Background())).To(MatchError(ContainSubstring("no healthy endpoint")))
+	})
+})
@@ -0,0 +1,56 @@
+package fakewapi
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// sessionCookie is the cookie WAPI authenticates sessions with
+const sessionCookie = "ibapauth"
+
+// authenticate checks the basic authentication of r or, without one, its
+// session cookie, when the server requires authentication, and returns the
+// cookie of the session opened when r authenticated with a password
+func (s *Server) authenticate(r *http.Request) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.username == "" {
+		return "", true
+	}
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		cookie, err := r.Cookie(sessionCookie)
+		return "", err == nil && s.sessions[cookie.Value]
+	}
+	if username != s.username || password != s.password {
+		return "", false
+	}
+	s.logins++
+	s.lastSess++
+	session := fmt.Sprintf("session-%d", s.lastSess)
+	s.sessions[session] = true
+	return session, true
+}
+
+// logout ends the session of r
+func (s *Server) logout(r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		delete(s.sessions, cookie.Value)
+	}
+}
+
+// ExpireSessions ends all the sessions, as their timeout would:
+// the clients have to authenticate again.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]bool)
+}
+
+// Logins returns the number of requests which authenticated with
+// a username and a password rather than a session cookie.
+func (s *Server) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins
+}
@@ -0,0 +1,74 @@
+package fakewapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// wapiError is an error response, in the format of NIOS
+type wapiError struct {
+	status  int
+	errType string
+	code    string
+	text    string
+}
+
+func protoError(status int, format string, args ...interface{}) *wapiError {
+	return &wapiError{
+		status:  status,
+		errType: "AdmConProtoError",
+		code:    "Client.Ibap.Proto",
+		text:    fmt.Sprintf(format, args...),
+	}
+}
+
+func dataError(status int, format string, args ...interface{}) *wapiError {
+	return &wapiError{
+		status:  status,
+		errType: "AdmConDataError",
+		code:    "Client.Ibap.Data",
+		text:    fmt.Sprintf(format, args...),
+	}
+}
+
+func refNotFound(ref string) *wapiError {
+	return &wapiError{
+		status:  http.StatusNotFound,
+		errType: "AdmConDataNotFoundError",
+		code:    "Client.Ibap.Data.NotFound",
+		text:    fmt.Sprintf("Reference %s not found", ref),
+	}
+}
+
+func conflictError(objType string, ref string) *wapiError {
+	return &wapiError{
+		status:  http.StatusBadRequest,
+		errType: "AdmConDataError",
+		code:    "Client.Ibap.Data.Conflict",
+		text:    fmt.Sprintf("The object of type '%s' already exists in the database: %s", objType, ref),
+	}
+}
+
+func (e *wapiError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"Error": fmt.Sprintf("%s: %s", e.errType, e.text),
+		"code":  e.code,
+		"text":  e.text,
+	})
+}
+
+func writeError(w http.ResponseWriter, err *wapiError) {
+	writeJSON(w, err.status, err)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,13 @@
+package fakewapi_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFakeWapi(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fake WAPI Suite")
+}
@@ -0,0 +1,400 @@
+package fakewapi
+
+import (
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+const (
+	nextAvailableIP      = "next_available_ip"
+	nextAvailableNetwork = "next_available_network"
+)
+
+// call serves a function call on an object
+func (s *Server) call(ref string, function string, data interface{}) (interface{}, *wapiError) {
+	rec := s.lookup(ref)
+	if rec == nil {
+		return nil, refNotFound(ref)
+	}
+	params, _ := data.(map[string]interface{})
+	if function != nextAvailableIP && function != nextAvailableNetwork {
+		return nil, protoError(http.StatusBadRequest, "Function %s is not supported by the fake WAPI", function)
+	}
+
+	num, err := intParam(params, "num", 1)
+	if err != nil {
+		return nil, err
+	}
+	exclude, _ := params["exclude"].([]interface{})
+
+	if function == nextAvailableNetwork {
+		prefixLen, err := intParam(params, "cidr", 0)
+		if err != nil {
+			return nil, err
+		}
+		taken := s.usedNetworks(rec)
+		for _, network := range exclude {
+			if prefix, err := netip.ParsePrefix(stringValue(network)); err == nil {
+				taken = append(taken, prefix)
+			}
+		}
+		networks, err := nextAvailableNetworks(rec, prefixLen, num, taken)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"networks": stringList(networks)}, nil
+	}
+
+	taken := s.usedAddresses()
+	for _, ip := range exclude {
+		taken[stringValue(ip)] = true
+	}
+	ips, err := nextAvailableIPs(rec, num, taken)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"ips": stringList(ips)}, nil
+}
+
+// intParam returns the positive integer parameter of a function call
+func intParam(params map[string]interface{}, name string, defaultValue int) (int, *wapiError) {
+	value, ok := params[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(stringValue(value))
+	if err != nil || i < 1 {
+		return 0, protoError(http.StatusBadRequest, "Invalid value for %s: %v", name, value)
+	}
+	return i, nil
+}
+
+func stringList(values []string) []interface{} {
+	res := make([]interface{}, len(values))
+	for i, v := range values {
+		res[i] = v
+	}
+	return res
+}
+
+// nextAvailableIPs returns the first num addresses of a network which are
+// not taken, and marks them as taken. The network and broadcast addresses
+// of IPv4 networks are never available.
+func nextAvailableIPs(network *record, num int, taken map[string]bool) ([]string, *wapiError) {
+	if network.objType != "network" && network.objType != "ipv6network" {
+		return nil, protoError(http.StatusBadRequest, "Function %s is not supported for %s", nextAvailableIP, network.objType)
+	}
+	cidr, _ := network.fields["network"].(string)
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return nil, dataError(http.StatusBadRequest, "Invalid network %s", cidr)
+	}
+	prefix = prefix.Masked()
+
+	var ips []string
+	for addr := prefix.Addr().Next(); prefix.Contains(addr) && len(ips) < num; addr = addr.Next() {
+		if addr.Is4() && prefix.Bits() < 31 && !prefix.Contains(addr.Next()) {
+			break // broadcast address
+		}
+		if !taken[addr.String()] {
+			taken[addr.String()] = true
+			ips = append(ips, addr.String())
+		}
+	}
+	if len(ips) < num {
+		return nil, dataError(http.StatusBadRequest, "Cannot find %d available IP address(es) in network %s", num, cidr)
+	}
+	return ips, nil
+}
+
+// nextAvailableNetworks returns the first num networks with the given
+// prefix length in a network container which don't overlap the taken ones.
+func nextAvailableNetworks(container *record, prefixLen int, num int, taken []netip.Prefix) ([]string, *wapiError) {
+	if container.objType != "networkcontainer" && container.objType != "ipv6networkcontainer" {
+		return nil, protoError(http.StatusBadRequest, "Function %s is not supported for %s", nextAvailableNetwork, container.objType)
+	}
+	cidr, _ := container.fields["network"].(string)
+	parent, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return nil, dataError(http.StatusBadRequest, "Invalid network %s", cidr)
+	}
+	parent = parent.Masked()
+	if prefixLen <= parent.Bits() || prefixLen > parent.Addr().BitLen() {
+		return nil, dataError(http.StatusBadRequest, "Invalid prefix length %d for network container %s", prefixLen, cidr)
+	}
+
+	var networks []string
+	for addr := parent.Addr(); parent.Contains(addr) && len(networks) < num; {
+		candidate := netip.PrefixFrom(addr, prefixLen)
+		free := true
+		for _, t := range taken {
+			if t.Overlaps(candidate) {
+				free = false
+				break
+			}
+		}
+		if free {
+			networks = append(networks, candidate.String())
+			taken = append(taken, candidate)
+		}
+		if addr = lastAddr(candidate).Next(); !addr.IsValid() {
+			break
+		}
+	}
+	if len(networks) < num {
+		return nil, dataError(http.StatusBadRequest, "Cannot find %d available network(s) in network container %s", num, cidr)
+	}
+	return networks, nil
+}
+
+// lastAddr returns the last address of a prefix
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Masked().Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// usedNetworks returns the networks and network containers of the network
+// view of a container which are inside it
+func (s *Server) usedNetworks(container *record) []netip.Prefix {
+	parent, err := netip.ParsePrefix(stringValue(container.fields["network"]))
+	if err != nil {
+		return nil
+	}
+	var used []netip.Prefix
+	for _, rec := range s.records {
+		switch rec.objType {
+		case "network", "networkcontainer", "ipv6network", "ipv6networkcontainer":
+		default:
+			continue
+		}
+		if rec.id == container.id || stringValue(rec.fields["network_view"]) != stringValue(container.fields["network_view"]) {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(stringValue(rec.fields["network"]))
+		if err == nil && prefix.Bits() > parent.Bits() && parent.Overlaps(prefix) {
+			used = append(used, prefix.Masked())
+		}
+	}
+	return used
+}
+
+// usedAddresses returns the IP addresses assigned to the stored objects
+func (s *Server) usedAddresses() map[string]bool {
+	used := make(map[string]bool)
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for k, item := range val {
+				if ip, ok := item.(string); ok && (k == "ipv4addr" || k == "ipv6addr") {
+					if addr, err := netip.ParseAddr(ip); err == nil {
+						used[addr.String()] = true
+					}
+				} else {
+					walk(item)
+				}
+			}
+		case []interface{}:
+			for _, item := range val {
+				walk(item)
+			}
+		}
+	}
+	for _, rec := range s.records {
+		walk(rec.fields)
+	}
+	return used
+}
+
+// evalFunctions replaces the function calls in the fields of a new or
+// updated object by their result: "func:nextavailableip:<network>[,<view>]"
+// strings, where network is a CIDR or a reference, and objects with an
+// _object_function field.
+func (s *Server) evalFunctions(fields map[string]interface{}) *wapiError {
+	taken := s.usedAddresses()
+	for name, value := range fields {
+		res, err := s.evalValue(value, taken)
+		if err != nil {
+			return err
+		}
+		fields[name] = res
+	}
+	return nil
+}
+
+func (s *Server) evalValue(v interface{}, taken map[string]bool) (interface{}, *wapiError) {
+	switch val := v.(type) {
+	case string:
+		if !strings.HasPrefix(val, "func:") {
+			return val, nil
+		}
+		return s.evalFuncString(val, taken)
+	case map[string]interface{}:
+		if _, ok := val["_object_function"]; ok {
+			return s.evalObjectFunction(val, taken)
+		}
+		res := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			evaluated, err := s.evalValue(item, taken)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = evaluated
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, item := range val {
+			evaluated, err := s.evalValue(item, taken)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = evaluated
+		}
+		return res, nil
+	}
+	return v, nil
+}
+
+func (s *Server) evalFuncString(f string, taken map[string]bool) (interface{}, *wapiError) {
+	parts := strings.SplitN(f, ":", 3)
+	if len(parts) != 3 || (parts[1] != "nextavailableip" && parts[1] != "nextavailablenetwork") {
+		return nil, protoError(http.StatusBadRequest, "Function %s is not supported by the fake WAPI", f)
+	}
+	args := strings.Split(parts[2], ",")
+	netview := "default"
+	if len(args) > 1 && args[1] != "" {
+		netview = args[1]
+	}
+
+	if parts[1] == "nextavailablenetwork" {
+		// func:nextavailablenetwork:<container>,<network view>,<prefix length>
+		container := s.lookup(args[0])
+		if container == nil {
+			container = s.findNetworkContainer(args[0], netview)
+		}
+		if container == nil {
+			return nil, dataError(http.StatusBadRequest, "Cannot find network container %s in network view %s", args[0], netview)
+		}
+		prefixLen := 0
+		if len(args) > 2 {
+			prefixLen, _ = strconv.Atoi(args[2])
+		}
+		networks, err := nextAvailableNetworks(container, prefixLen, 1, s.usedNetworks(container))
+		if err != nil {
+			return nil, err
+		}
+		return networks[0], nil
+	}
+
+	network := s.lookup(args[0])
+	if network == nil {
+		network = s.findNetwork(args[0], netview)
+	}
+	if network == nil {
+		return nil, dataError(http.StatusBadRequest, "Cannot find network %s in network view %s", args[0], netview)
+	}
+	ips, err := nextAvailableIPs(network, 1, taken)
+	if err != nil {
+		return nil, err
+	}
+	return ips[0], nil
+}
+
+// evalObjectFunction calls a function on the object matching the
+// _object and _object_parameters fields, e.g.
+// {"_object_function": "next_available_ip", "_object": "network",
+// "_object_parameters": {"network": "10.0.0.0/24"}, "_result_field": "ips"}
+func (s *Server) evalObjectFunction(f map[string]interface{}, taken map[string]bool) (interface{}, *wapiError) {
+	function, _ := f["_object_function"].(string)
+	objType, _ := f["_object"].(string)
+	if function != nextAvailableIP && function != nextAvailableNetwork {
+		return nil, protoError(http.StatusBadRequest, "Function %s is not supported by the fake WAPI", function)
+	}
+
+	params, _ := f["_object_parameters"].(map[string]interface{})
+	var network *record
+	for _, id := range s.order {
+		rec := s.records[id]
+		if rec.objType != objType {
+			continue
+		}
+		matches := true
+		for k, v := range params {
+			arg, err := parseSearchArg(k, []string{stringValue(v)})
+			if err != nil {
+				return nil, protoError(http.StatusBadRequest, "%s", err)
+			}
+			if !arg.matches(rec.fields) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			network = rec
+			break
+		}
+	}
+	if network == nil {
+		return nil, dataError(http.StatusBadRequest, "Cannot find the %s object to call %s on", objType, function)
+	}
+
+	callParams, _ := f["_parameters"].(map[string]interface{})
+	num, err := intParam(callParams, "num", 1)
+	if err != nil {
+		return nil, err
+	}
+	if function == nextAvailableNetwork {
+		prefixLen, err := intParam(callParams, "cidr", 0)
+		if err != nil {
+			return nil, err
+		}
+		networks, err := nextAvailableNetworks(network, prefixLen, num, s.usedNetworks(network))
+		if err != nil {
+			return nil, err
+		}
+		return networks[0], nil
+	}
+	ips, err := nextAvailableIPs(network, num, taken)
+	if err != nil {
+		return nil, err
+	}
+	return ips[0], nil
+}
+
+// findNetworkContainer returns the network container with the given
+// CIDR in a network view
+func (s *Server) findNetworkContainer(cidr string, netview string) *record {
+	objType := "networkcontainer"
+	if strings.Contains(cidr, ":") {
+		objType = "ipv6networkcontainer"
+	}
+	for _, id := range s.order {
+		rec := s.records[id]
+		if rec.objType == objType && rec.fields["network"] == cidr && rec.fields["network_view"] == netview {
+			return rec
+		}
+	}
+	return nil
+}
+
+// findNetwork returns the network with the given CIDR in a network view
+func (s *Server) findNetwork(cidr string, netview string) *record {
+	objType := "network"
+	if strings.Contains(cidr, ":") {
+		objType = "ipv6network"
+	}
+	for _, id := range s.order {
+		rec := s.records[id]
+		if rec.objType == objType && rec.fields["network"] == cidr && rec.fields["network_view"] == netview {
+			return rec
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package fakewapi
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// SetSchema sets the schema served for an object type: fields maps the
+// field names to the operations they support, e.g. "rwus" for a field
+// which can be read, written, updated and searched.
+func (s *Server) SetSchema(objType string, fields map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schemas[objType] = fields
+}
+
+func (s *Server) supportsVersion(version string) bool {
+	if len(s.SupportedVersions) == 0 {
+		return true
+	}
+	for _, v := range s.SupportedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// schema serves ?_schema, the WAPI schema, and <type>?_schema, the schema
+// of an object type. Only the object types given to SetSchema have one.
+func (s *Server) schema(version string, objType string, args url.Values) (interface{}, *wapiError) {
+	if objType == "" {
+		if args.Get("_schema") != "" {
+			return nil, protoError(http.StatusBadRequest, "The schema of an object type is served by <type>?_schema")
+		}
+		objects := []string{}
+		for t := range s.schemas {
+			objects = append(objects, t)
+		}
+		sort.Strings(objects)
+		return map[string]interface{}{
+			"requested_version":  version,
+			"supported_objects":  objects,
+			"supported_versions": s.SupportedVersions,
+		}, nil
+	}
+
+	fields, ok := s.schemas[objType]
+	if !ok {
+		return nil, protoError(http.StatusBadRequest, "Unknown object type (%s)", objType)
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	res := []interface{}{}
+	for _, name := range names {
+		res = append(res, map[string]interface{}{
+			"name":     name,
+			"supports": fields[name],
+			"type":     []string{"string"},
+			"is_array": false,
+		})
+	}
+	return map[string]interface{}{
+		"type":         objType,
+		"version":      version,
+		"restrictions": []string{},
+		"fields":       res,
+	}, nil
+}
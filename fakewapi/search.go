@@ -0,0 +1,248 @@
+package fakewapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// searchArg is a search argument: a field, or an extensible attribute
+// when eaName is set, compared to values according to the WAPI modifiers
+// following the field name, e.g. name~:=^test matches names starting
+// with "test" regardless of case.
+type searchArg struct {
+	field     string
+	eaName    string
+	regex     bool
+	noCase    bool
+	negate    bool
+	lessEq    bool
+	greaterEq bool
+	values    []string
+}
+
+func parseSearchArg(key string, values []string) (*searchArg, error) {
+	arg := &searchArg{values: values}
+	name := strings.TrimRight(key, "~:!<>=")
+	for _, m := range key[len(name):] {
+		switch m {
+		case '~':
+			arg.regex = true
+		case ':':
+			arg.noCase = true
+		case '!':
+			arg.negate = true
+		case '<':
+			arg.lessEq = true
+		case '>':
+			arg.greaterEq = true
+		}
+	}
+	if strings.HasPrefix(name, "*") {
+		arg.eaName = name[1:]
+	} else {
+		arg.field = name
+	}
+	if name == "" || name == "*" {
+		return nil, fmt.Errorf("invalid search argument '%s'", key)
+	}
+	if arg.regex {
+		for _, v := range values {
+			if _, err := regexp.Compile(v); err != nil {
+				return nil, fmt.Errorf("invalid regular expression '%s': %s", v, err)
+			}
+		}
+	}
+	return arg, nil
+}
+
+// matches reports whether the object fields match the argument:
+// as on NIOS, one of the values has to match one of the field values.
+func (arg *searchArg) matches(fields map[string]interface{}) bool {
+	var actual []interface{}
+	if arg.eaName != "" {
+		if v, ok := eaValue(fields, arg.eaName); ok {
+			actual = flatten(v)
+		}
+	} else {
+		actual = fieldValues(fields, arg.field)
+	}
+
+	for _, a := range actual {
+		for _, v := range arg.values {
+			if arg.compare(stringValue(a), v) {
+				return !arg.negate
+			}
+		}
+	}
+	return arg.negate
+}
+
+func (arg *searchArg) compare(actual string, value string) bool {
+	switch {
+	case arg.regex:
+		if arg.noCase {
+			value = "(?i)" + value
+		}
+		return regexp.MustCompile(value).MatchString(actual)
+	case arg.lessEq || arg.greaterEq:
+		a, errA := strconv.ParseFloat(actual, 64)
+		v, errV := strconv.ParseFloat(value, 64)
+		if errA != nil || errV != nil {
+			return false
+		}
+		return (arg.lessEq && a <= v) || (arg.greaterEq && a >= v)
+	case arg.noCase:
+		return strings.EqualFold(actual, value)
+	}
+	return actual == value
+}
+
+// fieldValues returns the values of a field. Fields missing from the object
+// are looked up in its lists of sub-objects, so that e.g. a host record
+// is found by the ipv4addr of one of its ipv4addrs.
+func fieldValues(fields map[string]interface{}, name string) []interface{} {
+	if v, ok := fields[name]; ok {
+		return flatten(v)
+	}
+	var res []interface{}
+	for _, v := range fields {
+		list, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, item := range list {
+			if sub, ok := item.(map[string]interface{}); ok {
+				if subValue, ok := sub[name]; ok {
+					res = append(res, flatten(subValue)...)
+				}
+			}
+		}
+	}
+	return res
+}
+
+func flatten(v interface{}) []interface{} {
+	if list, ok := v.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{v}
+}
+
+// eaValue returns the value of an extensible attribute of an object
+func eaValue(fields map[string]interface{}, name string) (interface{}, bool) {
+	eas, _ := fields["extattrs"].(map[string]interface{})
+	ea, ok := eas[name].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	v, ok := ea["value"]
+	return v, ok
+}
+
+// stringValue formats a field value the way it is written in a search
+func stringValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	return fmt.Sprint(v)
+}
+
+// search returns the objects of a type matching the search arguments,
+// which are given in the query or as a JSON object in the body.
+func (s *Server) search(objType string, args url.Values, data interface{}) (interface{}, *wapiError) {
+	var searchArgs []*searchArg
+	for key, values := range args {
+		if strings.HasPrefix(key, "_") {
+			continue
+		}
+		arg, err := parseSearchArg(key, values)
+		if err != nil {
+			return nil, protoError(http.StatusBadRequest, "%s", err)
+		}
+		searchArgs = append(searchArgs, arg)
+	}
+	if fields, ok := data.(map[string]interface{}); ok {
+		for key, value := range fields {
+			arg, err := parseSearchArg(key, []string{stringValue(value)})
+			if err != nil {
+				return nil, protoError(http.StatusBadRequest, "%s", err)
+			}
+			searchArgs = append(searchArgs, arg)
+		}
+	}
+
+	rf := returnFields(args)
+	res := []interface{}{}
+records:
+	for _, id := range s.order {
+		rec := s.records[id]
+		if rec.objType != objType {
+			continue
+		}
+		for _, arg := range searchArgs {
+			if !arg.matches(rec.fields) {
+				continue records
+			}
+		}
+		res = append(res, rec.document(rf))
+	}
+
+	maxResults := 0
+	if v := args.Get("_max_results"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, protoError(http.StatusBadRequest, "Invalid value for _max_results: %s", v)
+		}
+		maxResults = n
+	}
+
+	if args.Get("_paging") == "1" {
+		if maxResults <= 0 {
+			return nil, protoError(http.StatusBadRequest, "_max_results must be set and positive when paging")
+		}
+		return s.page(res, maxResults), nil
+	}
+	if maxResults > 0 && len(res) > maxResults {
+		return nil, dataError(http.StatusBadRequest, "Result set too large (> %d)", maxResults)
+	} else if maxResults < 0 && len(res) > -maxResults {
+		res = res[:-maxResults]
+	}
+	if args.Get("_return_as_object") == "1" {
+		return map[string]interface{}{"result": res}, nil
+	}
+	return res, nil
+}
+
+// page returns the first page of results and keeps the
+// others to be fetched with _page_id
+func (s *Server) page(res []interface{}, size int) interface{} {
+	if len(res) <= size {
+		return map[string]interface{}{"result": res}
+	}
+	s.lastPg++
+	pageId := fmt.Sprintf("fakepage%d", s.lastPg)
+	s.pages[pageId] = &pendingPages{results: res[size:], size: size}
+	return map[string]interface{}{"result": res[:size], "next_page_id": pageId}
+}
+
+func (s *Server) nextPage(args url.Values) (interface{}, *wapiError) {
+	pageId := args.Get("_page_id")
+	pending, ok := s.pages[pageId]
+	if !ok {
+		return nil, protoError(http.StatusBadRequest, "Page %s not found", pageId)
+	}
+	delete(s.pages, pageId)
+	return s.page(pending.results, pending.size), nil
+}
@@ -0,0 +1,816 @@
+// Package fakewapi provides an in-memory fake of the Infoblox WAPI, served
+// by an httptest server, to run code built on ibclient without an appliance.
+//
+// The fake stores objects of any type as JSON documents and understands the
+// URLs built by ibclient.WapiRequestBuilder: searches with modifiers and
+// extensible attributes, _return_fields, paging, the "request" multi-object
+// endpoint, the next_available_ip and next_available_network functions
+// and, once SetCredentials is called, basic authentication with ibapauth
+// session cookies. It does not validate fields against the WAPI schema.
+package fakewapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server is a fake WAPI server. Objects can be seeded with Add
+// and inspected with Get and Objects.
+type Server struct {
+	*httptest.Server
+
+	// Version is the WAPI version clients should use, any version is served.
+	Version string
+
+	mu       sync.Mutex
+	username string
+	password string
+	sessions map[string]bool // ibapauth cookies
+	lastSess int
+	logins   int
+
+	records map[string]*record // by id
+	order   []string           // ids in creation order
+	lastId  int
+	pages   map[string]*pendingPages
+	lastPg  int
+
+	// KeyFields are the fields which identify an object of a given type:
+	// creating an object with the same values as an existing one fails
+	// with a conflict error.
+	KeyFields map[string][]string
+
+	// Defaults are the values given to missing fields of new objects.
+	Defaults map[string]map[string]interface{}
+
+	// SupportedVersions are the WAPI versions served, see SetSchema.
+	SupportedVersions []string
+
+	schemas map[string]map[string]string
+}
+
+// record is a stored object
+type record struct {
+	id      string
+	objType string
+	ref     string
+	fields  map[string]interface{}
+}
+
+// pendingPages are the results of a paged search not fetched yet
+type pendingPages struct {
+	results []interface{}
+	size    int
+}
+
+// DefaultKeyFields are the initial KeyFields of a Server.
+var DefaultKeyFields = map[string][]string{
+	"networkview":            {"name"},
+	"view":                   {"name"},
+	"network":                {"network", "network_view"},
+	"ipv6network":            {"network", "network_view"},
+	"networkcontainer":       {"network", "network_view"},
+	"ipv6networkcontainer":   {"network", "network_view"},
+	"fixedaddress":           {"ipv4addr", "network_view"},
+	"ipv6fixedaddress":       {"ipv6addr", "network_view"},
+	"zone_auth":              {"fqdn", "view"},
+	"zone_forward":           {"fqdn", "view"},
+	"record:a":               {"name", "ipv4addr", "view"},
+	"record:aaaa":            {"name", "ipv6addr", "view"},
+	"record:cname":           {"name", "view"},
+	"record:host":            {"name", "view"},
+	"record:ptr":             {"ptrdname", "ipv4addr", "ipv6addr", "view"},
+	"record:txt":             {"name", "text", "view"},
+	"extensibleattributedef": {"name"},
+}
+
+// DefaultDefaults are the initial Defaults of a Server.
+var DefaultDefaults = map[string]map[string]interface{}{
+	"network":              {"network_view": "default"},
+	"ipv6network":          {"network_view": "default"},
+	"networkcontainer":     {"network_view": "default"},
+	"ipv6networkcontainer": {"network_view": "default"},
+	"fixedaddress":         {"network_view": "default"},
+	"ipv6fixedaddress":     {"network_view": "default"},
+	"range":                {"network_view": "default"},
+	"ipv6range":            {"network_view": "default"},
+	"zone_auth":            {"view": "default"},
+	"zone_forward":         {"view": "default"},
+	"record:a":             {"view": "default"},
+	"record:aaaa":          {"view": "default"},
+	"record:cname":         {"view": "default"},
+	"record:host":          {"view": "default"},
+	"record:mx":            {"view": "default"},
+	"record:ptr":           {"view": "default"},
+	"record:srv":           {"view": "default"},
+	"record:txt":           {"view": "default"},
+}
+
+// DefaultSupportedVersions are the initial SupportedVersions of a Server.
+var DefaultSupportedVersions = []string{"1.0", "2.0", "2.5", "2.9", "2.10", "2.11", "2.12", "2.12.1", "2.12.2", "2.12.3"}
+
+// NewServer starts a fake WAPI server over HTTP. The default
+// network view and DNS view exist, as on a new grid.
+func NewServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// NewTLSServer starts a fake WAPI server over HTTPS,
+// with a certificate clients must not verify.
+func NewTLSServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewTLSServer(s)
+	return s
+}
+
+func newServer() *Server {
+	s := &Server{
+		Version:           "2.12.3",
+		KeyFields:         make(map[string][]string),
+		Defaults:          make(map[string]map[string]interface{}),
+		SupportedVersions: append([]string(nil), DefaultSupportedVersions...),
+		schemas:           make(map[string]map[string]string),
+		sessions:          make(map[string]bool),
+	}
+	for objType, fields := range DefaultKeyFields {
+		s.KeyFields[objType] = fields
+	}
+	for objType, fields := range DefaultDefaults {
+		s.Defaults[objType] = fields
+	}
+	s.Reset()
+	return s
+}
+
+// Reset removes all the objects but the default network view and DNS view.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	s.records = make(map[string]*record)
+	s.order = nil
+	s.pages = make(map[string]*pendingPages)
+	s.mu.Unlock()
+
+	s.Add("networkview", map[string]interface{}{"name": "default", "is_default": true})
+	s.Add("view", map[string]interface{}{"name": "default", "network_view": "default", "is_default": true})
+}
+
+// SetCredentials makes the server require basic authentication.
+func (s *Server) SetCredentials(username string, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.username = username
+	s.password = password
+}
+
+// Host returns the host name clients should connect to.
+func (s *Server) Host() string {
+	u, _ := url.Parse(s.URL)
+	return u.Hostname()
+}
+
+// Port returns the port clients should connect to.
+func (s *Server) Port() string {
+	u, _ := url.Parse(s.URL)
+	return u.Port()
+}
+
+// Add stores an object without any check and returns its reference.
+func (s *Server) Add(objType string, fields map[string]interface{}) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.insert(objType, normalize(fields).(map[string]interface{})).ref
+}
+
+// Get returns the fields of the object with the given reference.
+func (s *Server) Get(ref string) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.lookup(ref)
+	if rec == nil {
+		return nil, false
+	}
+	return rec.document(nil), true
+}
+
+// Objects returns the objects of the given type, with their _ref field.
+func (s *Server) Objects(objType string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []map[string]interface{}
+	for _, id := range s.order {
+		if rec := s.records[id]; rec.objType == objType {
+			res = append(res, rec.document(nil))
+		}
+	}
+	return res
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="InfoBlox ONE Platform"`)
+		http.Error(w, "Authorization Required", http.StatusUnauthorized)
+		return
+	}
+	if session != "" {
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: session, Path: "/", HttpOnly: true})
+	}
+
+	// /wapi/v2.12.3/record:a/ZG5zLmJpbmRfYSQ:a.test.com/default
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 3)
+	if len(parts) < 3 || parts[0] != "wapi" || !strings.HasPrefix(parts[1], "v") {
+		writeError(w, protoError(http.StatusNotFound, "Unknown WAPI path %s", r.URL.Path))
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, protoError(http.StatusBadRequest, "Cannot read request: %s", err))
+		return
+	}
+	var data interface{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if data, err = decode(body); err != nil {
+			writeError(w, protoError(http.StatusBadRequest, "Invalid JSON: %s", err))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	var res interface{}
+	var wErr *wapiError
+	if version := strings.TrimPrefix(parts[1], "v"); !s.supportsVersion(version) {
+		wErr = protoError(http.StatusBadRequest, "Version %s not supported", version)
+	} else if _, ok := r.URL.Query()["_schema"]; ok && r.Method == http.MethodGet && !strings.Contains(parts[2], "/") {
+		res, wErr = s.schema(version, parts[2], r.URL.Query())
+	} else if parts[2] == "" && r.Method == http.MethodGet {
+		wErr = protoError(http.StatusBadRequest, "The _schema argument is required on the WAPI root")
+	} else if parts[2] == "logout" && r.Method == http.MethodPost {
+		s.logout(r)
+	} else if parts[2] == "request" && r.Method == http.MethodPost {
+		res, wErr = s.multiRequest(data)
+	} else {
+		res, wErr = s.do(r.Method, parts[2], r.URL.Query(), data)
+	}
+	s.mu.Unlock()
+
+	if wErr != nil {
+		writeError(w, wErr)
+		return
+	}
+	status := http.StatusOK
+	if r.Method == http.MethodPost && r.URL.Query().Get("_function") == "" && parts[2] != "request" && parts[2] != "logout" {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, res)
+}
+
+// do serves a request for an object type or reference
+func (s *Server) do(method string, object string, args url.Values, data interface{}) (interface{}, *wapiError) {
+	objType, isRef := object, false
+	if i := strings.Index(object, "/"); i >= 0 {
+		objType, isRef = object[:i], true
+	}
+
+	switch {
+	case method == http.MethodGet && args.Get("_page_id") != "":
+		return s.nextPage(args)
+	case method == http.MethodGet && isRef:
+		rec := s.lookup(object)
+		if rec == nil {
+			return nil, refNotFound(object)
+		}
+		return rec.document(returnFields(args)), nil
+	case method == http.MethodGet:
+		return s.search(objType, args, data)
+	case method == http.MethodPost && args.Get("_function") != "":
+		if !isRef {
+			return nil, protoError(http.StatusBadRequest, "Function %s must be called on an object", args.Get("_function"))
+		}
+		return s.call(object, args.Get("_function"), data)
+	case method == http.MethodPost && !isRef:
+		return s.create(objType, args, data)
+	case method == http.MethodPut && isRef:
+		return s.update(object, args, data)
+	case method == http.MethodDelete && isRef:
+		return s.remove(object)
+	}
+	return nil, protoError(http.StatusBadRequest, "Method %s is not supported for %s", method, object)
+}
+
+func (s *Server) create(objType string, args url.Values, data interface{}) (interface{}, *wapiError) {
+	fields, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, protoError(http.StatusBadRequest, "Object body must be a JSON object")
+	}
+	fields = copyMap(fields)
+	for name, value := range s.Defaults[objType] {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+	if err := s.evalFunctions(fields); err != nil {
+		return nil, err
+	}
+	s.computeFields(objType, fields)
+	if err := s.checkConflict(objType, "", fields); err != nil {
+		return nil, err
+	}
+
+	rec := s.insert(objType, fields)
+	if rf := returnFields(args); rf != nil {
+		return rec.document(rf), nil
+	}
+	return rec.ref, nil
+}
+
+func (s *Server) update(ref string, args url.Values, data interface{}) (interface{}, *wapiError) {
+	rec := s.lookup(ref)
+	if rec == nil {
+		return nil, refNotFound(ref)
+	}
+	changes, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, protoError(http.StatusBadRequest, "Object body must be a JSON object")
+	}
+
+	fields := copyMap(rec.fields)
+	for name, value := range changes {
+		switch {
+		case strings.HasSuffix(name, "+"):
+			fields[name[:len(name)-1]] = addValues(fields[name[:len(name)-1]], value)
+		case strings.HasSuffix(name, "-"):
+			fields[name[:len(name)-1]] = removeValues(fields[name[:len(name)-1]], value)
+		default:
+			fields[name] = value
+		}
+	}
+	if err := s.evalFunctions(fields); err != nil {
+		return nil, err
+	}
+	s.computeFields(rec.objType, fields)
+	if err := s.checkConflict(rec.objType, rec.id, fields); err != nil {
+		return nil, err
+	}
+
+	// objects are never modified in place, see multiRequest
+	updated := &record{id: rec.id, objType: rec.objType, fields: fields}
+	updated.ref = makeRef(updated)
+	s.records[rec.id] = updated
+	if rf := returnFields(args); rf != nil {
+		return updated.document(rf), nil
+	}
+	return updated.ref, nil
+}
+
+func (s *Server) remove(ref string) (interface{}, *wapiError) {
+	rec := s.lookup(ref)
+	if rec == nil {
+		return nil, refNotFound(ref)
+	}
+	delete(s.records, rec.id)
+	for i, id := range s.order {
+		if id == rec.id {
+			s.order = append(s.order[:i:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return rec.ref, nil
+}
+
+func (s *Server) insert(objType string, fields map[string]interface{}) *record {
+	s.lastId++
+	rec := &record{
+		id:      fmt.Sprintf("fake.%s$%d", objType, s.lastId),
+		objType: objType,
+		fields:  fields,
+	}
+	rec.ref = makeRef(rec)
+	s.records[rec.id] = rec
+	s.order = append(s.order, rec.id)
+	return rec
+}
+
+// lookup returns the object a reference points to. As on NIOS, only the
+// object ID of the reference matters, not the name following it.
+func (s *Server) lookup(ref string) *record {
+	i := strings.Index(ref, "/")
+	if i < 0 {
+		return nil
+	}
+	encoded := ref[i+1:]
+	if j := strings.Index(encoded, ":"); j >= 0 {
+		encoded = encoded[:j]
+	}
+	id, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil
+	}
+	rec := s.records[string(id)]
+	if rec == nil || rec.objType != ref[:i] {
+		return nil
+	}
+	return rec
+}
+
+func (s *Server) checkConflict(objType string, id string, fields map[string]interface{}) *wapiError {
+	keys := s.KeyFields[objType]
+	if len(keys) == 0 {
+		return nil
+	}
+	for _, other := range s.records {
+		if other.objType != objType || other.id == id {
+			continue
+		}
+		same := true
+		for _, k := range keys {
+			if fmt.Sprint(other.fields[k]) != fmt.Sprint(fields[k]) {
+				same = false
+				break
+			}
+		}
+		if same {
+			return conflictError(objType, other.ref)
+		}
+	}
+	return nil
+}
+
+// refNameFields are the fields which make the readable part of the
+// references of some object types, see makeRef
+var refNameFields = map[string][]string{
+	"fixedaddress":     {"ipv4addr", "network_view"},
+	"ipv6fixedaddress": {"ipv6addr", "network_view"},
+	"range":            {"start_addr", "end_addr", "network_view"},
+	"ipv6range":        {"start_addr", "end_addr", "network_view"},
+	"record:ptr":       {"name", "view"},
+}
+
+// makeRef builds a reference in the NIOS format: the object type, an
+// encoded object ID and a readable name, e.g. network/ZmFrZS4...:10.0.0.0/24/default
+func makeRef(rec *record) string {
+	var parts []string
+	if fields, ok := refNameFields[rec.objType]; ok {
+		for _, f := range fields {
+			parts = append(parts, stringValue(rec.fields[f]))
+		}
+	} else {
+		name := ""
+		for _, f := range []string{"name", "fqdn", "network", "ipv4addr", "ipv6addr"} {
+			if v, ok := rec.fields[f].(string); ok && v != "" {
+				name = v
+				break
+			}
+		}
+		parts = append(parts, name)
+		for _, f := range []string{"view", "network_view"} {
+			if v, ok := rec.fields[f].(string); ok && v != "" {
+				parts = append(parts, v)
+				break
+			}
+		}
+		if rec.objType == "networkview" || rec.objType == "view" {
+			parts = append(parts, strconv.FormatBool(rec.fields["is_default"] == true))
+		}
+	}
+	for i, part := range parts {
+		// as on NIOS, e.g. ipv6network/ZmFrZS4...:2001%3Adb8%3A%3A/64/default
+		parts[i] = strings.ReplaceAll(part, ":", "%3A")
+	}
+	return rec.objType + "/" + base64.RawURLEncoding.EncodeToString([]byte(rec.id)) + ":" + strings.Join(parts, "/")
+}
+
+// computeFields sets the fields NIOS computes for new and updated objects:
+// the name of the PTR records given by their address, the zone of the DNS
+// records and the network container of the networks.
+func (s *Server) computeFields(objType string, fields map[string]interface{}) {
+	switch objType {
+	case "network", "networkcontainer", "ipv6network", "ipv6networkcontainer":
+		fields["network_container"] = s.parentContainer(objType, fields)
+	}
+	if objType == "record:ptr" && stringValue(fields["name"]) == "" {
+		for _, f := range []string{"ipv4addr", "ipv6addr"} {
+			if addr, err := netip.ParseAddr(stringValue(fields[f])); err == nil {
+				fields["name"] = reverseName(addr)
+				break
+			}
+		}
+	}
+	if !strings.HasPrefix(objType, "record:") {
+		return
+	}
+	name := strings.ToLower(stringValue(fields["name"]))
+	zone := ""
+	for _, rec := range s.records {
+		if rec.objType != "zone_auth" || stringValue(rec.fields["view"]) != stringValue(fields["view"]) {
+			continue
+		}
+		fqdn := strings.ToLower(stringValue(rec.fields["fqdn"]))
+		if (name == fqdn || strings.HasSuffix(name, "."+fqdn)) && len(fqdn) > len(zone) {
+			zone = fqdn
+		}
+	}
+	if zone != "" {
+		fields["zone"] = zone
+	}
+}
+
+// parentContainer returns the smallest network container of the network
+// view of a network which includes it, "/" when there is none
+func (s *Server) parentContainer(objType string, fields map[string]interface{}) string {
+	prefix, err := netip.ParsePrefix(stringValue(fields["network"]))
+	if err != nil {
+		return "/"
+	}
+	containerType := "networkcontainer"
+	if strings.HasPrefix(objType, "ipv6") {
+		containerType = "ipv6networkcontainer"
+	}
+	parent, bits := "/", -1
+	for _, rec := range s.records {
+		if rec.objType != containerType || stringValue(rec.fields["network_view"]) != stringValue(fields["network_view"]) {
+			continue
+		}
+		container, err := netip.ParsePrefix(stringValue(rec.fields["network"]))
+		if err == nil && container.Bits() < prefix.Bits() && container.Bits() > bits && container.Contains(prefix.Addr()) {
+			parent, bits = container.Masked().String(), container.Bits()
+		}
+	}
+	return parent
+}
+
+// reverseName returns the name of the PTR record of an address,
+// e.g. 4.3.2.1.in-addr.arpa for 1.2.3.4
+func reverseName(addr netip.Addr) string {
+	var labels []string
+	if addr.Is4() {
+		for _, b := range addr.As4() {
+			labels = append([]string{strconv.Itoa(int(b))}, labels...)
+		}
+		return strings.Join(labels, ".") + ".in-addr.arpa"
+	}
+	for _, b := range addr.As16() {
+		labels = append([]string{strconv.FormatInt(int64(b&0xf), 16), strconv.FormatInt(int64(b>>4), 16)}, labels...)
+	}
+	return strings.Join(labels, ".") + ".ip6.arpa"
+}
+
+// document returns the object with its reference and the
+// given fields, or all its fields when returnFields is nil.
+func (rec *record) document(returnFields []string) map[string]interface{} {
+	doc := map[string]interface{}{"_ref": rec.ref}
+	if returnFields == nil {
+		for name, value := range rec.fields {
+			doc[name] = value
+		}
+		return doc
+	}
+	for _, name := range returnFields {
+		if value, ok := rec.fields[name]; ok {
+			doc[name] = value
+		}
+	}
+	return doc
+}
+
+// returnFields returns the fields requested with _return_fields
+// or _return_fields+, nil meaning all fields.
+func returnFields(args url.Values) []string {
+	if _, ok := args["_return_fields+"]; ok {
+		return nil
+	}
+	rf, ok := args["_return_fields"]
+	if !ok {
+		return nil
+	}
+	fields := []string{}
+	for _, v := range rf {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+// multiRequest serves the "request" object: the requests are done in
+// order and the changes are rolled back if any of them fails.
+func (s *Server) multiRequest(data interface{}) (interface{}, *wapiError) {
+	reqs, ok := data.([]interface{})
+	if !ok {
+		if single, isMap := data.(map[string]interface{}); isMap {
+			reqs = []interface{}{single}
+		} else {
+			return nil, protoError(http.StatusBadRequest, "Request body must be a JSON list")
+		}
+	}
+
+	records := make(map[string]*record, len(s.records))
+	for id, rec := range s.records {
+		records[id] = rec
+	}
+	order, lastId := append([]string(nil), s.order...), s.lastId
+	rollback := func() {
+		s.records, s.order, s.lastId = records, order, lastId
+	}
+
+	state := map[string]interface{}{}
+	results := []interface{}{}
+	for i, item := range reqs {
+		req, ok := item.(map[string]interface{})
+		if !ok {
+			rollback()
+			return nil, protoError(http.StatusBadRequest, "Request %d must be a JSON object", i)
+		}
+		if substitute, _ := req["enable_substitution"].(bool); substitute {
+			req = substituteState(req, state).(map[string]interface{})
+		}
+		method, _ := req["method"].(string)
+		object, _ := req["object"].(string)
+
+		var res interface{}
+		var err *wapiError
+		switch method {
+		case "STATE:DISPLAY":
+			res = copyMap(state)
+		case "STATE:ASSIGN":
+			if fields, ok := req["data"].(map[string]interface{}); ok {
+				for k, v := range fields {
+					state[k] = v
+				}
+			}
+		default:
+			args := url.Values{}
+			if reqArgs, ok := req["args"].(map[string]interface{}); ok {
+				for k, v := range reqArgs {
+					args.Set(k, fmt.Sprint(v))
+				}
+			}
+			if method == http.MethodGet {
+				// the data of a search are its search fields
+				if fields, ok := req["data"].(map[string]interface{}); ok {
+					for k, v := range fields {
+						args.Set(k, stringValue(v))
+					}
+				}
+				res, err = s.do(method, object, args, nil)
+			} else {
+				res, err = s.do(method, object, args, req["data"])
+			}
+		}
+		if err == nil {
+			err = assignState(state, req["assign_state"], res, i)
+		}
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+		if discard, _ := req["discard"].(bool); !discard {
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
+var stateVariable = regexp.MustCompile(`##STATE:([^:#]+):##`)
+
+// substituteState replaces the ##STATE:name:## placeholders of the strings in v
+func substituteState(v interface{}, state map[string]interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return stateVariable.ReplaceAllStringFunc(val, func(m string) string {
+			return stringValue(state[stateVariable.FindStringSubmatch(m)[1]])
+		})
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			res[k] = substituteState(item, state)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, item := range val {
+			res[i] = substituteState(item, state)
+		}
+		return res
+	}
+	return v
+}
+
+// assignState stores the fields of a request result in state variables,
+// a field named "*EA" is the value of the extensible attribute EA.
+func assignState(state map[string]interface{}, assign interface{}, res interface{}, i int) *wapiError {
+	vars, ok := assign.(map[string]interface{})
+	if !ok || len(vars) == 0 {
+		return nil
+	}
+	switch val := res.(type) {
+	case []interface{}:
+		if len(val) == 0 {
+			return dataError(http.StatusBadRequest, "Request %d returned no object to assign state from", i)
+		}
+		res = val[0]
+	case string:
+		res = map[string]interface{}{"_ref": val}
+	}
+	doc, _ := res.(map[string]interface{})
+	for name, field := range vars {
+		fieldName, _ := field.(string)
+		if strings.HasPrefix(fieldName, "*") {
+			if value, ok := eaValue(doc, fieldName[1:]); ok {
+				state[name] = value
+			}
+		} else if value, ok := doc[fieldName]; ok {
+			state[name] = value
+		}
+	}
+	return nil
+}
+
+// decode unmarshals JSON keeping the numbers as they are
+func decode(data []byte) (interface{}, error) {
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&v)
+	return v, err
+}
+
+// normalize converts v to the types decode produces
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	res, err := decode(data)
+	if err != nil {
+		return v
+	}
+	return res
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
+// addValues implements the "field+" update of lists and extensible attributes
+func addValues(current interface{}, added interface{}) interface{} {
+	switch val := added.(type) {
+	case map[string]interface{}:
+		res, _ := current.(map[string]interface{})
+		res = copyMap(res)
+		for k, v := range val {
+			res[k] = v
+		}
+		return res
+	case []interface{}:
+		list, _ := current.([]interface{})
+		return append(append([]interface{}(nil), list...), val...)
+	}
+	return added
+}
+
+// removeValues implements the "field-" update of lists and extensible attributes
+func removeValues(current interface{}, removed interface{}) interface{} {
+	switch val := removed.(type) {
+	case map[string]interface{}:
+		res, _ := current.(map[string]interface{})
+		res = copyMap(res)
+		for k := range val {
+			delete(res, k)
+		}
+		return res
+	case []interface{}:
+		list, _ := current.([]interface{})
+		res := []interface{}{}
+	items:
+		for _, item := range list {
+			for _, r := range val {
+				if fmt.Sprint(item) == fmt.Sprint(r) {
+					continue items
+				}
+			}
+			res = append(res, item)
+		}
+		return res
+	}
+	return current
+}
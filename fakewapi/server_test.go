@@ -0,0 +1,236 @@
+package fakewapi_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fake WAPI server", func() {
+	var (
+		server *fakewapi.Server
+		conn   *ibclient.Connector
+		objMgr *ibclient.ObjectManager
+	)
+
+	newConnector := func(username string, password string) *ibclient.Connector {
+		hostCfg := ibclient.HostConfig{
+			Scheme:  "http",
+			Host:    server.Host(),
+			Port:    server.Port(),
+			Version: server.Version,
+		}
+		authCfg := ibclient.AuthConfig{Username: username, Password: password}
+		c, err := ibclient.NewConnector(hostCfg, authCfg, ibclient.NewTransportConfig("false", 10, 1),
+			&ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+		Expect(err).To(BeNil())
+		return c
+	}
+
+	BeforeEach(func() {
+		server = fakewapi.NewServer()
+		server.SetCredentials("admin", "infoblox")
+		conn = newConnector("admin", "infoblox")
+		objMgr = ibclient.NewObjectManager(conn, "", "").(*ibclient.ObjectManager)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should create, get, update and delete objects", func() {
+		nv, err := objMgr.CreateNetworkView("private-view", "first", ibclient.EA{"Site": "Paris"})
+		Expect(err).To(BeNil())
+		Expect(nv.Ref).To(HavePrefix("networkview/"))
+		Expect(nv.Ref).To(HaveSuffix(":private-view/false"))
+
+		found, err := objMgr.GetNetworkView("private-view")
+		Expect(err).To(BeNil())
+		Expect(found.Ref).To(Equal(nv.Ref))
+		Expect(*found.Comment).To(Equal("first"))
+		Expect(found.Ea).To(Equal(ibclient.EA{"Site": "Paris"}))
+
+		updated, err := objMgr.UpdateNetworkView(nv.Ref, "renamed-view", "second", nil)
+		Expect(err).To(BeNil())
+		Expect(updated.Ref).To(HaveSuffix(":renamed-view/false"))
+		stored, ok := server.Get(updated.Ref)
+		Expect(ok).To(BeTrue())
+		Expect(stored["comment"]).To(Equal("second"))
+
+		_, err = objMgr.DeleteNetworkView(updated.Ref)
+		Expect(err).To(BeNil())
+		_, err = objMgr.GetNetworkViewByRef(updated.Ref)
+		Expect(ibclient.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should reject duplicate objects", func() {
+		_, err := objMgr.CreateNetworkView("private-view", "", nil)
+		Expect(err).To(BeNil())
+		_, err = objMgr.CreateNetworkView("private-view", "", nil)
+		Expect(ibclient.IsConflict(err)).To(BeTrue())
+	})
+
+	It("should reject wrong credentials", func() {
+		_, err := newConnector("admin", "wrong").CreateObject(ibclient.NewNetworkView("private-view", "", nil, ""))
+		Expect(ibclient.IsAuthFailure(err)).To(BeTrue())
+	})
+
+	It("should honor search modifiers, EA searches and return fields", func() {
+		server.Add("networkview", map[string]interface{}{"name": "Blue-view", "comment": "b",
+			"extattrs": map[string]interface{}{"Site": map[string]interface{}{"value": "Paris"}}})
+		server.Add("networkview", map[string]interface{}{"name": "red-view", "comment": "r",
+			"extattrs": map[string]interface{}{"Site": map[string]interface{}{"value": "London"}}})
+
+		search := func(sf map[string]string) []ibclient.NetworkView {
+			var res []ibclient.NetworkView
+			err := conn.GetObject(ibclient.NewEmptyNetworkView(), "", ibclient.NewQueryParams(false, sf), &res)
+			if ibclient.IsNotFound(err) {
+				return nil
+			}
+			Expect(err).To(BeNil())
+			return res
+		}
+
+		Expect(search(map[string]string{"name~": "-view$"})).To(HaveLen(2))
+		Expect(search(map[string]string{"name~": "^blue"})).To(BeEmpty())
+		Expect(search(map[string]string{"name~:": "^blue"})).To(HaveLen(1))
+		Expect(search(map[string]string{"name:": "BLUE-VIEW"})).To(HaveLen(1))
+		Expect(search(map[string]string{"name!": "default"})).To(HaveLen(2))
+		Expect(search(map[string]string{"*Site": "London"})).To(HaveLen(1))
+		Expect(search(map[string]string{"*Site": "London,Paris"})).To(HaveLen(2))
+
+		var res []map[string]interface{}
+		qp := ibclient.NewQueryParams(false, map[string]string{"name": "red-view"})
+		err := conn.GetObject(ibclient.NewEmptyNetworkView(), "", qp, &res)
+		Expect(err).To(BeNil())
+		Expect(res).To(HaveLen(1))
+		Expect(res[0]).To(HaveKey("_ref"))
+		Expect(res[0]).To(HaveKey("comment"))
+		Expect(res[0]).NotTo(HaveKey("is_default"))
+	})
+
+	It("should serve results in pages", func() {
+		for _, name := range []string{"a", "b", "c", "d"} {
+			server.Add("networkview", map[string]interface{}{"name": name})
+		}
+		qp := ibclient.NewQueryParams(false, nil)
+		qp.SetPaging(2)
+		var res []ibclient.NetworkView
+		err := conn.GetObject(ibclient.NewEmptyNetworkView(), "", qp, &res)
+		Expect(err).To(BeNil())
+		Expect(res).To(HaveLen(5))
+	})
+
+	It("should allocate the next available IP addresses", func() {
+		_, err := objMgr.CreateNetwork("default", "10.0.0.0/30", false, "", nil)
+		Expect(err).To(BeNil())
+
+		fa, err := objMgr.AllocateIP("default", "10.0.0.0/30", "", false, "", "", "", nil, "", "", "", nil, "", false, nil, false)
+		Expect(err).To(BeNil())
+		Expect(fa.IPv4Address).To(Equal("10.0.0.1"))
+
+		host, err := objMgr.CreateHostRecord(false, false, "host.test.com", "default", "default",
+			"10.0.0.0/30", "", "", "", "", "", false, 0, "", nil, nil, false)
+		Expect(err).To(BeNil())
+		Expect(*host.Ipv4Addrs[0].Ipv4Addr).To(Equal("10.0.0.2"))
+
+		found, err := objMgr.GetHostRecord("", "default", "host.test.com", "10.0.0.2", "")
+		Expect(err).To(BeNil())
+		Expect(found.Ref).To(Equal(host.Ref))
+
+		// 10.0.0.3 is the broadcast address
+		_, err = objMgr.AllocateIP("default", "10.0.0.0/30", "", false, "", "", "", nil, "", "", "", nil, "", false, nil, false)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should call next_available_ip on networks", func() {
+		ref := server.Add("network", map[string]interface{}{"network": "10.0.0.0/24", "network_view": "default"})
+		server.Add("fixedaddress", map[string]interface{}{"ipv4addr": "10.0.0.2", "network_view": "default"})
+
+		req, _ := http.NewRequest("POST", server.URL+"/wapi/v2.12.3/"+ref+"?_function=next_available_ip",
+			strings.NewReader(`{"num": 3, "exclude": ["10.0.0.3"]}`))
+		req.SetBasicAuth("admin", "infoblox")
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).To(BeNil())
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		var res struct{ Ips []string }
+		Expect(json.Unmarshal(body, &res)).To(Succeed())
+		Expect(res.Ips).To(Equal([]string{"10.0.0.1", "10.0.0.4", "10.0.0.5"}))
+	})
+
+	It("should allocate the next available networks", func() {
+		_, err := objMgr.CreateNetworkContainer("default", "10.0.0.0/16", false, "", nil)
+		Expect(err).To(BeNil())
+		server.Add("network", map[string]interface{}{"network": "10.0.0.0/24", "network_view": "default"})
+
+		network, err := objMgr.AllocateNetwork("default", "10.0.0.0/16", false, 24, "", nil)
+		Expect(err).To(BeNil())
+		Expect(network.Cidr).To(Equal("10.0.1.0/24"))
+		stored, _ := server.Get(network.Ref)
+		Expect(stored["network_container"]).To(Equal("10.0.0.0/16"))
+
+		v6, err := objMgr.CreateNetworkContainer("default", "2001:db8::/48", true, "", nil)
+		Expect(err).To(BeNil())
+		Expect(v6.Ref).To(HaveSuffix(":2001%3Adb8%3A%3A/48/default"))
+		req, _ := http.NewRequest("POST", server.URL+"/wapi/v2.12.3/"+v6.Ref+"?_function=next_available_network",
+			strings.NewReader(`{"cidr": 64, "num": 2, "exclude": ["2001:db8::/64"]}`))
+		req.SetBasicAuth("admin", "infoblox")
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).To(BeNil())
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		var res struct{ Networks []string }
+		Expect(json.Unmarshal(body, &res)).To(Succeed())
+		Expect(res.Networks).To(Equal([]string{"2001:db8:0:1::/64", "2001:db8:0:2::/64"}))
+	})
+
+	It("should compute the zone of DNS records and the name of PTR records", func() {
+		server.Add("zone_auth", map[string]interface{}{"fqdn": "test.com", "view": "default"})
+		server.Add("zone_auth", map[string]interface{}{"fqdn": "sub.test.com", "view": "default"})
+
+		rec, err := objMgr.CreateARecord("default", "default", "a.sub.test.com", "", "10.0.0.1", 0, false, "", nil)
+		Expect(err).To(BeNil())
+		stored, _ := server.Get(rec.Ref)
+		Expect(stored["zone"]).To(Equal("sub.test.com"))
+
+		ptr, err := objMgr.CreatePTRRecord("default", "default", "a.test.com", "", "", "10.0.0.1", false, 0, "", nil)
+		Expect(err).To(BeNil())
+		Expect(ptr.Ref).To(HaveSuffix(":1.0.0.10.in-addr.arpa/default"))
+	})
+
+	It("should serve multi-object requests", func() {
+		_, err := objMgr.CreateNetworkView("private-view", "", ibclient.EA{"Lock": "Available"})
+		Expect(err).To(BeNil())
+		lock := &ibclient.NetworkViewLock{Name: "private-view", ObjMgr: objMgr, LockEA: "Lock", LockTimeoutEA: "LockTime"}
+
+		Expect(lock.Lock()).To(Succeed())
+		nv, err := objMgr.GetNetworkView("private-view")
+		Expect(err).To(BeNil())
+		Expect(nv.Ea).To(HaveKey("LockTime"))
+		Expect(lock.UnLock(false)).To(Succeed())
+		nv, err = objMgr.GetNetworkView("private-view")
+		Expect(err).To(BeNil())
+		Expect(nv.Ea).To(Equal(ibclient.EA{"Lock": "Available"}))
+	})
+
+	It("should roll multi-object requests back when one fails", func() {
+		_, err := objMgr.CreateMultiObject(ibclient.NewMultiRequest([]*ibclient.RequestBody{
+			{Method: "POST", Object: "networkview", Data: map[string]interface{}{"name": "first"}},
+			{Method: "DELETE", Object: "networkview/bWlzc2luZw:missing/false"},
+		}))
+		Expect(ibclient.IsNotFound(err)).To(BeTrue())
+		Expect(server.Objects("networkview")).To(HaveLen(1))
+	})
+})
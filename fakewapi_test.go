@@ -0,0 +1,27 @@
+package ibclient_test
+
+import (
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// newFakeConnector starts a fake WAPI server, closed once the current spec
+// is done, and returns it with a connector to it.
+func newFakeConnector() (*fakewapi.Server, *ibclient.Connector) {
+	server := fakewapi.NewServer()
+	DeferCleanup(server.Close)
+	return server, connectToFake(server, ibclient.AuthConfig{}, server.Version)
+}
+
+// connectToFake returns a connector to a fake WAPI server
+// authenticating with authCfg and using the given WAPI version.
+func connectToFake(server *fakewapi.Server, authCfg ibclient.AuthConfig, version string) *ibclient.Connector {
+	hostCfg := ibclient.HostConfig{Scheme: "http", Host: server.Host(), Port: server.Port(), Version: version}
+	conn, err := ibclient.NewConnector(hostCfg, authCfg, ibclient.NewTransportConfig("false", 10, 1),
+		&ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+	ExpectWithOffset(1, err).To(BeNil())
+	return conn
+}
@@ -0,0 +1,144 @@
+package ibclient
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Object is satisfied by pointers to the WAPI object structs, e.g.
+// *Adminuser or *RecordCaa, which lets the generic functions below
+// allocate objects of type T. Types such as Network, whose object type
+// depends on a field, can't be listed since a zero T has no object type.
+type Object[T any] interface {
+	*T
+	IBObject
+}
+
+// Get returns the object of type T with the given reference. The object's
+// default return fields are fetched, unless returnFields are given.
+func Get[T any, PT Object[T]](conn IBConnector, ref string, returnFields ...string) (*T, error) {
+	obj := PT(new(T))
+	if err := checkRefType(ref, obj.ObjectType()); err != nil {
+		return nil, err
+	}
+	if len(returnFields) > 0 {
+		obj.SetReturnFields(returnFields)
+	}
+
+	res := new(T)
+	if err := conn.GetObject(obj, ref, NewQueryParams(false, nil), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// List returns the objects of type T matching the search fields of
+// queryParams, which may be nil to list all of them. No match is not
+// an error: an empty list is returned.
+func List[T any, PT Object[T]](conn IBConnector, queryParams *QueryParams, returnFields ...string) ([]T, error) {
+	obj := PT(new(T))
+	if obj.ObjectType() == "" {
+		return nil, fmt.Errorf("the object type of %T is not known", obj)
+	}
+	if len(returnFields) > 0 {
+		obj.SetReturnFields(returnFields)
+	}
+	if queryParams == nil {
+		queryParams = NewQueryParams(false, nil)
+	}
+
+	var res []T
+	err := conn.GetObject(obj, "", queryParams, &res)
+	if isEmptyResult(err) {
+		return []T{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Create creates obj and returns the created object, as stored by NIOS.
+func Create[T any, PT Object[T]](conn IBConnector, obj PT) (*T, error) {
+	ref, err := conn.CreateObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		return nil, fmt.Errorf("no reference returned for the new %s object", obj.ObjectType())
+	}
+	return Get[T, PT](conn, ref, obj.ReturnFields()...)
+}
+
+// Update updates the object with the given reference with the fields
+// set in obj, and returns the updated object.
+func Update[T any, PT Object[T]](conn IBConnector, ref string, obj PT) (*T, error) {
+	if err := checkRefType(ref, obj.ObjectType()); err != nil {
+		return nil, err
+	}
+	newRef, err := conn.UpdateObject(obj, ref)
+	if err != nil {
+		return nil, err
+	}
+	return Get[T, PT](conn, newRef, obj.ReturnFields()...)
+}
+
+// GetByRef returns the object with the given reference, of the type
+// Ref.NewObject picks for it, e.g. a *Network for both network and
+// ipv6network references.
+func GetByRef(conn IBConnector, ref string, returnFields ...string) (IBObject, error) {
+	r, err := ParseRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := r.NewObject()
+	if err != nil {
+		return nil, err
+	}
+	if len(returnFields) > 0 {
+		obj.SetReturnFields(returnFields)
+	}
+	if err = conn.GetObject(obj, ref, NewQueryParams(false, nil), obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Delete deletes the object with the given reference,
+// whatever its type, and returns the reference.
+func Delete(conn IBConnector, ref string) (string, error) {
+	if _, err := refObjectType(ref); err != nil {
+		return "", err
+	}
+	return conn.DeleteObject(ref)
+}
+
+// refObjectType returns the object type of a reference,
+// e.g. record:a for record:a/ZG5zLmJpbmRfYSQ:a.test.com/default
+func refObjectType(ref string) (string, error) {
+	r, err := ParseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	return r.ObjectType, nil
+}
+
+// checkRefType checks that ref is a reference to an object of the given
+// type, which is not known for types such as Network when it is empty.
+func checkRefType(ref string, objType string) error {
+	refType, err := refObjectType(ref)
+	if err != nil {
+		return err
+	}
+	if objType != "" && refType != objType {
+		return fmt.Errorf("reference '%s' is not of type %s", ref, objType)
+	}
+	return nil
+}
+
+// isEmptyResult reports whether err is the NotFoundError GetObject
+// returns for a search without results, rather than a WAPI error.
+func isEmptyResult(err error) bool {
+	var notFoundErr *NotFoundError
+	return errors.As(err, &notFoundErr) && notFoundErr.err == nil
+}
@@ -0,0 +1,104 @@
+package ibclient_test
+
+import (
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generic CRUD", func() {
+	var (
+		server *fakewapi.Server
+		conn   *ibclient.Connector
+	)
+
+	BeforeEach(func() {
+		server, conn = newFakeConnector()
+	})
+
+	It("should create, get, update and delete objects", func() {
+		nsg, err := ibclient.Create(conn, &ibclient.Nsgroup{Name: utils.StringPtr("ns-group"), Comment: utils.StringPtr("first")})
+		Expect(err).To(BeNil())
+		Expect(nsg.Ref).To(HavePrefix("nsgroup/"))
+		Expect(*nsg.Name).To(Equal("ns-group"))
+
+		got, err := ibclient.Get[ibclient.Nsgroup](conn, nsg.Ref)
+		Expect(err).To(BeNil())
+		Expect(got).To(Equal(nsg))
+
+		updated, err := ibclient.Update(conn, nsg.Ref, &ibclient.Nsgroup{Comment: utils.StringPtr("second")})
+		Expect(err).To(BeNil())
+		Expect(*updated.Name).To(Equal("ns-group"))
+		Expect(*updated.Comment).To(Equal("second"))
+
+		ref, err := ibclient.Delete(conn, updated.Ref)
+		Expect(err).To(BeNil())
+		Expect(ref).To(Equal(updated.Ref))
+
+		_, err = ibclient.Get[ibclient.Nsgroup](conn, updated.Ref)
+		Expect(ibclient.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should fetch the given return fields only", func() {
+		ref := server.Add("adminuser", map[string]interface{}{
+			"name": "john", "comment": "admin", "email": "john@example.com"})
+
+		user, err := ibclient.Get[ibclient.Adminuser](conn, ref, "email")
+		Expect(err).To(BeNil())
+		Expect(user.Ref).To(Equal(ref))
+		Expect(*user.Email).To(Equal("john@example.com"))
+		Expect(user.Name).To(BeNil())
+	})
+
+	It("should list objects matching search fields", func() {
+		server.Add("nsgroup", map[string]interface{}{"name": "group-a"})
+		server.Add("nsgroup", map[string]interface{}{"name": "group-b"})
+		server.Add("nsgroup", map[string]interface{}{"name": "other"})
+
+		groups, err := ibclient.List[ibclient.Nsgroup](conn, nil)
+		Expect(err).To(BeNil())
+		Expect(groups).To(HaveLen(3))
+
+		groups, err = ibclient.List[ibclient.Nsgroup](conn, ibclient.NewQueryParams(false, map[string]string{"name~": "^group-"}))
+		Expect(err).To(BeNil())
+		Expect(groups).To(HaveLen(2))
+		Expect(*groups[1].Name).To(Equal("group-b"))
+
+		groups, err = ibclient.List[ibclient.Nsgroup](conn, ibclient.NewQueryParams(false, map[string]string{"name": "missing"}))
+		Expect(err).To(BeNil())
+		Expect(groups).To(BeEmpty())
+	})
+
+	It("should reject references to other object types", func() {
+		ref := server.Add("nsgroup", map[string]interface{}{"name": "ns-group"})
+
+		_, err := ibclient.Get[ibclient.Adminuser](conn, ref)
+		Expect(err).To(MatchError(ContainSubstring("is not of type adminuser")))
+		_, err = ibclient.Update(conn, ref, &ibclient.Adminuser{})
+		Expect(err).NotTo(BeNil())
+		_, err = ibclient.Delete(conn, "nsgroup")
+		Expect(err).To(MatchError("invalid reference 'nsgroup'"))
+	})
+
+	It("should not list types whose object type is not fixed", func() {
+		_, err := ibclient.List[ibclient.Network](conn, nil)
+		Expect(err).To(MatchError(ContainSubstring("is not known")))
+	})
+
+	It("should get objects of the struct matching their reference", func() {
+		ref := server.Add("ipv6network", map[string]interface{}{"network": "2001:db8::/64", "network_view": "default"})
+
+		obj, err := ibclient.GetByRef(conn, ref)
+		Expect(err).To(BeNil())
+		network, ok := obj.(*ibclient.Network)
+		Expect(ok).To(BeTrue())
+		Expect(network.ObjectType()).To(Equal("ipv6network"))
+		Expect(network.Cidr).To(Equal("2001:db8::/64"))
+
+		_, err = ibclient.GetByRef(conn, "record:a/ZG5z:a.test.com/default")
+		Expect(err).To(MatchError("no object is known for the references of type record:a"))
+	})
+})
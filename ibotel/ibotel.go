@@ -0,0 +1,93 @@
+// Package ibotel implements ibclient.Tracer with OpenTelemetry, so that
+// every WAPI request of a connector has a client span in the traces of
+// its callers and propagates their trace context to the grid:
+//
+//	conn.SetTracer(ibotel.NewTracer(nil, nil))
+//
+// The spans are named after the method and the object type of the
+// request, e.g. "WAPI GET record:a", and carry the attributes
+// http.request.method, wapi.object_type, wapi.ref, wapi.version and
+// wapi.force_proxy.
+package ibotel
+
+import (
+	"context"
+	"net/http"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the OpenTelemetry tracer
+const instrumentationName = "github.com/infobloxopen/infoblox-go-client/v2/ibotel"
+
+// Compile-time interface checks
+var _ ibclient.Tracer = new(Tracer)
+
+// Tracer starts OpenTelemetry spans for the WAPI requests.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewTracer returns a tracer starting the spans with provider and
+// injecting the trace context with propagator. The global tracer provider
+// and propagator are used when they are nil.
+func NewTracer(provider trace.TracerProvider, propagator propagation.TextMapPropagator) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	return &Tracer{
+		tracer:     provider.Tracer(instrumentationName),
+		propagator: propagator,
+	}
+}
+
+func (t *Tracer) StartSpan(ctx context.Context, info ibclient.SpanInfo) (context.Context, ibclient.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", info.Method),
+		attribute.String("wapi.object_type", info.ObjectType),
+		attribute.String("wapi.version", info.WapiVersion),
+		attribute.Bool("wapi.force_proxy", info.ForceProxy),
+	}
+	if info.Ref != "" {
+		attrs = append(attrs, attribute.String("wapi.ref", info.Ref))
+	}
+	ctx, span := t.tracer.Start(ctx, "WAPI "+info.Method+" "+info.ObjectType,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	return ctx, &otelSpan{span: span}
+}
+
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// otelSpan adapts an OpenTelemetry span to ibclient.Span
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) AddEvent(name string, attrs map[string]string) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		kvs = append(kvs, attribute.String(k, v))
+	}
+	s.span.AddEvent(name, trace.WithAttributes(kvs...))
+}
+
+func (s *otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *otelSpan) End() {
+	s.span.End()
+}
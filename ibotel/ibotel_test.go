@@ -0,0 +1,135 @@
+package ibotel_test
+
+import (
+	"context"
+	"net/http"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/ibotel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OpenTelemetry tracer", func() {
+	var (
+		server   *fakewapi.Server
+		conn     *ibclient.Connector
+		exporter *tracetest.InMemoryExporter
+		provider *sdktrace.TracerProvider
+		headers  []http.Header
+	)
+
+	BeforeEach(func() {
+		server = fakewapi.NewServer()
+		DeferCleanup(server.Close)
+		hostCfg := ibclient.HostConfig{Scheme: "http", Host: server.Host(), Port: server.Port(), Version: server.Version}
+		var err error
+		conn, err = ibclient.NewConnector(hostCfg, ibclient.AuthConfig{}, ibclient.NewTransportConfig("false", 10, 1),
+			&ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+		Expect(err).To(BeNil())
+
+		exporter = tracetest.NewInMemoryExporter()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		conn.SetTracer(ibotel.NewTracer(provider, propagation.TraceContext{}))
+
+		headers = nil
+		conn.Use(func(next ibclient.RequestHandler) ibclient.RequestHandler {
+			return func(req *http.Request) ([]byte, error) {
+				headers = append(headers, req.Header.Clone())
+				return next(req)
+			}
+		})
+	})
+
+	attrs := func(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+		res := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes {
+			res[kv.Key] = kv.Value
+		}
+		return res
+	}
+
+	It("should start a span per request in the caller's trace", func() {
+		ctx, parent := provider.Tracer("test").Start(context.Background(), "reconcile")
+		ref, err := conn.CreateObjectCtx(ctx, ibclient.NewNetworkView("private", "", nil, ""))
+		Expect(err).To(BeNil())
+		Expect(ref).NotTo(BeEmpty())
+		parent.End()
+
+		spans := exporter.GetSpans()
+		Expect(spans).To(HaveLen(2))
+		span := spans[0]
+		Expect(span.Name).To(Equal("WAPI POST networkview"))
+		Expect(span.SpanKind).To(Equal(trace.SpanKindClient))
+		Expect(span.Parent.SpanID()).To(Equal(parent.SpanContext().SpanID()))
+		Expect(attrs(span)).To(HaveKeyWithValue(attribute.Key("http.request.method"), attribute.StringValue("POST")))
+		Expect(attrs(span)).To(HaveKeyWithValue(attribute.Key("wapi.object_type"), attribute.StringValue("networkview")))
+		Expect(attrs(span)).To(HaveKeyWithValue(attribute.Key("wapi.version"), attribute.StringValue(server.Version)))
+
+		Expect(headers).To(HaveLen(1))
+		Expect(headers[0].Get("traceparent")).To(ContainSubstring(span.SpanContext.SpanID().String()))
+	})
+
+	It("should record the errors", func() {
+		ref := "networkview/ZG5zLm5ldHdvcmtfdmlldyQx:missing"
+		_, err := conn.DeleteObjectCtx(context.Background(), ref)
+		Expect(err).NotTo(BeNil())
+
+		spans := exporter.GetSpans()
+		Expect(spans).To(HaveLen(1))
+		Expect(attrs(spans[0])).To(HaveKeyWithValue(attribute.Key("wapi.ref"), attribute.StringValue(ref)))
+		Expect(spans[0].Status.Code).To(Equal(codes.Error))
+		Expect(spans[0].Events).To(HaveLen(1))
+		Expect(spans[0].Events[0].Name).To(Equal("exception"))
+	})
+
+	It("should record the retries through the Grid Master", func() {
+		ref := server.Add("networkview", map[string]interface{}{"name": "private"})
+		failed := false
+		conn.Use(func(next ibclient.RequestHandler) ibclient.RequestHandler {
+			return func(req *http.Request) ([]byte, error) {
+				if !failed {
+					failed = true
+					return nil, &ibclient.WapiError{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}
+				}
+				return next(req)
+			}
+		})
+		var res ibclient.NetworkView
+		err := conn.GetObjectCtx(context.Background(), ibclient.NewEmptyNetworkView(), ref,
+			ibclient.NewQueryParams(false, nil), &res)
+		Expect(err).To(BeNil())
+
+		spans := exporter.GetSpans()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Status.Code).NotTo(Equal(codes.Error))
+		Expect(spans[0].Events).To(HaveLen(1))
+		Expect(spans[0].Events[0].Name).To(Equal(ibclient.ProxyRetryEvent))
+		Expect(headers).To(HaveLen(2))
+		Expect(headers[1].Get("traceparent")).To(Equal(headers[0].Get("traceparent")))
+	})
+
+	It("should record the retry through the Grid Master of an empty search", func() {
+		var res []ibclient.NetworkView
+		err := conn.GetObjectCtx(context.Background(), ibclient.NewEmptyNetworkView(), "",
+			ibclient.NewQueryParams(false, map[string]string{"name": "missing"}), &res)
+		Expect(err).To(MatchError(ibclient.NewNotFoundError("not found")))
+
+		spans := exporter.GetSpans()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Status.Code).NotTo(Equal(codes.Error))
+		Expect(spans[0].Events).To(HaveLen(1))
+		Expect(spans[0].Events[0].Name).To(Equal(ibclient.ProxyRetryEvent))
+		Expect(spans[0].Events[0].Attributes).To(ContainElement(attribute.String("reason", "empty result")))
+		Expect(headers).To(HaveLen(2))
+		Expect(headers[1].Get("traceparent")).To(Equal(headers[0].Get("traceparent")))
+	})
+})
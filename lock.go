@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
-
-	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -148,23 +146,23 @@ func (l *NetworkViewLock) createUnlockRequest(force bool) *MultiRequest {
 }
 
 func (l *NetworkViewLock) getLock() bool {
-	logrus.Debugf("Creating lock on network niew %s\n", l.Name)
+	l.ObjMgr.log().Debug("creating the lock on the network view", "network_view", l.Name)
 	req := l.createLockRequest()
 	res, err := l.ObjMgr.CreateMultiObject(req)
 
 	if err != nil {
-		logrus.Debugf("Failed to create lock on network view %s: %s\n", l.Name, err)
+		l.ObjMgr.log().Debug("failed to create the lock on the network view", "network_view", l.Name, "error", err)
 
 		//Check for Lock Timeout
 		nw, err := l.ObjMgr.GetNetworkView(l.Name)
 		if err != nil {
-			logrus.Debugf("Failed to get the network view object for %s : %s\n", l.Name, err)
+			l.ObjMgr.log().Debug("failed to get the network view", "network_view", l.Name, "error", err)
 			return false
 		}
 
 		if t, ok := nw.Ea[l.LockTimeoutEA]; ok {
 			if int32(time.Now().Unix())-int32(t.(int)) > timeout {
-				logrus.Debugln("Lock is timed out. Forcefully acquiring it.")
+				l.ObjMgr.log().Debug("the lock timed out, acquiring it forcefully", "network_view", l.Name)
 				//remove the lock forcefully and acquire it
 				l.UnLock(true)
 				// try to get lock again
@@ -176,7 +174,7 @@ func (l *NetworkViewLock) getLock() bool {
 
 	dockerID := res[0]["DOCKER-ID"]
 	if dockerID == l.ObjMgr.tenantID {
-		logrus.Debugln("Got the lock !!!")
+		l.ObjMgr.log().Debug("got the lock", "network_view", l.Name)
 		return true
 	}
 
@@ -189,7 +187,7 @@ func (l *NetworkViewLock) Lock() error {
 	nw, err := l.ObjMgr.GetNetworkView(l.Name)
 	if err != nil {
 		msg := fmt.Sprintf("Failed to get the network view object for %s : %s\n", l.Name, err)
-		logrus.Debugf(msg)
+		l.ObjMgr.log().Debug(msg)
 		return fmt.Errorf(msg)
 	}
 
@@ -207,7 +205,7 @@ func (l *NetworkViewLock) Lock() error {
 		lock := l.getLock()
 		if lock == true {
 			// Got the lock.
-			logrus.Debugf("Got the lock on Network View %s\n", l.Name)
+			l.ObjMgr.log().Debug("got the lock on the network view", "network_view", l.Name)
 			return nil
 		}
 
@@ -217,7 +215,7 @@ func (l *NetworkViewLock) Lock() error {
 		}
 
 		retryCount++
-		logrus.Debugf("Lock on Network View %s not free. Retrying again %d out of 10.\n", l.Name, retryCount)
+		l.ObjMgr.log().Debug("the lock on the network view is not free, retrying", "network_view", l.Name, "retry", retryCount)
 		// sleep for random time (between 1 - 10 seconds) to reduce collisions
 		time.Sleep(time.Duration(rand.Intn(9)+1) * time.Second)
 		continue
@@ -232,17 +230,17 @@ func (l *NetworkViewLock) UnLock(force bool) error {
 
 	if err != nil {
 		msg := fmt.Sprintf("Failed to release lock from Network View %s: %s\n", l.Name, err)
-		logrus.Errorf(msg)
+		l.ObjMgr.log().Error(msg)
 		return fmt.Errorf(msg)
 	}
 
 	dockerID := res[0]["DOCKER-ID"]
 	if dockerID == freeLockVal {
-		logrus.Debugln("Removed the lock!")
+		l.ObjMgr.log().Debug("removed the lock", "network_view", l.Name)
 		return nil
 	}
 
 	msg := fmt.Sprintf("Failed to release lock from Network View %s\n", l.Name)
-	logrus.Errorf(msg)
+	l.ObjMgr.log().Error(msg)
 	return fmt.Errorf(msg)
 }
@@ -0,0 +1,159 @@
+package ibclient
+
+import (
+	"context"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// loggerSetter is implemented by the request builders and requestors
+// which log, see Connector.SetLogger
+type loggerSetter interface {
+	SetLogger(logger *slog.Logger)
+}
+
+// requestStats are recorded by WapiHttpRequestor for the connector's logs
+type requestStats struct {
+	attempts int
+	status   int
+}
+
+type requestStatsKey struct{}
+
+// withRequestStats returns req with stats the requestor records
+func withRequestStats(req *http.Request) (*http.Request, *requestStats) {
+	stats := &requestStats{}
+	return req.WithContext(context.WithValue(req.Context(), requestStatsKey{}, stats)), stats
+}
+
+// recordAttempt records an attempt to send req, when it has stats
+func recordAttempt(req *http.Request, resp *http.Response) {
+	stats, ok := req.Context().Value(requestStatsKey{}).(*requestStats)
+	if !ok {
+		return
+	}
+	stats.attempts++
+	stats.status = 0
+	if resp != nil {
+		stats.status = resp.StatusCode
+	}
+}
+
+// SetLogger sets the logger of the connector, and of its request builder
+// and requestor when they have a SetLogger method, e.g. WapiHttpRequestor.
+// slog.Default() is used when it is nil; a logr.Logger can be used through
+// logr.ToSlogHandler.
+//
+// Every request is logged at the debug level with its method, object type,
+// reference, status, duration and number of retries, and with its request
+// and response bodies, in which DefaultScrubbedFields are redacted. Errors
+// are logged at the error level.
+func (c *Connector) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+	if s, ok := c.requestBuilder.(loggerSetter); ok {
+		s.SetLogger(logger)
+	}
+	if s, ok := c.requestor.(loggerSetter); ok {
+		s.SetLogger(logger)
+	}
+}
+
+func (c *Connector) log() *slog.Logger {
+	return loggerOrDefault(c.logger)
+}
+
+// SetLogger sets the logger of the object manager, the one of its
+// connector is used when it is nil, see Connector.SetLogger.
+func (objMgr *ObjectManager) SetLogger(logger *slog.Logger) {
+	objMgr.logger = logger
+}
+
+func (objMgr *ObjectManager) log() *slog.Logger {
+	if objMgr.logger != nil {
+		return objMgr.logger
+	}
+	if base, _ := unwrapConnector(objMgr.connector); base != nil {
+		if c, ok := base.(*Connector); ok {
+			return c.log()
+		}
+	}
+	return slog.Default()
+}
+
+func (wrb *WapiRequestBuilder) SetLogger(logger *slog.Logger) {
+	wrb.logger = logger
+}
+
+func (whr *WapiHttpRequestor) SetLogger(logger *slog.Logger) {
+	whr.logger = logger
+}
+
+func (whr *WapiHttpRequestor) log() *slog.Logger {
+	return loggerOrDefault(whr.logger)
+}
+
+func loggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
+
+// logRequest logs a request sent by the connector at the debug level
+func (c *Connector) logRequest(ctx context.Context, req *http.Request, stats *requestStats,
+	duration time.Duration, res []byte, err error) {
+
+	c.log().LogAttrs(ctx, slog.LevelDebug, "WAPI request", requestLogAttrs(req, stats, duration, res, err)...)
+}
+
+// requestLogAttrs returns the attributes a request is logged with: its
+// method, object type, reference, status, duration, number of retries,
+// error, and its scrubbed request and response bodies
+func requestLogAttrs(req *http.Request, stats *requestStats, duration time.Duration, res []byte, err error) []slog.Attr {
+	objType, ref := requestObject(req)
+	status, res := requestStatus(stats, res, err)
+	retries := 0
+	if stats.attempts > 1 {
+		retries = stats.attempts - 1
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("object_type", objType),
+	}
+	if ref != "" {
+		attrs = append(attrs, slog.String("ref", ref))
+	}
+	attrs = append(attrs,
+		slog.Int("status", status),
+		slog.Duration("duration", duration),
+		slog.Int("retries", retries))
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	if req.GetBody != nil {
+		if body, bodyErr := req.GetBody(); bodyErr == nil {
+			data, _ := ioutil.ReadAll(body)
+			body.Close()
+			if scrubbed := scrubJSON(data, DefaultScrubbedFields); scrubbed != nil {
+				attrs = append(attrs, slog.String("request_body", string(scrubbed)))
+			}
+		}
+	}
+	if scrubbed := scrubJSON(res, DefaultScrubbedFields); scrubbed != nil {
+		attrs = append(attrs, slog.String("response_body", string(scrubbed)))
+	}
+	return attrs
+}
+
+// requestObject returns the object type of a request's object and
+// its reference, when the request is for an object rather than a type
+func requestObject(req *http.Request) (string, string) {
+	object := wapiObjectFromURL(req.URL)
+	if i := strings.Index(object, "/"); i >= 0 {
+		return object[:i], object
+	}
+	return object, ""
+}
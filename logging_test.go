@@ -0,0 +1,105 @@
+package ibclient_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logging", func() {
+	var (
+		server *fakewapi.Server
+		conn   *ibclient.Connector
+		buf    *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		server, conn = newFakeConnector()
+		buf = &bytes.Buffer{}
+	})
+
+	records := func() []map[string]interface{} {
+		var res []map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+		for dec.More() {
+			record := map[string]interface{}{}
+			Expect(dec.Decode(&record)).To(Succeed())
+			res = append(res, record)
+		}
+		return res
+	}
+
+	It("should log the requests at the debug level", func() {
+		conn.SetLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+		user, err := ibclient.Create(conn, &ibclient.Adminuser{
+			Name:     utils.StringPtr("operator"),
+			Password: utils.StringPtr("s3cr3t"),
+		})
+		Expect(err).To(BeNil())
+		_, err = ibclient.Get[ibclient.Adminuser](conn, user.Ref)
+		Expect(err).To(BeNil())
+
+		// Create reads the created object back
+		logged := records()
+		Expect(logged).To(HaveLen(3))
+		Expect(logged[0]).To(HaveKeyWithValue("level", "DEBUG"))
+		Expect(logged[0]).To(HaveKeyWithValue("method", "POST"))
+		Expect(logged[0]).To(HaveKeyWithValue("object_type", "adminuser"))
+		Expect(logged[0]).To(HaveKeyWithValue("status", BeNumerically("==", 201)))
+		Expect(logged[0]).To(HaveKeyWithValue("retries", BeNumerically("==", 0)))
+		Expect(logged[0]).To(HaveKey("duration"))
+		Expect(logged[0]["request_body"]).To(ContainSubstring(ibclient.ScrubbedValue))
+		Expect(buf.String()).NotTo(ContainSubstring("s3cr3t"))
+
+		Expect(logged[2]).To(HaveKeyWithValue("method", "GET"))
+		Expect(logged[2]).To(HaveKeyWithValue("ref", user.Ref))
+		Expect(logged[2]).To(HaveKeyWithValue("status", BeNumerically("==", 200)))
+	})
+
+	It("should log the errors", func() {
+		conn.SetLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+		_, err := ibclient.Get[ibclient.View](conn, "view/ZG5zLnZpZXckLl9kZWZhdWx0:missing")
+		Expect(err).NotTo(BeNil())
+		logged := records()
+		Expect(logged).NotTo(BeEmpty())
+		Expect(logged[len(logged)-1]).To(HaveKeyWithValue("status", BeNumerically("==", 404)))
+		Expect(logged[len(logged)-1]).To(HaveKey("error"))
+	})
+
+	It("should not log the successful requests at the info level", func() {
+		conn.SetLogger(slog.New(slog.NewJSONHandler(buf, nil)))
+
+		_, err := ibclient.List[ibclient.View](conn, nil)
+		Expect(err).To(BeNil())
+		Expect(buf.Len()).To(BeZero())
+	})
+
+	It("should log the object manager's messages with the connector's logger", func() {
+		conn.SetLogger(slog.New(slog.NewJSONHandler(buf, nil)))
+		objMgr := ibclient.NewObjectManager(conn, "cmp", "tenant").(*ibclient.ObjectManager)
+		ref := server.Add("nsgroup", map[string]interface{}{"name": "ns-group", "comment": "first"})
+		original, err := ibclient.Get[ibclient.Nsgroup](conn, ref)
+		Expect(err).To(BeNil())
+		_, err = ibclient.PatchObject(conn, ref, &ibclient.Nsgroup{Comment: utils.StringPtr("changed")})
+		Expect(err).To(BeNil())
+
+		merge := func(current ibclient.IBObject) (ibclient.IBObject, error) {
+			return &ibclient.Nsgroup{Comment: utils.StringPtr("merged")}, nil
+		}
+		_, err = objMgr.UpdateIfUnchanged(ref, original, &ibclient.Nsgroup{Comment: utils.StringPtr("second")}, merge)
+		Expect(err).To(BeNil())
+		logged := records()
+		Expect(logged).To(HaveLen(1))
+		Expect(logged[0]).To(HaveKeyWithValue("level", "INFO"))
+		Expect(logged[0]).To(HaveKeyWithValue("ref", ref))
+	})
+})
@@ -0,0 +1,89 @@
+package ibclient
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestMetrics describe a WAPI request sent by a Connector,
+// see MetricsRecorder.
+type RequestMetrics struct {
+	Method     string
+	ObjectType string
+
+	// Status is the HTTP status of the last attempt, it is zero when no
+	// response was received, e.g. when the grid couldn't be reached.
+	Status int
+
+	// Duration is the time the request took, including its retries
+	Duration time.Duration
+
+	RequestBytes  int
+	ResponseBytes int
+
+	Err error
+}
+
+// MetricsRecorder records the metrics of a connector's requests,
+// see Connector.SetMetrics and PrometheusMetrics.
+// Its methods are called concurrently by the requests of the connector.
+type MetricsRecorder interface {
+	// ObserveRequest is called once each request is done
+	ObserveRequest(m RequestMetrics)
+
+	// ObserveProxyFallback is called when a request is sent again
+	// through the Grid Master because it failed or found nothing
+	// on the member it was sent to
+	ObserveProxyFallback(method string, objectType string)
+}
+
+// SetMetrics sets the recorder of the connector's request metrics,
+// no metrics are recorded when it is nil.
+func (c *Connector) SetMetrics(metrics MetricsRecorder) {
+	c.metrics = metrics
+}
+
+// observeRequest records the metrics of a request sent by the connector
+func (c *Connector) observeRequest(req *http.Request, stats *requestStats, duration time.Duration, res []byte, err error) {
+	objType, _ := requestObject(req)
+	status, res := requestStatus(stats, res, err)
+	reqBytes := 0
+	if req.ContentLength > 0 {
+		reqBytes = int(req.ContentLength)
+	}
+	c.metrics.ObserveRequest(RequestMetrics{
+		Method:        req.Method,
+		ObjectType:    objType,
+		Status:        status,
+		Duration:      duration,
+		RequestBytes:  reqBytes,
+		ResponseBytes: len(res),
+		Err:           err,
+	})
+}
+
+// observeProxyFallback records a request sent again through the Grid Master
+func (c *Connector) observeProxyFallback(t RequestType, obj IBObject, ref string) {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.ObserveProxyFallback(t.toMethod(), requestObjectType(obj, ref))
+}
+
+// requestObjectType returns the type of the object a request is for
+func requestObjectType(obj IBObject, ref string) string {
+	if obj != nil {
+		return obj.ObjectType()
+	}
+	return strings.SplitN(ref, "/", 2)[0]
+}
+
+// requestStatus returns the status and the response body of a request,
+// which is the body of the WAPI error when it failed
+func requestStatus(stats *requestStats, res []byte, err error) (int, []byte) {
+	if wapiErr, ok := asWapiError(err); ok {
+		return wapiErr.StatusCode, wapiErr.Body
+	}
+	return stats.status, res
+}
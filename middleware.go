@@ -0,0 +1,146 @@
+package ibclient
+
+import (
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RequestHandler sends a WAPI request and returns the response body,
+// HttpRequestor.SendRequest is the innermost RequestHandler of a Connector.
+type RequestHandler func(req *http.Request) ([]byte, error)
+
+// Middleware wraps a RequestHandler, to act on requests before they are
+// sent and on their results, see Connector.Use.
+type Middleware func(next RequestHandler) RequestHandler
+
+// middlewareChain holds the middlewares of a Connector. It is kept
+// behind a pointer so that copies of a Connector share it.
+type middlewareChain struct {
+	mu          sync.RWMutex
+	middlewares []Middleware
+}
+
+// Use appends middlewares to the connector's chain. They are applied in
+// the given order: the first middleware sees each request first and its
+// result last. Use is expected to be called before the connector is shared.
+func (c *Connector) Use(middlewares ...Middleware) {
+	if c.middlewares == nil {
+		c.middlewares = &middlewareChain{}
+	}
+	c.middlewares.mu.Lock()
+	defer c.middlewares.mu.Unlock()
+	c.middlewares.middlewares = append(c.middlewares.middlewares, middlewares...)
+}
+
+// handler returns the connector's requestor wrapped in its middlewares
+func (c *Connector) handler() RequestHandler {
+	handler := RequestHandler(c.requestor.SendRequest)
+	if c.middlewares == nil {
+		return handler
+	}
+
+	c.middlewares.mu.RLock()
+	defer c.middlewares.mu.RUnlock()
+	for i := len(c.middlewares.middlewares) - 1; i >= 0; i-- {
+		handler = c.middlewares.middlewares[i](handler)
+	}
+	return handler
+}
+
+// HeaderMiddleware adds the given headers to every request.
+func HeaderMiddleware(header http.Header) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return func(req *http.Request) ([]byte, error) {
+			for h, values := range header {
+				for _, v := range values {
+					req.Header.Add(h, v)
+				}
+			}
+			return next(req)
+		}
+	}
+}
+
+// LoggingMiddleware logs every request with the fields of the connector's
+// debug logs, see Connector.SetLogger: at the info level, and at the error
+// level when it fails. slog.Default() is used when logger is nil.
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	logger = loggerOrDefault(logger)
+	return func(next RequestHandler) RequestHandler {
+		return func(req *http.Request) ([]byte, error) {
+			stats, ok := req.Context().Value(requestStatsKey{}).(*requestStats)
+			if !ok {
+				req, stats = withRequestStats(req)
+			}
+			start := time.Now()
+			res, err := next(req)
+			level := slog.LevelInfo
+			if err != nil {
+				level = slog.LevelError
+			}
+			logger.LogAttrs(req.Context(), level, "WAPI request",
+				requestLogAttrs(req, stats, time.Since(start), res, err)...)
+			return res, err
+		}
+	}
+}
+
+// AuditRecord describes a request which modified the grid, or tried to.
+type AuditRecord struct {
+	Time     time.Time
+	Method   string
+	URL      string
+	Body     []byte
+	Response []byte
+	Duration time.Duration
+	Err      error
+}
+
+// AuditMiddleware calls record for every request
+// which creates, updates or deletes objects.
+func AuditMiddleware(record func(AuditRecord)) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return func(req *http.Request) ([]byte, error) {
+			if req.Method == GET.toMethod() {
+				return next(req)
+			}
+
+			rec := AuditRecord{
+				Time:   time.Now(),
+				Method: req.Method,
+				URL:    req.URL.String(),
+			}
+			if req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					rec.Body, _ = ioutil.ReadAll(body)
+					body.Close()
+				}
+			}
+
+			res, err := next(req)
+			rec.Response = res
+			rec.Duration = time.Since(rec.Time)
+			rec.Err = err
+			record(rec)
+
+			return res, err
+		}
+	}
+}
+
+// FaultInjectionMiddleware fails the requests for which inject returns
+// an error, without sending them. It is meant for testing how callers
+// cope with failing WAPI requests.
+func FaultInjectionMiddleware(inject func(req *http.Request) error) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return func(req *http.Request) ([]byte, error) {
+			if err := inject(req); err != nil {
+				return nil, err
+			}
+			return next(req)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package ibclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// recordingHttpRequestor records the requests it is given.
+type recordingHttpRequestor struct {
+	reqs []*http.Request
+	res  []byte
+	err  error
+}
+
+func (hr *recordingHttpRequestor) Init(authCfg AuthConfig, trCfg TransportConfig) {}
+
+func (hr *recordingHttpRequestor) SendRequest(req *http.Request) ([]byte, error) {
+	hr.reqs = append(hr.reqs, req)
+	return hr.res, hr.err
+}
+
+var _ = Describe("Middleware", func() {
+	hostCfg := HostConfig{Host: "172.22.18.66", Version: "2.12.1", Port: "443"}
+	authCfg := AuthConfig{Username: "myname", Password: "mysecrete!"}
+	netviewRef := "networkview/ZG5zLm5ldHdvcmtfdmlldyQyMw:global_view/false"
+
+	var (
+		hr   *recordingHttpRequestor
+		conn *Connector
+	)
+
+	BeforeEach(func() {
+		hr = &recordingHttpRequestor{res: []byte(`"` + netviewRef + `"`)}
+		wrb, _ := NewWapiRequestBuilder(hostCfg, authCfg)
+
+		OrigValidateConnector := ValidateConnector
+		ValidateConnector = MockValidateConnector
+		defer func() { ValidateConnector = OrigValidateConnector }()
+
+		var err error
+		conn, err = NewConnector(hostCfg, authCfg, NewTransportConfig("false", 10, 1), wrb, hr)
+		Expect(err).To(BeNil())
+	})
+
+	It("should apply middlewares in the order they were added", func() {
+		var calls []string
+		tag := func(name string) Middleware {
+			return func(next RequestHandler) RequestHandler {
+				return func(req *http.Request) ([]byte, error) {
+					calls = append(calls, name+" before")
+					res, err := next(req)
+					calls = append(calls, name+" after")
+					return res, err
+				}
+			}
+		}
+		conn.Use(tag("first"), tag("second"))
+		conn.Use(tag("third"))
+
+		_, err := conn.DeleteObject(netviewRef)
+		Expect(err).To(BeNil())
+		Expect(calls).To(Equal([]string{
+			"first before", "second before", "third before",
+			"third after", "second after", "first after",
+		}))
+	})
+
+	It("should let middlewares alter the result", func() {
+		conn.Use(func(next RequestHandler) RequestHandler {
+			return func(req *http.Request) ([]byte, error) {
+				return []byte(`"networkview/changed:default/true"`), nil
+			}
+		})
+		ref, err := conn.DeleteObject(netviewRef)
+		Expect(err).To(BeNil())
+		Expect(ref).To(Equal("networkview/changed:default/true"))
+		Expect(hr.reqs).To(BeEmpty())
+	})
+
+	It("should add headers to every request", func() {
+		conn.Use(HeaderMiddleware(http.Header{"X-Request-Id": []string{"42"}}))
+		_, err := conn.DeleteObject(netviewRef)
+		Expect(err).To(BeNil())
+		Expect(hr.reqs).To(HaveLen(1))
+		Expect(hr.reqs[0].Header.Get("X-Request-Id")).To(Equal("42"))
+	})
+
+	It("should log every request", func() {
+		var buf bytes.Buffer
+		conn.Use(LoggingMiddleware(slog.New(slog.NewJSONHandler(&buf, nil))))
+		_, err := conn.DeleteObject(netviewRef)
+		Expect(err).To(BeNil())
+
+		record := map[string]interface{}{}
+		Expect(json.Unmarshal(buf.Bytes(), &record)).To(Succeed())
+		Expect(record).To(HaveKeyWithValue("level", "INFO"))
+		Expect(record).To(HaveKeyWithValue("msg", "WAPI request"))
+		Expect(record).To(HaveKeyWithValue("method", "DELETE"))
+		Expect(record).To(HaveKeyWithValue("object_type", "networkview"))
+		Expect(record).To(HaveKeyWithValue("ref", netviewRef))
+		Expect(record).To(HaveKeyWithValue("retries", BeNumerically("==", 0)))
+		Expect(record).To(HaveKey("duration"))
+
+		buf.Reset()
+		hr.err = errors.New("connection refused")
+		_, err = conn.DeleteObject(netviewRef)
+		Expect(err).NotTo(BeNil())
+		Expect(json.Unmarshal(buf.Bytes(), &record)).To(Succeed())
+		Expect(record).To(HaveKeyWithValue("level", "ERROR"))
+		Expect(record).To(HaveKeyWithValue("error", "connection refused"))
+	})
+
+	It("should audit modifying requests only", func() {
+		var records []AuditRecord
+		conn.Use(AuditMiddleware(func(rec AuditRecord) {
+			records = append(records, rec)
+		}))
+
+		_, err := conn.CreateObject(NewNetworkView("private-view", "", nil, ""))
+		Expect(err).To(BeNil())
+		var res []NetworkView
+		hr.res = []byte(`[{"_ref":"` + netviewRef + `","name":"private-view"}]`)
+		err = conn.GetObject(NewEmptyNetworkView(), "", NewQueryParams(false, nil), &res)
+		Expect(err).To(BeNil())
+
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].Method).To(Equal("POST"))
+		Expect(records[0].URL).To(Equal("https://172.22.18.66:443/wapi/v2.12.1/networkview"))
+		Expect(string(records[0].Body)).To(ContainSubstring(`"name":"private-view"`))
+		Expect(string(records[0].Response)).To(Equal(`"` + netviewRef + `"`))
+		Expect(records[0].Err).To(BeNil())
+		// the body must still be readable by the requestor
+		body := new(bytes.Buffer)
+		_, _ = body.ReadFrom(hr.reqs[0].Body)
+		Expect(body.String()).To(ContainSubstring(`"name":"private-view"`))
+	})
+
+	It("should inject faults without sending the request", func() {
+		injected := errors.New("injected")
+		conn.Use(FaultInjectionMiddleware(func(req *http.Request) error {
+			if req.Method == "DELETE" {
+				return injected
+			}
+			return nil
+		}))
+		_, err := conn.DeleteObject(netviewRef)
+		Expect(err).To(Equal(injected))
+		Expect(hr.reqs).To(BeEmpty())
+
+		_, err = conn.CreateObject(NewNetworkView("private-view", "", nil, ""))
+		Expect(err).To(BeNil())
+		Expect(hr.reqs).To(HaveLen(1))
+	})
+})
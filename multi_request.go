@@ -0,0 +1,376 @@
+package ibclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MultiRequestBuilder builds the steps of a call to the WAPI "request"
+// object, which runs them in order, in a single transaction:
+//
+//	b := NewMultiRequestBuilder()
+//	b.Get(&NetworkView{}, NewQueryParams(false, map[string]string{"name": "default"})).
+//		AssignState("NV_REF", "_ref").Discard()
+//	b.Update(&NetworkView{Comment: utils.StringPtr("updated")}, StateVar("NV_REF")).Discard()
+//	rec := b.Create(&RecordA{Name: utils.StringPtr("web.example.com"),
+//		Ipv4Addr: utils.StringPtr("10.0.0.1"), View: "default"})
+//	res, err := objMgr.ExecuteMultiRequest(b)
+//	...
+//	var created RecordA
+//	err = res.Decode(rec, &created)
+//
+// Step results which are not discarded are decoded from the
+// MultiRequestResult. The steps are checked by Build, which reports
+// the state variables used before being assigned among other errors.
+type MultiRequestBuilder struct {
+	steps []*MultiRequestStep
+}
+
+// MultiRequestStep is a step of a multi-request. Its methods
+// return it, so they can be chained.
+type MultiRequestStep struct {
+	pos     int
+	body    *RequestBody
+	objType string
+	ref     string
+	err     error
+	result  int
+}
+
+// MultiRequestResult holds the results of the steps of an executed
+// multi-request which were not discarded.
+type MultiRequestResult struct {
+	results []json.RawMessage
+}
+
+var stateVarRegexp = regexp.MustCompile(`##STATE:([^:#]+):##`)
+
+// StateVar returns the placeholder of a state variable, to use as
+// a reference or a field value in the following steps.
+func StateVar(name string) string {
+	return "##STATE:" + name + ":##"
+}
+
+func NewMultiRequestBuilder() *MultiRequestBuilder {
+	return &MultiRequestBuilder{}
+}
+
+func (b *MultiRequestBuilder) add(body *RequestBody, objType string, ref string, err error) *MultiRequestStep {
+	step := &MultiRequestStep{pos: len(b.steps), body: body, objType: objType, ref: ref, err: err}
+	b.steps = append(b.steps, step)
+	return step
+}
+
+// Create adds a step creating obj. The step's result is the created object
+// with the object's return fields, or its reference when it has none.
+func (b *MultiRequestBuilder) Create(obj IBObject) *MultiRequestStep {
+	if err := checkMultiRequestObject(obj); err != nil {
+		return b.add(&RequestBody{Method: "POST"}, "", "", err)
+	}
+	data, err := multiRequestData(obj)
+	return b.add(&RequestBody{
+		Method: "POST",
+		Object: obj.ObjectType(),
+		Data:   data,
+		Args:   returnFieldsArgs(obj.ReturnFields(), nil),
+	}, obj.ObjectType(), "", err)
+}
+
+// Get adds a step searching the objects of the type of obj with the search
+// fields of queryParams, which may be nil. Each field can only be searched
+// with one value. The step's result is the list of the objects found.
+func (b *MultiRequestBuilder) Get(obj IBObject, queryParams *QueryParams) *MultiRequestStep {
+	if err := checkMultiRequestObject(obj); err != nil {
+		return b.add(&RequestBody{Method: "GET"}, "", "", err)
+	}
+	if queryParams == nil {
+		queryParams = NewQueryParams(false, nil)
+	}
+
+	data := make(map[string]interface{})
+	for k, v := range queryParams.searchFields {
+		data[k] = v
+	}
+	keys := make([]string, 0, len(queryParams.search))
+	for k := range queryParams.search {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch v := queryParams.search[k]; len(v) {
+		case 0:
+			// a field without a value is not searched
+		case 1:
+			data[k] = v[0]
+		default:
+			err := fmt.Errorf("'%s' is searched with several values, which a multi-request does not support", k)
+			return b.add(&RequestBody{Method: "GET"}, "", "", err)
+		}
+	}
+	for k, v := range obj.EaSearch() {
+		data["*"+k] = v
+	}
+	if len(data) == 0 {
+		data = nil
+	}
+	return b.add(&RequestBody{
+		Method: "GET",
+		Object: obj.ObjectType(),
+		Data:   data,
+		Args:   returnFieldsArgs(obj.ReturnFields(), queryParams.returnFieldsPlus),
+	}, obj.ObjectType(), "", nil)
+}
+
+// GetRef adds a step reading the object of the type of obj with the given
+// reference, which may be a state variable. The step's result is the object.
+func (b *MultiRequestBuilder) GetRef(obj IBObject, ref string) *MultiRequestStep {
+	if err := checkMultiRequestObject(obj); err != nil {
+		return b.add(&RequestBody{Method: "GET"}, "", "", err)
+	}
+	return b.add(&RequestBody{
+		Method: "GET",
+		Object: ref,
+		Args:   returnFieldsArgs(obj.ReturnFields(), nil),
+	}, obj.ObjectType(), ref, nil)
+}
+
+// Update adds a step updating the object with the given reference, which
+// may be a state variable, with the fields set in obj. The step's result is
+// the updated object with the object's return fields, or its reference.
+func (b *MultiRequestBuilder) Update(obj IBObject, ref string) *MultiRequestStep {
+	if err := checkMultiRequestObject(obj); err != nil {
+		return b.add(&RequestBody{Method: "PUT"}, "", ref, err)
+	}
+	data, err := multiRequestData(obj)
+	return b.add(&RequestBody{
+		Method: "PUT",
+		Object: ref,
+		Data:   data,
+		Args:   returnFieldsArgs(obj.ReturnFields(), nil),
+	}, obj.ObjectType(), ref, err)
+}
+
+// Delete adds a step deleting the object with the given reference, which
+// may be a state variable. The step's result is the reference.
+func (b *MultiRequestBuilder) Delete(ref string) *MultiRequestStep {
+	return b.add(&RequestBody{Method: "DELETE", Object: ref}, "", ref, nil)
+}
+
+// DisplayState adds a step whose result is the map of the state variables.
+func (b *MultiRequestBuilder) DisplayState() *MultiRequestStep {
+	return b.add(&RequestBody{Method: "STATE:DISPLAY"}, "", "", nil)
+}
+
+// AssignState assigns a field of the step's result, e.g. "_ref", to the
+// state variable name. The result of a search is its first object.
+func (s *MultiRequestStep) AssignState(name string, field string) *MultiRequestStep {
+	if s.err == nil && (name == "" || strings.ContainsAny(name, ":#")) {
+		s.err = fmt.Errorf("invalid state variable name '%s'", name)
+	}
+	if s.body.AssignState == nil {
+		s.body.AssignState = make(map[string]string)
+	}
+	s.body.AssignState[name] = field
+	return s
+}
+
+// AssignStateEA assigns the value of the extensible attribute ea
+// of the step's result to the state variable name.
+func (s *MultiRequestStep) AssignStateEA(name string, ea string) *MultiRequestStep {
+	return s.AssignState(name, "*"+ea)
+}
+
+// Discard drops the step's result from the results of the multi-request.
+func (s *MultiRequestStep) Discard() *MultiRequestStep {
+	s.body.Discard = true
+	return s
+}
+
+// Build checks the steps and returns the multi-request to send. The state
+// variables used by a step must be assigned by a previous one.
+func (b *MultiRequestBuilder) Build() (*MultiRequest, error) {
+	if len(b.steps) == 0 {
+		return nil, fmt.Errorf("the multi-request has no steps")
+	}
+
+	assigned := make(map[string]bool)
+	bodies := make([]*RequestBody, len(b.steps))
+	result := 0
+	for i, s := range b.steps {
+		if err := s.check(assigned); err != nil {
+			return nil, fmt.Errorf("step %d of the multi-request: %s", i, err)
+		}
+		for name := range s.body.AssignState {
+			assigned[name] = true
+		}
+		s.result = -1
+		if !s.body.Discard {
+			s.result = result
+			result++
+		}
+		bodies[i] = s.body
+	}
+	return NewMultiRequest(bodies), nil
+}
+
+// check validates the step and enables the substitution of the state
+// variables it uses.
+func (s *MultiRequestStep) check(assigned map[string]bool) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	if s.ref != "" || s.body.Method == "PUT" || s.body.Method == "DELETE" {
+		if !stateVarRegexp.MatchString(s.ref) {
+			if err := checkRefType(s.ref, s.objType); err != nil {
+				return err
+			}
+		} else if stateVarRegexp.FindString(s.ref) != s.ref {
+			return fmt.Errorf("invalid reference '%s'", s.ref)
+		}
+	}
+
+	data, err := json.Marshal(s.body.Data)
+	if err != nil {
+		return err
+	}
+	used := stateVarRegexp.FindAllStringSubmatch(s.ref+string(data), -1)
+	for _, m := range used {
+		if !assigned[m[1]] {
+			return fmt.Errorf("the state variable '%s' is used before being assigned", m[1])
+		}
+	}
+	s.body.EnableSubstitution = len(used) > 0
+	return nil
+}
+
+// ExecuteMultiRequest builds and sends the multi-request of b, and returns
+// the results of its steps.
+func (objMgr *ObjectManager) ExecuteMultiRequest(b *MultiRequestBuilder) (*MultiRequestResult, error) {
+	req, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := objMgr.sendMultiRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &MultiRequestResult{}
+	if err = json.Unmarshal(resp, &res.results); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal the multi-request results '%s': %s", string(resp), err)
+	}
+	expected := 0
+	for _, s := range b.steps {
+		if s.result >= 0 {
+			expected++
+		}
+	}
+	if len(res.results) != expected {
+		return nil, fmt.Errorf("the multi-request returned %d results instead of %d", len(res.results), expected)
+	}
+	return res, nil
+}
+
+// Decode unmarshals the result of step into out. The result of a search
+// is decoded into a slice, or its first object into a struct.
+func (r *MultiRequestResult) Decode(step *MultiRequestStep, out interface{}) error {
+	raw, err := r.result(step)
+	if err != nil {
+		return err
+	}
+
+	outType := reflect.TypeOf(out)
+	isSlice := outType != nil && outType.Kind() == reflect.Ptr && outType.Elem().Kind() == reflect.Slice
+	switch {
+	case raw[0] == '[' && !isSlice:
+		var objects []json.RawMessage
+		if err = json.Unmarshal(raw, &objects); err != nil {
+			return err
+		}
+		if len(objects) == 0 {
+			return NewNotFoundError(fmt.Sprintf("step %d of the multi-request found no object", step.pos))
+		}
+		raw = objects[0]
+	case raw[0] == '"' && outType != reflect.TypeOf((*string)(nil)):
+		return fmt.Errorf("step %d of the multi-request returned the reference %s, not an object", step.pos, string(raw))
+	}
+	if err = json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("cannot unmarshal the result of step %d of the multi-request: %s", step.pos, err)
+	}
+	return nil
+}
+
+// Ref returns the reference of the object returned by step.
+func (r *MultiRequestResult) Ref(step *MultiRequestStep) (string, error) {
+	raw, err := r.result(step)
+	if err != nil {
+		return "", err
+	}
+	var ref string
+	if raw[0] == '"' {
+		err = json.Unmarshal(raw, &ref)
+	} else {
+		var obj struct {
+			Ref string `json:"_ref"`
+		}
+		err = r.Decode(step, &obj)
+		ref = obj.Ref
+	}
+	if err == nil && ref == "" {
+		err = fmt.Errorf("step %d of the multi-request returned no reference", step.pos)
+	}
+	return ref, err
+}
+
+func (r *MultiRequestResult) result(step *MultiRequestStep) (json.RawMessage, error) {
+	if step.result < 0 {
+		return nil, fmt.Errorf("the result of step %d of the multi-request is discarded", step.pos)
+	}
+	if step.result >= len(r.results) {
+		return nil, fmt.Errorf("step %d is not a step of the multi-request", step.pos)
+	}
+	raw := r.results[step.result]
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, fmt.Errorf("step %d of the multi-request returned no result", step.pos)
+	}
+	return raw, nil
+}
+
+func checkMultiRequestObject(obj IBObject) error {
+	if obj == nil || obj.ObjectType() == "" {
+		return fmt.Errorf("the object type of %T is not known", obj)
+	}
+	if obj.ObjectType() == "request" {
+		return fmt.Errorf("multi-requests can't be nested")
+	}
+	return nil
+}
+
+// multiRequestData returns the fields of obj sent by CreateObject and UpdateObject
+func multiRequestData(obj IBObject) (map[string]interface{}, error) {
+	body := (&WapiRequestBuilder{}).BuildBody(CREATE, obj)
+	if body == nil {
+		return nil, fmt.Errorf("cannot marshal the %s object", obj.ObjectType())
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+	delete(data, "_ref")
+	return data, nil
+}
+
+func returnFieldsArgs(returnFields []string, returnFieldsPlus []string) map[string]string {
+	if len(returnFieldsPlus) > 0 {
+		return map[string]string{"_return_fields+": strings.Join(mergeFields(returnFields, returnFieldsPlus), ",")}
+	}
+	if len(returnFields) > 0 {
+		return map[string]string{"_return_fields": strings.Join(returnFields, ",")}
+	}
+	return nil
+}
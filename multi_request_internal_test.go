@@ -0,0 +1,21 @@
+package ibclient
+
+import (
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Multi-request searches", func() {
+	It("should not search the fields without a value", func() {
+		qp := NewQueryParams(false, nil)
+		qp.search = url.Values{"name": []string{}, "comment": []string{"c"}}
+		b := NewMultiRequestBuilder()
+		b.Get(&Nsgroup{}, qp)
+		req, err := b.Build()
+		Expect(err).To(BeNil())
+		Expect(req.Body).To(HaveLen(1))
+		Expect(req.Body[0].Data).To(Equal(map[string]interface{}{"comment": "c"}))
+	})
+})
@@ -0,0 +1,165 @@
+package ibclient_test
+
+import (
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Multi-request builder", func() {
+	It("should check the steps before sending them", func() {
+		_, err := ibclient.NewMultiRequestBuilder().Build()
+		Expect(err).To(MatchError("the multi-request has no steps"))
+
+		b := ibclient.NewMultiRequestBuilder()
+		b.Update(&ibclient.Nsgroup{Comment: utils.StringPtr("c")}, ibclient.StateVar("NSG_REF"))
+		_, err = b.Build()
+		Expect(err).To(MatchError("step 0 of the multi-request: the state variable 'NSG_REF' is used before being assigned"))
+
+		b = ibclient.NewMultiRequestBuilder()
+		b.Get(&ibclient.Nsgroup{}, nil).AssignState("NSG_REF", "_ref").Discard()
+		b.Create(&ibclient.Adminrole{Name: utils.StringPtr("role"), Comment: utils.StringPtr(ibclient.StateVar("NSG"))})
+		_, err = b.Build()
+		Expect(err).To(MatchError("step 1 of the multi-request: the state variable 'NSG' is used before being assigned"))
+
+		b = ibclient.NewMultiRequestBuilder()
+		b.Update(&ibclient.Nsgroup{}, "adminrole/ZG5zLmFkbWluX3JvbGU:role")
+		_, err = b.Build()
+		Expect(err).To(MatchError("step 0 of the multi-request: reference 'adminrole/ZG5zLmFkbWluX3JvbGU:role' is not of type nsgroup"))
+
+		b = ibclient.NewMultiRequestBuilder()
+		b.Delete("")
+		_, err = b.Build()
+		Expect(err).To(MatchError("step 0 of the multi-request: invalid reference ''"))
+
+		b = ibclient.NewMultiRequestBuilder()
+		b.Create(ibclient.NewMultiRequest(nil))
+		_, err = b.Build()
+		Expect(err).To(MatchError("step 0 of the multi-request: multi-requests can't be nested"))
+
+		qp, err := ibclient.NewQuery(&ibclient.Nsgroup{}).Field("name").Equals("a", "b").Build()
+		Expect(err).To(BeNil())
+		b = ibclient.NewMultiRequestBuilder()
+		b.Get(&ibclient.Nsgroup{}, qp)
+		_, err = b.Build()
+		Expect(err).To(MatchError("step 0 of the multi-request: 'name' is searched with several values, which a multi-request does not support"))
+
+		qp, err = ibclient.NewQuery(&ibclient.Nsgroup{}).Field("name").Equals("a", "b").Field("comment").Equals("c", "d").Build()
+		Expect(err).To(BeNil())
+		b = ibclient.NewMultiRequestBuilder()
+		b.Get(&ibclient.Nsgroup{}, qp)
+		_, err = b.Build()
+		Expect(err).To(MatchError("step 0 of the multi-request: 'comment' is searched with several values, which a multi-request does not support"))
+	})
+
+	It("should build the request bodies", func() {
+		b := ibclient.NewMultiRequestBuilder()
+		b.Get(&ibclient.NetworkView{}, ibclient.NewQueryParams(false, map[string]string{"name": "default"})).
+			AssignState("NV_REF", "_ref").
+			AssignStateEA("SITE", "Site").
+			Discard()
+		b.Update(&ibclient.NetworkView{Comment: utils.StringPtr(ibclient.StateVar("SITE"))}, ibclient.StateVar("NV_REF"))
+		b.DisplayState()
+
+		req, err := b.Build()
+		Expect(err).To(BeNil())
+		Expect(req.Body).To(HaveLen(3))
+		Expect(*req.Body[0]).To(Equal(ibclient.RequestBody{
+			Method:      "GET",
+			Object:      "networkview",
+			Data:        map[string]interface{}{"name": "default"},
+			Args:        map[string]string{"_return_fields": "comment,is_default,name"},
+			AssignState: map[string]string{"NV_REF": "_ref", "SITE": "*Site"},
+			Discard:     true,
+		}))
+		Expect(req.Body[1].Method).To(Equal("PUT"))
+		Expect(req.Body[1].Object).To(Equal("##STATE:NV_REF:##"))
+		Expect(req.Body[1].Data).To(HaveKeyWithValue("comment", "##STATE:SITE:##"))
+		Expect(req.Body[1].EnableSubstitution).To(BeTrue())
+		Expect(*req.Body[2]).To(Equal(ibclient.RequestBody{Method: "STATE:DISPLAY"}))
+	})
+
+	Context("with a grid", func() {
+		var (
+			server *fakewapi.Server
+			objMgr *ibclient.ObjectManager
+		)
+
+		BeforeEach(func() {
+			var conn *ibclient.Connector
+			server, conn = newFakeConnector()
+			objMgr = ibclient.NewObjectManager(conn, "cmpType", "tenantID").(*ibclient.ObjectManager)
+		})
+
+		It("should run the steps and decode their results", func() {
+			b := ibclient.NewMultiRequestBuilder()
+			created := b.Create(&ibclient.Nsgroup{Name: utils.StringPtr("ns-group"), Comment: utils.StringPtr("first")}).
+				AssignState("NSG_REF", "_ref")
+			b.Update(&ibclient.Nsgroup{Comment: utils.StringPtr("second")}, ibclient.StateVar("NSG_REF")).Discard()
+			read := b.GetRef(&ibclient.Nsgroup{}, ibclient.StateVar("NSG_REF"))
+			found := b.Get(&ibclient.Nsgroup{}, ibclient.NewQueryParams(false, map[string]string{"name": "ns-group"}))
+			state := b.DisplayState()
+
+			res, err := objMgr.ExecuteMultiRequest(b)
+			Expect(err).To(BeNil())
+
+			var nsg ibclient.Nsgroup
+			Expect(res.Decode(created, &nsg)).To(Succeed())
+			Expect(*nsg.Comment).To(Equal("first"))
+			ref, err := res.Ref(created)
+			Expect(err).To(BeNil())
+			Expect(ref).To(Equal(nsg.Ref))
+
+			Expect(res.Decode(read, &nsg)).To(Succeed())
+			Expect(*nsg.Comment).To(Equal("second"))
+
+			var list []ibclient.Nsgroup
+			Expect(res.Decode(found, &list)).To(Succeed())
+			Expect(list).To(HaveLen(1))
+			Expect(list[0].Ref).To(Equal(ref))
+
+			var vars map[string]interface{}
+			Expect(res.Decode(state, &vars)).To(Succeed())
+			Expect(vars).To(Equal(map[string]interface{}{"NSG_REF": ref}))
+		})
+
+		It("should report the results which can't be decoded", func() {
+			ref := server.Add("nsgroup", map[string]interface{}{"name": "ns-group"})
+
+			b := ibclient.NewMultiRequestBuilder()
+			discarded := b.GetRef(&ibclient.Nsgroup{}, ref).Discard()
+			deleted := b.Delete(ref)
+			none := b.Get(&ibclient.Nsgroup{}, nil)
+
+			res, err := objMgr.ExecuteMultiRequest(b)
+			Expect(err).To(BeNil())
+
+			var nsg ibclient.Nsgroup
+			Expect(res.Decode(discarded, &nsg)).To(MatchError("the result of step 0 of the multi-request is discarded"))
+			Expect(res.Decode(deleted, &nsg)).To(MatchError(
+				"step 1 of the multi-request returned the reference \"" + ref + "\", not an object"))
+			var deletedRef string
+			Expect(res.Decode(deleted, &deletedRef)).To(Succeed())
+			Expect(deletedRef).To(Equal(ref))
+
+			err = res.Decode(none, &nsg)
+			Expect(ibclient.IsNotFound(err)).To(BeTrue())
+			var list []ibclient.Nsgroup
+			Expect(res.Decode(none, &list)).To(Succeed())
+			Expect(list).To(BeEmpty())
+		})
+
+		It("should roll back all the steps when one fails", func() {
+			b := ibclient.NewMultiRequestBuilder()
+			b.Create(&ibclient.Nsgroup{Name: utils.StringPtr("ns-group")})
+			b.Delete("nsgroup/ZmFrZS5uc2dyb3VwJDQy:missing")
+
+			_, err := objMgr.ExecuteMultiRequest(b)
+			Expect(err).NotTo(BeNil())
+			Expect(server.Objects("nsgroup")).To(BeEmpty())
+		})
+	})
+})
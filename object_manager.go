@@ -1,15 +1,17 @@
 package ibclient
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"strings"
 )
 
 // Compile-time interface checks
-var _ IBObjectManager = new(ObjectManager)
+var _ IBContextObjectManager = new(ObjectManager)
 
 type IBObjectManager interface {
 	GetDNSView(name string) (*View, error)
@@ -173,6 +175,13 @@ type IBObjectManager interface {
 	UpdateDhcpStatus(ref string, status bool) (Dhcp, error)
 }
 
+// IBContextObjectManager is an IBObjectManager whose calls can be
+// cancelled or bounded by a deadline through a context.Context.
+type IBContextObjectManager interface {
+	IBObjectManager
+	WithContext(ctx context.Context) IBContextObjectManager
+}
+
 const (
 	ARecord               = "A"
 	AaaaRecord            = "AAAA"
@@ -615,6 +624,7 @@ type ObjectManager struct {
 	connector IBConnector
 	cmpType   string
 	tenantID  string
+	logger    *slog.Logger
 }
 
 func NewObjectManager(connector IBConnector, cmpType string, tenantID string) IBObjectManager {
@@ -627,13 +637,54 @@ func NewObjectManager(connector IBConnector, cmpType string, tenantID string) IB
 	return objMgr
 }
 
+// contextConnector binds a context to an IBContextConnector, so that
+// every call made through the IBConnector interface is bound to it.
+type contextConnector struct {
+	ctx  context.Context
+	conn IBContextConnector
+}
+
+func (c *contextConnector) CreateObject(obj IBObject) (string, error) {
+	return c.conn.CreateObjectCtx(c.ctx, obj)
+}
+
+func (c *contextConnector) GetObject(obj IBObject, ref string, queryParams *QueryParams, res interface{}) error {
+	return c.conn.GetObjectCtx(c.ctx, obj, ref, queryParams, res)
+}
+
+func (c *contextConnector) DeleteObject(ref string) (string, error) {
+	return c.conn.DeleteObjectCtx(c.ctx, ref)
+}
+
+func (c *contextConnector) UpdateObject(obj IBObject, ref string) (string, error) {
+	return c.conn.UpdateObjectCtx(c.ctx, obj, ref)
+}
+
+// unwrapConnector returns the underlying connector and the context
+// its calls are bound to.
+func unwrapConnector(connector IBConnector) (IBConnector, context.Context) {
+	if cc, ok := connector.(*contextConnector); ok {
+		return cc.conn, cc.ctx
+	}
+	return connector, context.Background()
+}
+
+// WithContext returns a shallow copy of the object manager whose calls
+// are bound to ctx, so they can be cancelled or bounded by a deadline.
+// The context is ignored if the connector does not implement IBContextConnector.
+func (objMgr *ObjectManager) WithContext(ctx context.Context) IBContextObjectManager {
+	res := *objMgr
+	base, _ := unwrapConnector(objMgr.connector)
+	if conn, ok := base.(IBContextConnector); ok {
+		res.connector = &contextConnector{ctx: ctx, conn: conn}
+	}
+	return &res
+}
+
 // CreateMultiObject unmarshals the result into slice of maps
 func (objMgr *ObjectManager) CreateMultiObject(req *MultiRequest) ([]map[string]interface{}, error) {
 
-	conn := objMgr.connector.(*Connector)
-	queryParams := NewQueryParams(false, nil)
-	res, err := conn.makeRequest(CREATE, req, "", queryParams)
-
+	res, err := objMgr.sendMultiRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -648,6 +699,17 @@ func (objMgr *ObjectManager) CreateMultiObject(req *MultiRequest) ([]map[string]
 	return result, nil
 }
 
+// sendMultiRequest sends req and returns the raw results, which
+// CreateObject can't since it decodes a reference.
+func (objMgr *ObjectManager) sendMultiRequest(req *MultiRequest) ([]byte, error) {
+	base, ctx := unwrapConnector(objMgr.connector)
+	conn, ok := base.(*Connector)
+	if !ok {
+		return nil, fmt.Errorf("multi-requests need a *Connector, not %T", base)
+	}
+	return conn.makeRequest(ctx, CREATE, req, "", NewQueryParams(false, nil))
+}
+
 // GetUpgradeStatus returns the grid upgrade information
 func (objMgr *ObjectManager) GetUpgradeStatus(statusType string) ([]UpgradeStatus, error) {
 	var res []UpgradeStatus
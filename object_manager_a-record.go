@@ -165,3 +165,27 @@ func (objMgr *ObjectManager) GetARecordByRef(ref string) (*RecordA, error) {
 func (objMgr *ObjectManager) DeleteARecord(ref string) (string, error) {
 	return objMgr.connector.DeleteObject(ref)
 }
+
+// CreateARecordWithOptions creates an A record with the given name and IPv4
+// address, e.g. NextAvailableIP(cidr, netView), and the fields set by opts.
+func (objMgr *ObjectManager) CreateARecordWithOptions(name string, ipAddr string, opts ...Option) (*RecordA, error) {
+	if name == "" || ipAddr == "" {
+		return nil, fmt.Errorf("name and IP address are required to create an A record")
+	}
+	opts = append([]Option{WithField("name", name), WithField("ipv4addr", ipAddr)}, opts...)
+	ref, err := createWithOptions(objMgr.connector, NewEmptyRecordA(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return objMgr.GetARecordByRef(ref)
+}
+
+// UpdateARecordWithOptions updates the fields set by opts of the A record
+// with the given reference, leaving its other fields unchanged.
+func (objMgr *ObjectManager) UpdateARecordWithOptions(ref string, opts ...Option) (*RecordA, error) {
+	newRef, err := updateWithOptions(objMgr.connector, NewEmptyRecordA(), ref, opts)
+	if err != nil {
+		return nil, err
+	}
+	return objMgr.GetARecordByRef(newRef)
+}
@@ -155,3 +155,27 @@ func (objMgr *ObjectManager) UpdateAAAARecord(
 	}
 	return recordAAAA, nil
 }
+
+// CreateAAAARecordWithOptions creates an AAAA record with the given name and
+// IPv6 address, e.g. NextAvailableIP(cidr, netView), and the fields set by opts.
+func (objMgr *ObjectManager) CreateAAAARecordWithOptions(name string, ipAddr string, opts ...Option) (*RecordAAAA, error) {
+	if name == "" || ipAddr == "" {
+		return nil, fmt.Errorf("name and IP address are required to create an AAAA record")
+	}
+	opts = append([]Option{WithField("name", name), WithField("ipv6addr", ipAddr)}, opts...)
+	ref, err := createWithOptions(objMgr.connector, NewEmptyRecordAAAA(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return objMgr.GetAAAARecordByRef(ref)
+}
+
+// UpdateAAAARecordWithOptions updates the fields set by opts of the AAAA record
+// with the given reference, leaving its other fields unchanged.
+func (objMgr *ObjectManager) UpdateAAAARecordWithOptions(ref string, opts ...Option) (*RecordAAAA, error) {
+	newRef, err := updateWithOptions(objMgr.connector, NewEmptyRecordAAAA(), ref, opts)
+	if err != nil {
+		return nil, err
+	}
+	return objMgr.GetAAAARecordByRef(newRef)
+}
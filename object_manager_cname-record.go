@@ -82,3 +82,27 @@ func (objMgr *ObjectManager) UpdateCNAMERecord(
 	recordCNAME, err = objMgr.GetCNAMERecordByRef(updatedRef)
 	return recordCNAME, err
 }
+
+// CreateCNAMERecordWithOptions creates a CNAME record with the given name
+// and canonical name, and the fields set by opts.
+func (objMgr *ObjectManager) CreateCNAMERecordWithOptions(name string, canonical string, opts ...Option) (*RecordCNAME, error) {
+	if canonical == "" || name == "" {
+		return nil, fmt.Errorf("canonical name and record name fields are required to create a CNAME record")
+	}
+	opts = append([]Option{WithField("name", name), WithField("canonical", canonical)}, opts...)
+	ref, err := createWithOptions(objMgr.connector, NewEmptyRecordCNAME(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return objMgr.GetCNAMERecordByRef(ref)
+}
+
+// UpdateCNAMERecordWithOptions updates the fields set by opts of the CNAME
+// record with the given reference, leaving its other fields unchanged.
+func (objMgr *ObjectManager) UpdateCNAMERecordWithOptions(ref string, opts ...Option) (*RecordCNAME, error) {
+	newRef, err := updateWithOptions(objMgr.connector, NewEmptyRecordCNAME(), ref, opts)
+	if err != nil {
+		return nil, err
+	}
+	return objMgr.GetCNAMERecordByRef(newRef)
+}
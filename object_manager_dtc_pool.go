@@ -343,6 +343,49 @@ func (objMgr *ObjectManager) CreateDtcPool(
 	return poolDtc, nil
 }
 
+// CreateDtcPoolWithOptions creates a DTC pool with the given name, preferred
+// load balancing method, servers and monitors, and the fields set by opts.
+// The servers and monitors are given by name, and resolved to their
+// references as in CreateDtcPool.
+func (objMgr *ObjectManager) CreateDtcPoolWithOptions(
+	name string,
+	lbPreferredMethod string,
+	servers []*DtcServerLink,
+	monitors []Monitor,
+	opts ...Option) (*DtcPool, error) {
+
+	if name == "" || lbPreferredMethod == "" {
+		return nil, fmt.Errorf("name and preferred load balancing method must be provided to create a pool")
+	}
+	required := []Option{WithField("name", name), WithField("lb_preferred_method", lbPreferredMethod)}
+	if len(servers) > 0 {
+		if err := updateServerReferences(servers, objMgr); err != nil {
+			return nil, err
+		}
+		required = append(required, WithField("servers", servers))
+	}
+	if len(monitors) > 0 {
+		monitorRefs := make([]string, 0, len(monitors))
+		for _, monitor := range monitors {
+			monitorRef, err := getMonitorReference(monitor.Name, monitor.Type, objMgr)
+			if err != nil {
+				return nil, err
+			}
+			monitorRefs = append(monitorRefs, monitorRef)
+		}
+		// WAPI takes the monitors by reference, as in DtcPool.MarshalJSON
+		required = append(required, func(t *optionTarget) error {
+			t.fields["monitors"] = monitorRefs
+			return nil
+		})
+	}
+	ref, err := createWithOptions(objMgr.connector, NewEmptyDtcPool(), append(required, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return objMgr.GetDtcPoolByRef(ref)
+}
+
 func (objMgr *ObjectManager) GetAllDtcPool(queryParams *QueryParams) ([]DtcPool, error) {
 	var res []DtcPool
 	pool := NewEmptyDtcPool()
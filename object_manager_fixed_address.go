@@ -74,6 +74,33 @@ func (objMgr *ObjectManager) AllocateIP(
 	return fixedAddr, err
 }
 
+// AllocateIPWithOptions creates a fixed address with the given IP address,
+// e.g. NextAvailableIP(cidr, netview), MAC address or DUID, and the fields
+// set by opts. The zero MAC address is used when an IPv4 fixed address has
+// no MAC address.
+func (objMgr *ObjectManager) AllocateIPWithOptions(ipAddr string, isIPv6 bool, macOrDuid string, opts ...Option) (*FixedAddress, error) {
+	if ipAddr == "" {
+		return nil, fmt.Errorf("the IP address is required to create a fixed address")
+	}
+	var required []Option
+	if isIPv6 {
+		if macOrDuid == "" {
+			return nil, fmt.Errorf("the DUID field cannot be left empty")
+		}
+		required = []Option{WithField("ipv6addr", ipAddr), WithField("duid", macOrDuid)}
+	} else {
+		if macOrDuid == "" {
+			macOrDuid = MACADDR_ZERO
+		}
+		required = []Option{WithField("ipv4addr", ipAddr), WithMAC(macOrDuid)}
+	}
+	ref, err := createWithOptions(objMgr.connector, NewEmptyFixedAddress(isIPv6), append(required, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return objMgr.GetFixedAddressByRef(ref)
+}
+
 func (objMgr *ObjectManager) GetFixedAddress(netview string, cidr string, ipAddr string, isIpv6 bool, macOrDuid string) (*FixedAddress, error) {
 	var res []FixedAddress
 
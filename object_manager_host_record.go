@@ -261,3 +261,41 @@ func (objMgr *ObjectManager) UpdateHostRecord(
 func (objMgr *ObjectManager) DeleteHostRecord(ref string) (string, error) {
 	return objMgr.connector.DeleteObject(ref)
 }
+
+// CreateHostRecordWithOptions creates a host record with the given name and
+// addresses, and the fields set by opts.
+func (objMgr *ObjectManager) CreateHostRecordWithOptions(
+	name string,
+	ipv4Addrs []HostRecordIpv4Addr,
+	ipv6Addrs []HostRecordIpv6Addr,
+	opts ...Option) (*HostRecord, error) {
+
+	if name == "" {
+		return nil, fmt.Errorf("name is required to create a host record")
+	}
+	if len(ipv4Addrs) == 0 && len(ipv6Addrs) == 0 {
+		return nil, fmt.Errorf("at least one IP address is required to create a host record")
+	}
+	required := []Option{WithField("name", name)}
+	if len(ipv4Addrs) > 0 {
+		required = append(required, WithField("ipv4addrs", ipv4Addrs))
+	}
+	if len(ipv6Addrs) > 0 {
+		required = append(required, WithField("ipv6addrs", ipv6Addrs))
+	}
+	ref, err := createWithOptions(objMgr.connector, NewEmptyHostRecord(), append(required, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return objMgr.GetHostRecordByRef(ref)
+}
+
+// UpdateHostRecordWithOptions updates the fields set by opts of the host
+// record with the given reference, leaving its other fields unchanged.
+func (objMgr *ObjectManager) UpdateHostRecordWithOptions(ref string, opts ...Option) (*HostRecord, error) {
+	newRef, err := updateWithOptions(objMgr.connector, NewEmptyHostRecord(), ref, opts)
+	if err != nil {
+		return nil, err
+	}
+	return objMgr.GetHostRecordByRef(newRef)
+}
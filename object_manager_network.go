@@ -2,7 +2,7 @@ package ibclient
 
 import (
 	"fmt"
-	"regexp"
+	"strings"
 )
 
 func (objMgr *ObjectManager) CreateNetwork(netview string, cidr string, isIPv6 bool, comment string, eas EA) (*Network, error) {
@@ -17,6 +17,21 @@ func (objMgr *ObjectManager) CreateNetwork(netview string, cidr string, isIPv6 b
 	return network, err
 }
 
+// CreateNetworkWithOptions creates a network with the given CIDR, e.g.
+// NextAvailableNetwork(container, netview, prefixLen), and the fields set
+// by opts.
+func (objMgr *ObjectManager) CreateNetworkWithOptions(cidr string, isIPv6 bool, opts ...Option) (*Network, error) {
+	if cidr == "" {
+		return nil, fmt.Errorf("the CIDR is required to create a network")
+	}
+	opts = append([]Option{WithField("network", cidr)}, opts...)
+	ref, err := createWithOptions(objMgr.connector, NewNetwork("", "", isIPv6, "", nil), opts)
+	if err != nil {
+		return nil, err
+	}
+	return objMgr.GetNetworkByRef(ref)
+}
+
 func (objMgr *ObjectManager) AllocateNetwork(
 	netview string,
 	cidr string,
@@ -76,6 +91,35 @@ func (objMgr *ObjectManager) AllocateNextAvailableIp(
 	return nil, err
 }
 
+// AllocateNextAvailableIpWithOptions creates a record of objectType, i.e.
+// record:a, record:aaaa or record:host, with the given name, the next
+// available IP address of the network cidr of netview and the fields set
+// by opts.
+func (objMgr *ObjectManager) AllocateNextAvailableIpWithOptions(
+	name string,
+	objectType string,
+	cidr string,
+	netview string,
+	opts ...Option) (interface{}, error) {
+
+	if cidr == "" {
+		return nil, fmt.Errorf("the network is required to allocate the next available IP address")
+	}
+	ipAddr := NextAvailableIP(cidr, netview)
+	switch objectType {
+	case "record:a":
+		return objMgr.CreateARecordWithOptions(name, ipAddr, opts...)
+	case "record:aaaa":
+		return objMgr.CreateAAAARecordWithOptions(name, ipAddr, opts...)
+	case "record:host":
+		if strings.Contains(cidr, ":") {
+			return objMgr.CreateHostRecordWithOptions(name, nil, []HostRecordIpv6Addr{{Ipv6Addr: &ipAddr}}, opts...)
+		}
+		return objMgr.CreateHostRecordWithOptions(name, []HostRecordIpv4Addr{{Ipv4Addr: &ipAddr}}, nil, opts...)
+	}
+	return nil, fmt.Errorf("the next available IP address can't be allocated to %s objects", objectType)
+}
+
 func (objMgr *ObjectManager) AllocateNetworkByEA(
 	netview string, isIPv6 bool, comment string, eas EA, eaMap map[string]string, prefixLen uint, object string) (network *Network, err error) {
 
@@ -155,10 +199,10 @@ func (objMgr *ObjectManager) GetNetwork(netview string, cidr string, isIPv6 bool
 }
 
 func (objMgr *ObjectManager) GetNetworkByRef(ref string) (*Network, error) {
-	r := regexp.MustCompile("^ipv6network\\/.+")
-	isIPv6 := r.MatchString(ref)
+	// an invalid reference is reported by WAPI
+	r, _ := ParseRef(ref)
 
-	network := NewNetwork("", "", isIPv6, "", nil)
+	network := NewNetwork("", "", r.IsIPv6(), "", nil)
 	err := objMgr.connector.GetObject(network, ref, NewQueryParams(false, nil), network)
 	return network, err
 }
@@ -172,10 +216,9 @@ func (objMgr *ObjectManager) UpdateNetwork(
 	setEas EA,
 	comment string) (*Network, error) {
 
-	r := regexp.MustCompile("^ipv6network\\/.+")
-	isIPv6 := r.MatchString(ref)
+	r, _ := ParseRef(ref)
 
-	nw := NewNetwork("", "", isIPv6, "", nil)
+	nw := NewNetwork("", "", r.IsIPv6(), "", nil)
 	err := objMgr.connector.GetObject(
 		nw, ref, NewQueryParams(false, nil), nw)
 
@@ -202,6 +245,34 @@ func (objMgr *ObjectManager) UpdateNetwork(
 	return nw, nil
 }
 
+// PatchNetwork updates the comment and the EAs of a network like UpdateNetwork,
+// but sends only what differs from the network's current values: the EAs
+// to add or change in extattrs+ and the ones to delete in extattrs-.
+// A nil comment or a nil setEas map is left unchanged.
+func (objMgr *ObjectManager) PatchNetwork(
+	ref string,
+	setEas EA,
+	comment *string) (*Network, error) {
+
+	objType, err := refObjectType(ref)
+	if err != nil {
+		return nil, err
+	}
+	desired := &rawObject{objType: objType, fields: map[string]interface{}{}}
+	if setEas != nil {
+		desired.fields["extattrs"] = setEas
+	}
+	if comment != nil {
+		desired.fields["comment"] = *comment
+	}
+
+	newRef, err := PatchObject(objMgr.connector, ref, desired)
+	if err != nil {
+		return nil, err
+	}
+	return objMgr.GetNetworkByRef(newRef)
+}
+
 func (objMgr *ObjectManager) DeleteNetwork(ref string) (string, error) {
 	return objMgr.connector.DeleteObject(ref)
 }
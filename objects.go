@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"reflect"
 	"time"
 )
@@ -118,6 +119,20 @@ type QueryParams struct {
 	forceProxy bool
 
 	searchFields map[string]string
+
+	// search holds the search arguments built by a Query, with their
+	// modifiers, returnFieldsPlus the fields requested with _return_fields+
+	search           url.Values
+	returnFieldsPlus []string
+
+	// paging, maxResults and pageId control WAPI result paging
+	paging     bool
+	maxResults int
+	pageId     string
+
+	// schema requests the schema of the object type, or the WAPI schema
+	// without one, with a bare _schema argument
+	schema bool
 }
 
 func NewQueryParams(forceProxy bool, searchFields map[string]string) *QueryParams {
@@ -131,6 +146,17 @@ func NewQueryParams(forceProxy bool, searchFields map[string]string) *QueryParam
 	return &qp
 }
 
+// SetPaging enables WAPI result paging with pages of up to maxResults
+// objects. GetObject then follows next_page_id until all the results
+// have been fetched. A non-positive maxResults selects DefaultPageSize.
+func (qp *QueryParams) SetPaging(maxResults int) {
+	if maxResults <= 0 {
+		maxResults = DefaultPageSize
+	}
+	qp.paging = true
+	qp.maxResults = maxResults
+}
+
 type RequestBody struct {
 	Data               map[string]interface{} `json:"data,omitempty"`
 	Args               map[string]string      `json:"args,omitempty"`
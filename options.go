@@ -0,0 +1,242 @@
+package ibclient
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Option sets a field of an object created or updated by the *WithOptions
+// functions. Only the fields set by options are sent to WAPI: the fields
+// which are not set keep their server default on creation and their
+// current value on update.
+type Option func(t *optionTarget) error
+
+// optionTarget collects the fields set by options, checking
+// that they are fields of obj
+type optionTarget struct {
+	obj    IBObject
+	fields map[string]interface{}
+}
+
+func (t *optionTarget) has(name string) bool {
+	_, ok := jsonFieldType(t.obj, name)
+	return ok
+}
+
+func (t *optionTarget) set(name string, value interface{}) error {
+	fieldType, ok := jsonFieldType(t.obj, name)
+	if !ok {
+		return fmt.Errorf("%s objects have no field '%s'", t.obj.ObjectType(), name)
+	}
+	if value != nil && !assignable(value, fieldType) {
+		return fmt.Errorf("the field '%s' of %s objects can't be set to a %T", name, t.obj.ObjectType(), value)
+	}
+	t.fields[name] = value
+	return nil
+}
+
+// assignable reports whether value can be set to a field of fieldType, or
+// of the type it points to. An int or a float64, which untyped constants
+// such as WithField("ttl", 300) are given, can be set to any numeric field
+// which represents it exactly.
+func assignable(value interface{}, fieldType reflect.Type) bool {
+	v := reflect.ValueOf(value)
+	for {
+		if v.Type().AssignableTo(fieldType) {
+			return true
+		}
+		if fieldType.Kind() != reflect.Ptr {
+			break
+		}
+		fieldType = fieldType.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Int:
+		switch fieldType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return !reflect.Zero(fieldType).OverflowInt(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return v.Int() >= 0 && !reflect.Zero(fieldType).OverflowUint(uint64(v.Int()))
+		case reflect.Float32, reflect.Float64:
+			return true
+		}
+	case reflect.Float64:
+		switch fieldType.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return !reflect.Zero(fieldType).OverflowFloat(v.Float())
+		}
+	}
+	return false
+}
+
+// applyOptions returns the object of type obj with the fields set by opts
+func applyOptions(obj IBObject, opts []Option) (*rawObject, error) {
+	t := &optionTarget{obj: obj, fields: make(map[string]interface{})}
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+	return &rawObject{objType: obj.ObjectType(), fields: t.fields}, nil
+}
+
+// jsonFieldType returns the type of the field of obj with the given JSON name
+func jsonFieldType(obj IBObject, name string) (reflect.Type, bool) {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if jsonName(field) == name {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// jsonName returns the JSON name of a struct field
+func jsonName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+// WithField sets any field of the object, by its WAPI name.
+func WithField(name string, value interface{}) Option {
+	return func(t *optionTarget) error {
+		return t.set(name, value)
+	}
+}
+
+// WithComment sets the comment of the object.
+func WithComment(comment string) Option {
+	return WithField("comment", comment)
+}
+
+// WithEA sets the extensible attributes of the object.
+func WithEA(eas EA) Option {
+	return WithField("extattrs", eas)
+}
+
+// WithTTL sets the TTL of the object, and use_ttl when the object has it.
+func WithTTL(ttl uint32) Option {
+	return func(t *optionTarget) error {
+		if err := t.set("ttl", ttl); err != nil {
+			return err
+		}
+		if t.has("use_ttl") {
+			return t.set("use_ttl", true)
+		}
+		return nil
+	}
+}
+
+// WithView sets the DNS view of the object.
+func WithView(view string) Option {
+	return WithField("view", view)
+}
+
+// WithNetworkView sets the network view of the object.
+func WithNetworkView(netview string) Option {
+	return WithField("network_view", netview)
+}
+
+// WithDisable sets whether the object is disabled.
+func WithDisable(disable bool) Option {
+	return WithField("disable", disable)
+}
+
+// WithMAC sets the MAC address of the object, e.g. a fixed address.
+func WithMAC(mac string) Option {
+	return WithField("mac", mac)
+}
+
+// WithAliases sets the aliases of a host record.
+func WithAliases(aliases ...string) Option {
+	return WithField("aliases", aliases)
+}
+
+// WithEnableDNS sets whether a host record is configured for DNS.
+func WithEnableDNS(enable bool) Option {
+	return WithField("configure_for_dns", enable)
+}
+
+// WithEnableDHCP sets whether the object is configured for DHCP.
+func WithEnableDHCP(enable bool) Option {
+	return WithField("configure_for_dhcp", enable)
+}
+
+// NextAvailableIP returns the address to set to allocate the next
+// available IP address of a network, e.g. with WithField("ipv4addr", ...).
+func NextAvailableIP(cidr string, netview string) string {
+	if netview == "" {
+		netview = "default"
+	}
+	return fmt.Sprintf("func:nextavailableip:%s,%s", cidr, netview)
+}
+
+// NextAvailableNetwork returns the network to set to allocate the next
+// available network of prefixLen in a network container, e.g. with
+// CreateNetworkWithOptions.
+func NextAvailableNetwork(container string, netview string, prefixLen uint) string {
+	if netview == "" {
+		netview = "default"
+	}
+	return fmt.Sprintf("func:nextavailablenetwork:%s,%s,%d", container, netview, prefixLen)
+}
+
+// CreateWithOptions creates an object of type T with the fields set by opts,
+// and returns the created object, as stored by NIOS.
+func CreateWithOptions[T any, PT Object[T]](conn IBConnector, opts ...Option) (*T, error) {
+	ref, err := createWithOptions(conn, PT(new(T)), opts)
+	if err != nil {
+		return nil, err
+	}
+	return Get[T, PT](conn, ref)
+}
+
+// UpdateWithOptions updates the fields set by opts of the object of type T
+// with the given reference, and returns the updated object.
+func UpdateWithOptions[T any, PT Object[T]](conn IBConnector, ref string, opts ...Option) (*T, error) {
+	newRef, err := updateWithOptions(conn, PT(new(T)), ref, opts)
+	if err != nil {
+		return nil, err
+	}
+	return Get[T, PT](conn, newRef)
+}
+
+// createWithOptions creates an object of the type of obj
+// with the fields set by opts, and returns its reference
+func createWithOptions(conn IBConnector, obj IBObject, opts []Option) (string, error) {
+	if obj.ObjectType() == "" {
+		return "", fmt.Errorf("the object type of %T is not known", obj)
+	}
+	raw, err := applyOptions(obj, opts)
+	if err != nil {
+		return "", err
+	}
+	return conn.CreateObject(raw)
+}
+
+// updateWithOptions updates the fields set by opts of the object
+// of the type of obj with the given reference, and returns its new reference
+func updateWithOptions(conn IBConnector, obj IBObject, ref string, opts []Option) (string, error) {
+	if err := checkRefType(ref, obj.ObjectType()); err != nil {
+		return "", err
+	}
+	raw, err := applyOptions(obj, opts)
+	if err != nil {
+		return "", err
+	}
+	if len(raw.fields) == 0 {
+		return "", fmt.Errorf("no field to update")
+	}
+	return conn.UpdateObject(raw, ref)
+}
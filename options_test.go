@@ -0,0 +1,179 @@
+package ibclient_test
+
+import (
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Functional options", func() {
+	var (
+		server *fakewapi.Server
+		conn   *ibclient.Connector
+		objMgr *ibclient.ObjectManager
+	)
+
+	BeforeEach(func() {
+		server, conn = newFakeConnector()
+		objMgr = ibclient.NewObjectManager(conn, "cmp", "tenant").(*ibclient.ObjectManager)
+	})
+
+	It("should send only the fields set by options on creation", func() {
+		rec, err := objMgr.CreateARecordWithOptions("web.example.com", "10.0.0.5",
+			ibclient.WithTTL(300), ibclient.WithComment("web server"))
+		Expect(err).To(BeNil())
+		Expect(*rec.Ipv4Addr).To(Equal("10.0.0.5"))
+
+		stored, ok := server.Get(rec.Ref)
+		Expect(ok).To(BeTrue())
+		Expect(stored).To(HaveKeyWithValue("name", "web.example.com"))
+		Expect(stored).To(HaveKeyWithValue("use_ttl", true))
+		Expect(stored).To(HaveKeyWithValue("comment", "web server"))
+		Expect(stored).NotTo(HaveKey("extattrs"))
+		Expect(stored).NotTo(HaveKey("disable"))
+	})
+
+	It("should allocate the next available IP address", func() {
+		server.Add("network", map[string]interface{}{"network": "10.1.0.0/29", "network_view": "default"})
+
+		rec, err := objMgr.CreateARecordWithOptions("db.example.com", ibclient.NextAvailableIP("10.1.0.0/29", ""))
+		Expect(err).To(BeNil())
+		Expect(*rec.Ipv4Addr).To(Equal("10.1.0.1"))
+	})
+
+	It("should update only the fields set by options", func() {
+		ref := server.Add("record:cname", map[string]interface{}{
+			"name": "www.example.com", "canonical": "web.example.com", "comment": "first",
+			"extattrs": map[string]interface{}{"Site": map[string]interface{}{"value": "Lab"}}})
+
+		rec, err := objMgr.UpdateCNAMERecordWithOptions(ref, ibclient.WithComment("second"))
+		Expect(err).To(BeNil())
+		Expect(*rec.Comment).To(Equal("second"))
+
+		stored, _ := server.Get(rec.Ref)
+		Expect(stored["canonical"]).To(Equal("web.example.com"))
+		Expect(stored["extattrs"]).To(Equal(map[string]interface{}{"Site": map[string]interface{}{"value": "Lab"}}))
+	})
+
+	It("should create host records", func() {
+		addr := ibclient.NewHostRecordIpv4Addr("10.0.0.7", "", false, "")
+		rec, err := objMgr.CreateHostRecordWithOptions("host.example.com", []ibclient.HostRecordIpv4Addr{*addr}, nil,
+			ibclient.WithAliases("alias.example.com"), ibclient.WithEnableDNS(true))
+		Expect(err).To(BeNil())
+		Expect(rec.Ref).To(HavePrefix("record:host/"))
+
+		stored, _ := server.Get(rec.Ref)
+		Expect(stored["aliases"]).To(Equal([]interface{}{"alias.example.com"}))
+		Expect(stored["configure_for_dns"]).To(BeTrue())
+		Expect(stored).NotTo(HaveKey("ipv6addrs"))
+		Expect(stored).NotTo(HaveKey("extattrs"))
+	})
+
+	It("should allocate the next available IP address to any record type", func() {
+		server.Add("network", map[string]interface{}{"network": "10.2.0.0/29", "network_view": "default"})
+
+		rec, err := objMgr.AllocateNextAvailableIpWithOptions("host.example.com", "record:host", "10.2.0.0/29", "",
+			ibclient.WithComment("allocated"))
+		Expect(err).To(BeNil())
+		host := rec.(*ibclient.HostRecord)
+		Expect(*host.Ipv4Addrs[0].Ipv4Addr).To(Equal("10.2.0.1"))
+
+		_, err = objMgr.AllocateNextAvailableIpWithOptions("cname.example.com", "record:cname", "10.2.0.0/29", "")
+		Expect(err).To(MatchError("the next available IP address can't be allocated to record:cname objects"))
+	})
+
+	It("should create fixed addresses", func() {
+		server.Add("network", map[string]interface{}{"network": "10.3.0.0/29", "network_view": "default"})
+
+		fa, err := objMgr.AllocateIPWithOptions(ibclient.NextAvailableIP("10.3.0.0/29", ""), false, "",
+			ibclient.WithField("name", "printer"))
+		Expect(err).To(BeNil())
+		Expect(fa.IPv4Address).To(Equal("10.3.0.1"))
+
+		stored, _ := server.Get(fa.Ref)
+		Expect(stored).To(HaveKeyWithValue("mac", ibclient.MACADDR_ZERO))
+		Expect(stored).To(HaveKeyWithValue("name", "printer"))
+
+		_, err = objMgr.AllocateIPWithOptions("2001:db8::1", true, "")
+		Expect(err).To(MatchError("the DUID field cannot be left empty"))
+	})
+
+	It("should create networks", func() {
+		server.Add("networkcontainer", map[string]interface{}{"network": "10.4.0.0/16", "network_view": "default"})
+
+		network, err := objMgr.CreateNetworkWithOptions(ibclient.NextAvailableNetwork("10.4.0.0/16", "", 24), false,
+			ibclient.WithComment("allocated"))
+		Expect(err).To(BeNil())
+		Expect(network.Cidr).To(Equal("10.4.0.0/24"))
+		Expect(network.Comment).To(Equal("allocated"))
+	})
+
+	It("should resolve the servers and monitors of DTC pools", func() {
+		serverRef := server.Add("dtc:server", map[string]interface{}{"name": "web1", "host": "10.0.0.1"})
+		monitorRef := server.Add("dtc:monitor:http", map[string]interface{}{"name": "http"})
+
+		pool, err := objMgr.CreateDtcPoolWithOptions("pool", "ROUND_ROBIN",
+			[]*ibclient.DtcServerLink{{Server: "web1", Ratio: 1}},
+			[]ibclient.Monitor{{Name: "http", Type: "http"}},
+			ibclient.WithComment("web pool"))
+		Expect(err).To(BeNil())
+		Expect(*pool.Name).To(Equal("pool"))
+		Expect(pool.Servers).To(Equal([]*ibclient.DtcServerLink{{Server: serverRef, Ratio: 1}}))
+		Expect(pool.Monitors).To(Equal([]*ibclient.DtcMonitorHttp{{Ref: monitorRef}}))
+
+		stored, _ := server.Get(pool.Ref)
+		Expect(stored["monitors"]).To(Equal([]interface{}{monitorRef}))
+		Expect(stored).To(HaveKeyWithValue("comment", "web pool"))
+	})
+
+	It("should create and update objects of any type", func() {
+		nsg, err := ibclient.CreateWithOptions[ibclient.Nsgroup](conn,
+			ibclient.WithField("name", "ns-group"), ibclient.WithComment("first"))
+		Expect(err).To(BeNil())
+		Expect(*nsg.Name).To(Equal("ns-group"))
+
+		nsg, err = ibclient.UpdateWithOptions[ibclient.Nsgroup](conn, nsg.Ref, ibclient.WithComment("second"))
+		Expect(err).To(BeNil())
+		Expect(*nsg.Name).To(Equal("ns-group"))
+		Expect(*nsg.Comment).To(Equal("second"))
+	})
+
+	It("should reject the options which don't apply to the object", func() {
+		_, err := objMgr.CreateCNAMERecordWithOptions("www.example.com", "web.example.com", ibclient.WithMAC("00:11:22:33:44:55"))
+		Expect(err).To(MatchError("record:cname objects have no field 'mac'"))
+
+		_, err = objMgr.CreateCNAMERecordWithOptions("www.example.com", "web.example.com", ibclient.WithField("ttl", "300"))
+		Expect(err).To(MatchError("the field 'ttl' of record:cname objects can't be set to a string"))
+		Expect(server.Objects("record:cname")).To(BeEmpty())
+	})
+
+	It("should reject the values of another type than the field's", func() {
+		_, err := objMgr.CreateCNAMERecordWithOptions("www.example.com", "web.example.com", ibclient.WithField("comment", 42))
+		Expect(err).To(MatchError("the field 'comment' of record:cname objects can't be set to a int"))
+
+		_, err = objMgr.CreateCNAMERecordWithOptions("www.example.com", "web.example.com", ibclient.WithField("ttl", -1))
+		Expect(err).To(MatchError("the field 'ttl' of record:cname objects can't be set to a int"))
+
+		_, err = objMgr.CreateCNAMERecordWithOptions("www.example.com", "web.example.com", ibclient.WithField("disable", 1))
+		Expect(err).To(MatchError("the field 'disable' of record:cname objects can't be set to a int"))
+		Expect(server.Objects("record:cname")).To(BeEmpty())
+
+		rec, err := objMgr.CreateCNAMERecordWithOptions("www.example.com", "web.example.com",
+			ibclient.WithField("ttl", 300), ibclient.WithField("comment", utils.StringPtr("web")))
+		Expect(err).To(BeNil())
+		Expect(*rec.Ttl).To(Equal(uint32(300)))
+		Expect(*rec.Comment).To(Equal("web"))
+	})
+
+	It("should reject updates without options", func() {
+		ref := server.Add("record:a", map[string]interface{}{"name": "web.example.com", "ipv4addr": "10.0.0.5"})
+		_, err := objMgr.UpdateARecordWithOptions(ref)
+		Expect(err).To(MatchError("no field to update"))
+
+		_, err = objMgr.UpdateARecordWithOptions("record:cname/Y25hbWU:www.example.com/default", ibclient.WithComment("c"))
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,201 @@
+package ibclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Diff returns the fields to send to WAPI to turn the object current into
+// desired: the fields set in desired whose value differs from current.
+// The fields which are nil in desired are left unchanged, so desired should
+// only set the fields to manage, e.g. &HostRecord{Comment: &comment} rather
+// than the result of NewHostRecord.
+//
+// Extensible attributes are compared one by one: the ones to add or change
+// are sent in extattrs+ and the ones to remove in extattrs-. They are left
+// unchanged when the EA map of desired is nil, and all removed when it is
+// empty but not nil.
+func Diff(current IBObject, desired IBObject) (map[string]interface{}, error) {
+	currentFields, err := objectFields(current)
+	if err != nil {
+		return nil, err
+	}
+	desiredFields, err := desiredObjectFields(desired)
+	if err != nil {
+		return nil, err
+	}
+	return diffFields(currentFields, desiredFields), nil
+}
+
+// PatchObject updates the object with the given reference with the fields
+// of desired which differ from the object's current fields, see Diff.
+// The object is not updated, and ref is returned, when nothing differs.
+//
+// Unlike UpdateObject, the fields and extensible attributes which desired
+// doesn't set are not sent, so the changes made to them by others since
+// the object was read are kept.
+func PatchObject(conn IBConnector, ref string, desired IBObject) (string, error) {
+	objType, err := refObjectType(ref)
+	if err != nil {
+		return "", err
+	}
+	if desired.ObjectType() != "" && desired.ObjectType() != objType {
+		return "", fmt.Errorf("reference '%s' is not of type %s", ref, desired.ObjectType())
+	}
+	desiredFields, err := desiredObjectFields(desired)
+	if err != nil {
+		return "", err
+	}
+	if len(desiredFields) == 0 {
+		return ref, nil
+	}
+
+	names := make([]string, 0, len(desiredFields))
+	for name := range desiredFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	query := &rawObject{objType: objType}
+	query.SetReturnFields(names)
+	var current map[string]interface{}
+	if err = conn.GetObject(query, ref, NewQueryParams(false, nil), &current); err != nil {
+		return "", err
+	}
+	if current, err = normalizeFields(current); err != nil {
+		return "", err
+	}
+
+	diff := diffFields(current, desiredFields)
+	if len(diff) == 0 {
+		return ref, nil
+	}
+	return conn.UpdateObject(&rawObject{objType: objType, fields: diff}, ref)
+}
+
+// Patch updates the object of type T with the given reference with the
+// fields of desired which differ from its current ones, see PatchObject,
+// and returns the updated object.
+func Patch[T any, PT Object[T]](conn IBConnector, ref string, desired PT) (*T, error) {
+	if err := checkRefType(ref, desired.ObjectType()); err != nil {
+		return nil, err
+	}
+	newRef, err := PatchObject(conn, ref, desired)
+	if err != nil {
+		return nil, err
+	}
+	return Get[T, PT](conn, newRef, desired.ReturnFields()...)
+}
+
+// diffFields returns the fields of desired which differ from current,
+// with the extensible attributes split in extattrs+ and extattrs-
+func diffFields(current map[string]interface{}, desired map[string]interface{}) map[string]interface{} {
+	diff := make(map[string]interface{})
+	for name, value := range desired {
+		if name == "extattrs" {
+			continue
+		}
+		if !reflect.DeepEqual(current[name], value) {
+			diff[name] = value
+		}
+	}
+
+	desiredEAs, ok := desired["extattrs"].(map[string]interface{})
+	if !ok {
+		return diff
+	}
+	currentEAs, _ := current["extattrs"].(map[string]interface{})
+	added := make(map[string]interface{})
+	removed := make(map[string]interface{})
+	for name, value := range desiredEAs {
+		if !reflect.DeepEqual(currentEAs[name], value) {
+			added[name] = value
+		}
+	}
+	for name := range currentEAs {
+		if _, ok := desiredEAs[name]; !ok {
+			removed[name] = map[string]interface{}{}
+		}
+	}
+	if len(added) > 0 {
+		diff["extattrs+"] = added
+	}
+	if len(removed) > 0 {
+		diff["extattrs-"] = removed
+	}
+	return diff
+}
+
+// desiredObjectFields returns the fields set in obj: the ones which are
+// not null, and the extensible attributes when its EA map is not nil
+func desiredObjectFields(obj IBObject) (map[string]interface{}, error) {
+	fields, err := objectFields(obj)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range fields {
+		if value == nil {
+			delete(fields, name)
+		}
+	}
+	if eas, ok := objectEAs(obj); !ok || eas == nil {
+		delete(fields, "extattrs")
+	}
+	return fields, nil
+}
+
+// objectFields returns the fields of obj, as sent to WAPI
+func objectFields(obj IBObject) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal the %s object: %s", obj.ObjectType(), err)
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "_ref")
+	return fields, nil
+}
+
+// normalizeFields returns fields as they are decoded from JSON,
+// so that they can be compared to the fields of objects
+func normalizeFields(fields map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var res map[string]interface{}
+	if err = json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	delete(res, "_ref")
+	return res, nil
+}
+
+// objectEAs returns the EA map of obj, when it has an extattrs field
+func objectEAs(obj IBObject) (EA, bool) {
+	if raw, ok := obj.(*rawObject); ok {
+		eas, ok := raw.fields["extattrs"].(EA)
+		return eas, ok
+	}
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	eaType := reflect.TypeOf(EA{})
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.IsExported() && field.Type == eaType && jsonName(field) == "extattrs" {
+			return v.Field(i).Interface().(EA), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,107 @@
+package ibclient_test
+
+import (
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Patch", func() {
+	var (
+		server *fakewapi.Server
+		conn   *ibclient.Connector
+	)
+
+	BeforeEach(func() {
+		server, conn = newFakeConnector()
+	})
+
+	It("should diff the fields and the EAs", func() {
+		current := &ibclient.HostRecord{
+			Name:    utils.StringPtr("host.example.com"),
+			Comment: utils.StringPtr("first"),
+			Ea:      ibclient.EA{"Site": "Lab", "Owner": "Bob"},
+		}
+		desired := &ibclient.HostRecord{
+			Name:    utils.StringPtr("host.example.com"),
+			Comment: utils.StringPtr("second"),
+			Ea:      ibclient.EA{"Site": "Lab", "Dept": "IT"},
+		}
+
+		diff, err := ibclient.Diff(current, desired)
+		Expect(err).To(BeNil())
+		Expect(diff).To(Equal(map[string]interface{}{
+			"comment":   "second",
+			"extattrs+": map[string]interface{}{"Dept": map[string]interface{}{"value": "IT"}},
+			"extattrs-": map[string]interface{}{"Owner": map[string]interface{}{}},
+		}))
+	})
+
+	It("should leave the EAs unchanged when the desired EA map is nil", func() {
+		current := &ibclient.HostRecord{Comment: utils.StringPtr("first"), Ea: ibclient.EA{"Site": "Lab"}}
+
+		diff, err := ibclient.Diff(current, &ibclient.HostRecord{Comment: utils.StringPtr("second")})
+		Expect(err).To(BeNil())
+		Expect(diff).To(Equal(map[string]interface{}{"comment": "second"}))
+
+		diff, err = ibclient.Diff(current, &ibclient.HostRecord{Ea: ibclient.EA{}})
+		Expect(err).To(BeNil())
+		Expect(diff).To(Equal(map[string]interface{}{
+			"extattrs-": map[string]interface{}{"Site": map[string]interface{}{}},
+		}))
+	})
+
+	It("should send only the fields which differ", func() {
+		ref := server.Add("nsgroup", map[string]interface{}{
+			"name": "ns-group", "comment": "first",
+			"extattrs": map[string]interface{}{"Site": map[string]interface{}{"value": "Lab"}}})
+
+		nsg, err := ibclient.Patch(conn, ref, &ibclient.Nsgroup{
+			Comment: utils.StringPtr("second"),
+			Ea:      ibclient.EA{"Site": "Lab", "Owner": "Bob"},
+		})
+		Expect(err).To(BeNil())
+		Expect(*nsg.Comment).To(Equal("second"))
+
+		// a patch of the comment only keeps the EAs and the other fields
+		_, err = ibclient.PatchObject(conn, nsg.Ref, &ibclient.Nsgroup{Comment: utils.StringPtr("third")})
+		Expect(err).To(BeNil())
+		stored, _ := server.Get(nsg.Ref)
+		Expect(stored["name"]).To(Equal("ns-group"))
+		Expect(stored["comment"]).To(Equal("third"))
+		Expect(stored["extattrs"]).To(Equal(map[string]interface{}{
+			"Site":  map[string]interface{}{"value": "Lab"},
+			"Owner": map[string]interface{}{"value": "Bob"},
+		}))
+	})
+
+	It("should not update an object which doesn't differ", func() {
+		ref := server.Add("nsgroup", map[string]interface{}{"name": "ns-group", "comment": "first"})
+
+		newRef, err := ibclient.PatchObject(conn, ref, &ibclient.Nsgroup{Comment: utils.StringPtr("first")})
+		Expect(err).To(BeNil())
+		Expect(newRef).To(Equal(ref))
+	})
+
+	It("should patch the comment and the EAs of networks", func() {
+		objMgr := ibclient.NewObjectManager(conn, "cmp", "tenant").(*ibclient.ObjectManager)
+		ref := server.Add("network", map[string]interface{}{
+			"network": "10.0.0.0/24", "network_view": "default", "comment": "lab",
+			"extattrs": map[string]interface{}{
+				"Site":  map[string]interface{}{"value": "Lab"},
+				"Owner": map[string]interface{}{"value": "Bob"}}})
+
+		nw, err := objMgr.PatchNetwork(ref, ibclient.EA{"Site": "Prod"}, nil)
+		Expect(err).To(BeNil())
+		Expect(nw.Comment).To(Equal("lab"))
+		Expect(nw.Ea).To(Equal(ibclient.EA{"Site": "Prod"}))
+	})
+
+	It("should reject references of another type", func() {
+		_, err := ibclient.Patch(conn, "record:a/ZG5z:a.example.com/default", &ibclient.Nsgroup{})
+		Expect(err).To(MatchError("reference 'record:a/ZG5z:a.example.com/default' is not of type nsgroup"))
+	})
+})
@@ -0,0 +1,200 @@
+// Package prommetrics records the request metrics of an ibclient.Connector
+// and exposes them in the Prometheus text format on an http.Handler,
+// without depending on the Prometheus client library:
+//
+//	metrics := prommetrics.New()
+//	conn.SetMetrics(metrics)
+//	http.Handle("/metrics", metrics)
+//
+// The metrics are:
+//
+//	infoblox_wapi_requests_total{method,object_type,status}
+//	infoblox_wapi_request_duration_seconds{method,object_type} (histogram)
+//	infoblox_wapi_request_bytes_total{method,object_type}
+//	infoblox_wapi_response_bytes_total{method,object_type}
+//	infoblox_wapi_proxy_fallbacks_total{method,object_type}
+//
+// The status is "0" for the requests which got no response.
+package prommetrics
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets
+// of the request duration histogram.
+var DefaultBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Compile-time interface checks
+var (
+	_ ibclient.MetricsRecorder = new(Metrics)
+	_ http.Handler             = new(Metrics)
+)
+
+// Metrics is an ibclient.MetricsRecorder serving the metrics it recorded.
+type Metrics struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[objectKey]*histogram
+	reqBytes  map[objectKey]uint64
+	respBytes map[objectKey]uint64
+	fallbacks map[objectKey]uint64
+}
+
+type objectKey struct {
+	method     string
+	objectType string
+}
+
+type requestKey struct {
+	objectKey
+	status int
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// New returns metrics whose duration histogram has the given buckets,
+// DefaultBuckets when there are none.
+func New(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		buckets:   buckets,
+		requests:  map[requestKey]uint64{},
+		durations: map[objectKey]*histogram{},
+		reqBytes:  map[objectKey]uint64{},
+		respBytes: map[objectKey]uint64{},
+		fallbacks: map[objectKey]uint64{},
+	}
+}
+
+func (m *Metrics) ObserveRequest(r ibclient.RequestMetrics) {
+	key := objectKey{method: r.Method, objectType: r.ObjectType}
+	seconds := r.Duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{objectKey: key, status: r.Status}]++
+	h, ok := m.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.durations[key] = h
+	}
+	if i := sort.SearchFloat64s(m.buckets, seconds); i < len(m.buckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += seconds
+	m.reqBytes[key] += uint64(r.RequestBytes)
+	m.respBytes[key] += uint64(r.ResponseBytes)
+}
+
+func (m *Metrics) ObserveProxyFallback(method string, objectType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fallbacks[objectKey{method: method, objectType: objectType}]++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	out := bufio.NewWriter(w)
+	m.write(out)
+	out.Flush()
+}
+
+func (m *Metrics) write(out *bufio.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	header(out, "infoblox_wapi_requests_total", "counter", "WAPI requests by method, object type and HTTP status.")
+	requestKeys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		requestKeys = append(requestKeys, key)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		if requestKeys[i].objectKey != requestKeys[j].objectKey {
+			return requestKeys[i].objectKey.less(requestKeys[j].objectKey)
+		}
+		return requestKeys[i].status < requestKeys[j].status
+	})
+	for _, key := range requestKeys {
+		fmt.Fprintf(out, "infoblox_wapi_requests_total{%s,status=\"%d\"} %d\n", key.labels(), key.status, m.requests[key])
+	}
+
+	header(out, "infoblox_wapi_request_duration_seconds", "histogram", "Duration of the WAPI requests, including their retries.")
+	for _, key := range sortedKeys(m.durations) {
+		h := m.durations[key]
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(out, "infoblox_wapi_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				key.labels(), formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(out, "infoblox_wapi_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", key.labels(), h.count)
+		fmt.Fprintf(out, "infoblox_wapi_request_duration_seconds_sum{%s} %s\n", key.labels(), formatFloat(h.sum))
+		fmt.Fprintf(out, "infoblox_wapi_request_duration_seconds_count{%s} %d\n", key.labels(), h.count)
+	}
+
+	counter(out, "infoblox_wapi_request_bytes_total", "Bytes of the WAPI request bodies.", m.reqBytes)
+	counter(out, "infoblox_wapi_response_bytes_total", "Bytes of the WAPI response bodies.", m.respBytes)
+	counter(out, "infoblox_wapi_proxy_fallbacks_total", "WAPI requests sent again through the Grid Master.", m.fallbacks)
+}
+
+func header(out *bufio.Writer, name string, kind string, help string) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func counter(out *bufio.Writer, name string, help string, values map[objectKey]uint64) {
+	header(out, name, "counter", help)
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(out, "%s{%s} %d\n", name, key.labels(), values[key])
+	}
+}
+
+func sortedKeys[V any](values map[objectKey]V) []objectKey {
+	keys := make([]objectKey, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	return keys
+}
+
+func (k objectKey) less(other objectKey) bool {
+	if k.objectType != other.objectType {
+		return k.objectType < other.objectType
+	}
+	return k.method < other.method
+}
+
+func (k objectKey) labels() string {
+	return fmt.Sprintf("method=\"%s\",object_type=\"%s\"", escape(k.method), escape(k.objectType))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
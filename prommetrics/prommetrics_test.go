@@ -0,0 +1,82 @@
+package prommetrics_test
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"time"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/prommetrics"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prometheus metrics", func() {
+	var (
+		server  *fakewapi.Server
+		conn    *ibclient.Connector
+		metrics *prommetrics.Metrics
+	)
+
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(rec.Header().Get("Content-Type")).To(HavePrefix("text/plain; version=0.0.4"))
+		body, err := ioutil.ReadAll(rec.Body)
+		Expect(err).To(BeNil())
+		return string(body)
+	}
+
+	BeforeEach(func() {
+		server = fakewapi.NewServer()
+		DeferCleanup(server.Close)
+		hostCfg := ibclient.HostConfig{Scheme: "http", Host: server.Host(), Port: server.Port(), Version: server.Version}
+		var err error
+		conn, err = ibclient.NewConnector(hostCfg, ibclient.AuthConfig{}, ibclient.NewTransportConfig("false", 10, 1),
+			&ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+		Expect(err).To(BeNil())
+		metrics = prommetrics.New()
+		conn.SetMetrics(metrics)
+	})
+
+	It("should count the requests by method, object type and status", func() {
+		objMgr := ibclient.NewObjectManager(conn, "cmp", "tenant")
+		_, err := objMgr.CreateNetworkView("private", "", nil)
+		Expect(err).To(BeNil())
+		_, err = objMgr.CreateNetworkView("private", "", nil)
+		Expect(err).NotTo(BeNil())
+
+		text := scrape()
+		Expect(text).To(ContainSubstring("# TYPE infoblox_wapi_requests_total counter\n"))
+		Expect(text).To(ContainSubstring(`infoblox_wapi_requests_total{method="POST",object_type="networkview",status="201"} 1`))
+		Expect(text).To(ContainSubstring(`infoblox_wapi_requests_total{method="POST",object_type="networkview",status="400"} 1`))
+		Expect(text).To(ContainSubstring(`infoblox_wapi_request_duration_seconds_count{method="POST",object_type="networkview"} 2`))
+		Expect(text).To(ContainSubstring(`infoblox_wapi_request_duration_seconds_bucket{method="POST",object_type="networkview",le="+Inf"} 2`))
+		Expect(text).To(MatchRegexp(`infoblox_wapi_request_bytes_total\{method="POST",object_type="networkview"\} [1-9]`))
+		Expect(text).To(MatchRegexp(`infoblox_wapi_response_bytes_total\{method="POST",object_type="networkview"\} [1-9]`))
+	})
+
+	It("should count the fallbacks to the Grid Master", func() {
+		objMgr := ibclient.NewObjectManager(conn, "cmp", "tenant")
+		_, err := objMgr.GetNetworkView("missing")
+		Expect(err).NotTo(BeNil())
+
+		Expect(scrape()).To(ContainSubstring(`infoblox_wapi_proxy_fallbacks_total{method="GET",object_type="networkview"} 1`))
+	})
+
+	It("should fill the duration buckets", func() {
+		metrics = prommetrics.New(0.1, 1)
+		metrics.ObserveRequest(ibclient.RequestMetrics{Method: "GET", ObjectType: "record:a", Status: 200, Duration: 50 * time.Millisecond})
+		metrics.ObserveRequest(ibclient.RequestMetrics{Method: "GET", ObjectType: "record:a", Status: 200, Duration: 500 * time.Millisecond})
+		metrics.ObserveRequest(ibclient.RequestMetrics{Method: "GET", ObjectType: "record:a", Status: 0, Duration: 2 * time.Second})
+
+		text := scrape()
+		Expect(text).To(ContainSubstring(`infoblox_wapi_request_duration_seconds_bucket{method="GET",object_type="record:a",le="0.1"} 1`))
+		Expect(text).To(ContainSubstring(`infoblox_wapi_request_duration_seconds_bucket{method="GET",object_type="record:a",le="1"} 2`))
+		Expect(text).To(ContainSubstring(`infoblox_wapi_request_duration_seconds_bucket{method="GET",object_type="record:a",le="+Inf"} 3`))
+		Expect(text).To(ContainSubstring(`infoblox_wapi_request_duration_seconds_sum{method="GET",object_type="record:a"} 2.55`))
+		Expect(text).To(ContainSubstring(`infoblox_wapi_requests_total{method="GET",object_type="record:a",status="0"} 1`))
+	})
+})
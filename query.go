@@ -0,0 +1,273 @@
+package ibclient
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// WAPI search modifiers, which follow the name of the searched field.
+const (
+	SearchModifierRegex      = "~"
+	SearchModifierIgnoreCase = ":"
+	SearchModifierLessEq     = "<"
+	SearchModifierGreaterEq  = ">"
+	SearchModifierNot        = "!"
+)
+
+// Query builds the search arguments of a WAPI GET request:
+//
+//	qp, err := NewQuery(&RecordA{}).
+//		Field("name").Regex(`^web\d+\.example\.com$`).
+//		Field("view").Equals("default").
+//		EA("Site").IgnoreCase().Equals("paris", "london").
+//		Field("ttl").LessOrEqual(300).
+//		ReturnFieldsPlus("creation_time").
+//		Build()
+//
+// Several values given for a field match objects having any of them.
+// Field names are checked against the fields of the object given to
+// NewQuery, errors are reported by Build.
+type Query struct {
+	obj              IBObject
+	search           url.Values
+	returnFieldsPlus []string
+	forceProxy       bool
+	paging           int
+	err              error
+}
+
+// QueryField is a search argument of a Query being built. Its modifier
+// methods return it, the methods setting the values return the Query.
+type QueryField struct {
+	query      *Query
+	name       string
+	not        bool
+	regex      bool
+	ignoreCase bool
+}
+
+// NewQuery returns a Query for objects of the type of obj,
+// which may be nil to skip the field name checks.
+func NewQuery(obj IBObject) *Query {
+	return &Query{obj: obj, search: url.Values{}}
+}
+
+// Field starts a search argument on a field of the object.
+func (q *Query) Field(name string) *QueryField {
+	if q.err == nil && q.obj != nil && !isSearchableField(q.obj, name) {
+		q.err = fmt.Errorf("'%s' is not a field of %s objects", name, q.obj.ObjectType())
+	}
+	return &QueryField{query: q, name: name}
+}
+
+// EA starts a search argument on an extensible attribute.
+func (q *Query) EA(name string) *QueryField {
+	if q.err == nil && name == "" {
+		q.err = fmt.Errorf("the extensible attribute name is empty")
+	}
+	return &QueryField{query: q, name: "*" + name}
+}
+
+// ReturnFieldsPlus requests fields in addition to the WAPI default fields
+// and the object's return fields, through _return_fields+.
+func (q *Query) ReturnFieldsPlus(fields ...string) *Query {
+	for _, f := range fields {
+		if q.err == nil && q.obj != nil && !isSearchableField(q.obj, f) {
+			q.err = fmt.Errorf("'%s' is not a field of %s objects", f, q.obj.ObjectType())
+		}
+	}
+	q.returnFieldsPlus = append(q.returnFieldsPlus, fields...)
+	return q
+}
+
+// ProxyToGM sends the search to the Grid Master, with _proxy_search=GM.
+func (q *Query) ProxyToGM() *Query {
+	q.forceProxy = true
+	return q
+}
+
+// Paging fetches the results in pages of up to maxResults objects,
+// see QueryParams.SetPaging.
+func (q *Query) Paging(maxResults int) *Query {
+	if maxResults <= 0 {
+		maxResults = DefaultPageSize
+	}
+	q.paging = maxResults
+	return q
+}
+
+// Build returns the query parameters to pass to GetObject,
+// or the first error met while building the query.
+func (q *Query) Build() (*QueryParams, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	qp := NewQueryParams(q.forceProxy, nil)
+	qp.search = url.Values{}
+	for k, v := range q.search {
+		qp.search[k] = append([]string(nil), v...)
+	}
+	qp.returnFieldsPlus = append([]string(nil), q.returnFieldsPlus...)
+	if q.paging > 0 {
+		qp.SetPaging(q.paging)
+	}
+	return qp, nil
+}
+
+// Not negates the search argument.
+func (f *QueryField) Not() *QueryField {
+	f.not = true
+	return f
+}
+
+// IgnoreCase makes Equals and Regex case-insensitive.
+func (f *QueryField) IgnoreCase() *QueryField {
+	f.ignoreCase = true
+	return f
+}
+
+// Equals matches objects whose field has one of the given values.
+func (f *QueryField) Equals(values ...interface{}) *Query {
+	return f.add("", values)
+}
+
+// Regex matches objects whose field matches one of the given regular expressions.
+func (f *QueryField) Regex(patterns ...string) *Query {
+	f.regex = true
+	values := make([]interface{}, len(patterns))
+	for i, p := range patterns {
+		values[i] = p
+	}
+	return f.add("", values)
+}
+
+// LessOrEqual matches objects whose field is lower than or equal to value.
+func (f *QueryField) LessOrEqual(value interface{}) *Query {
+	return f.add(SearchModifierLessEq, []interface{}{value})
+}
+
+// GreaterOrEqual matches objects whose field is greater than or equal to value.
+func (f *QueryField) GreaterOrEqual(value interface{}) *Query {
+	return f.add(SearchModifierGreaterEq, []interface{}{value})
+}
+
+// add adds the search argument to the query, the comparison
+// modifiers can't be combined with the other ones.
+func (f *QueryField) add(comparison string, values []interface{}) *Query {
+	q := f.query
+	if q.err != nil {
+		return q
+	}
+	if len(values) == 0 {
+		q.err = fmt.Errorf("no value given to search '%s' with", f.name)
+		return q
+	}
+	if comparison != "" && (f.not || f.ignoreCase || f.regex) {
+		q.err = fmt.Errorf("the '%s' comparison of '%s' can't be negated or case-insensitive", comparison, f.name)
+		return q
+	}
+
+	key := f.name
+	if f.not {
+		key += SearchModifierNot
+	}
+	if f.regex {
+		key += SearchModifierRegex
+	}
+	if f.ignoreCase {
+		key += SearchModifierIgnoreCase
+	}
+	key += comparison
+
+	for _, v := range values {
+		q.search.Add(key, searchValue(v))
+	}
+	return q
+}
+
+// mergeFields returns the fields of a followed by those of b not in a
+func mergeFields(a []string, b []string) []string {
+	res := append([]string(nil), a...)
+	seen := make(map[string]bool, len(a))
+	for _, f := range a {
+		seen[f] = true
+	}
+	for _, f := range b {
+		if !seen[f] {
+			seen[f] = true
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// searchValue formats a value the way WAPI expects it in a search
+func searchValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case *string:
+		if val != nil {
+			return *val
+		}
+		return ""
+	case bool:
+		if val {
+			return "true"
+		}
+		return "false"
+	}
+	return fmt.Sprint(v)
+}
+
+// searchableFields caches the JSON field names of the object types
+var searchableFields sync.Map // reflect.Type -> map[string]bool
+
+// isSearchableField reports whether name is the JSON name of a field of obj,
+// or of a field of the structs it holds a list of: e.g. host records are
+// searched by the ipv4addr of their ipv4addrs.
+func isSearchableField(obj IBObject, name string) bool {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+
+	fields, ok := searchableFields.Load(t)
+	if !ok {
+		names := make(map[string]bool)
+		collectJSONFields(t, names, true)
+		fields, _ = searchableFields.LoadOrStore(t, names)
+	}
+	return fields.(map[string]bool)[name]
+}
+
+func collectJSONFields(t reflect.Type, names map[string]bool, nested bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if tag == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectJSONFields(field.Type, names, nested)
+			continue
+		}
+		if tag != "" {
+			names[tag] = true
+		}
+
+		elem := field.Type
+		for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Slice {
+			elem = elem.Elem()
+		}
+		if nested && elem.Kind() == reflect.Struct && field.Type.Kind() == reflect.Slice {
+			collectJSONFields(elem, names, false)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package ibclient
+
+import (
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Query", func() {
+	hostCfg := HostConfig{Host: "172.22.18.66", Version: "2.12.1", Port: "443"}
+	wrb := WapiRequestBuilder{hostCfg: hostCfg}
+
+	buildQuery := func(obj IBObject, qp *QueryParams) url.Values {
+		u, err := url.Parse(wrb.BuildUrl(GET, obj.ObjectType(), "", obj.ReturnFields(), qp))
+		Expect(err).To(BeNil())
+		return u.Query()
+	}
+
+	It("should compile search modifiers into the URL", func() {
+		qp, err := NewQuery(&RecordA{}).
+			Field("name").Regex(`^web\d+`).
+			Field("view").Equals("default").
+			Field("comment").Not().IgnoreCase().Equals("temp").
+			Field("ttl").LessOrEqual(300).
+			Field("ttl").GreaterOrEqual(60).
+			Field("use_ttl").Equals(true).
+			EA("Site").IgnoreCase().Regex("^par", "^lon").
+			Build()
+		Expect(err).To(BeNil())
+
+		vals := buildQuery(NewEmptyRecordA(), qp)
+		Expect(vals["name~"]).To(Equal([]string{`^web\d+`}))
+		Expect(vals["view"]).To(Equal([]string{"default"}))
+		Expect(vals["comment!:"]).To(Equal([]string{"temp"}))
+		Expect(vals["ttl<"]).To(Equal([]string{"300"}))
+		Expect(vals["ttl>"]).To(Equal([]string{"60"}))
+		Expect(vals["use_ttl"]).To(Equal([]string{"true"}))
+		Expect(vals["*Site~:"]).To(Equal([]string{"^par", "^lon"}))
+		Expect(vals.Get("_return_fields")).To(Equal("extattrs,ipv4addr,name,view,zone,comment,ttl,use_ttl"))
+	})
+
+	It("should keep several values of a field, commas included", func() {
+		qp, err := NewQuery(nil).Field("name").Equals("a,b", "c").Build()
+		Expect(err).To(BeNil())
+		Expect(buildQuery(NewEmptyNetworkView(), qp)["name"]).To(Equal([]string{"a,b", "c"}))
+	})
+
+	It("should request additional fields with _return_fields+", func() {
+		qp, err := NewQuery(&RecordA{}).ReturnFieldsPlus("creation_time", "name").Build()
+		Expect(err).To(BeNil())
+
+		vals := buildQuery(NewEmptyRecordA(), qp)
+		Expect(vals).NotTo(HaveKey("_return_fields"))
+		Expect(vals.Get("_return_fields+")).To(Equal("extattrs,ipv4addr,name,view,zone,comment,ttl,use_ttl,creation_time"))
+	})
+
+	It("should set the proxy and paging arguments", func() {
+		qp, err := NewQuery(nil).ProxyToGM().Paging(50).Build()
+		Expect(err).To(BeNil())
+
+		vals := buildQuery(NewEmptyNetworkView(), qp)
+		Expect(vals.Get("_proxy_search")).To(Equal("GM"))
+		Expect(vals.Get("_paging")).To(Equal("1"))
+		Expect(vals.Get("_max_results")).To(Equal("50"))
+	})
+
+	It("should accept the fields of the sub-objects of a type", func() {
+		_, err := NewQuery(&HostRecord{}).Field("ipv4addr").Equals("10.0.0.1").Build()
+		Expect(err).To(BeNil())
+	})
+
+	It("should reject unknown fields", func() {
+		_, err := NewQuery(&RecordA{}).Field("nmae").Equals("a").Build()
+		Expect(err).To(MatchError("'nmae' is not a field of record:a objects"))
+		_, err = NewQuery(&RecordA{}).ReturnFieldsPlus("nmae").Build()
+		Expect(err).To(MatchError("'nmae' is not a field of record:a objects"))
+	})
+
+	It("should reject invalid modifier combinations and missing values", func() {
+		_, err := NewQuery(&RecordA{}).Field("ttl").Not().LessOrEqual(300).Build()
+		Expect(err).To(MatchError(ContainSubstring("can't be negated or case-insensitive")))
+		_, err = NewQuery(&RecordA{}).Field("name").Equals().Build()
+		Expect(err).To(MatchError("no value given to search 'name' with"))
+		_, err = NewQuery(&RecordA{}).EA("").Equals("x").Build()
+		Expect(err).To(MatchError("the extensible attribute name is empty"))
+	})
+})
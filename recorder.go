@@ -0,0 +1,280 @@
+package ibclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultScrubbedFields are the JSON fields whose values are replaced
+// by ScrubbedValue before interactions are saved to a fixture file.
+var DefaultScrubbedFields = []string{
+	"password",
+	"secret",
+	"shared_secret",
+	"token",
+	"tsig_key",
+}
+
+const ScrubbedValue = "REDACTED"
+
+// Interaction is a WAPI request and its response, as saved in fixture files.
+// Response holds JSON responses, ResponseText holds any other one. Err holds
+// the error of requests which failed without a WAPI response.
+type Interaction struct {
+	Method       string          `json:"method"`
+	Object       string          `json:"object"`
+	Query        string          `json:"query,omitempty"`
+	RequestBody  json.RawMessage `json:"request_body,omitempty"`
+	StatusCode   int             `json:"status_code,omitempty"`
+	Status       string          `json:"status,omitempty"`
+	Response     json.RawMessage `json:"response,omitempty"`
+	ResponseText string          `json:"response_text,omitempty"`
+	Err          string          `json:"error,omitempty"`
+}
+
+type fixture struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// interactionKey identifies the interactions a request can be replayed from
+func interactionKey(method string, object string, query string) string {
+	return method + " " + object + "?" + query
+}
+
+// normalizeQuery sorts the query arguments and their values
+func normalizeQuery(rawQuery string) string {
+	vals, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	for _, v := range vals {
+		sort.Strings(v)
+	}
+	return vals.Encode()
+}
+
+// RecordingHttpRequestor is an HttpRequestor which sends requests through
+// another HttpRequestor and records them, to be saved to a fixture file
+// and served back by a ReplayHttpRequestor.
+type RecordingHttpRequestor struct {
+	requestor HttpRequestor
+	path      string
+
+	// ScrubbedFields are the JSON fields to redact, DefaultScrubbedFields by default
+	ScrubbedFields []string
+
+	mu           sync.Mutex
+	interactions []*Interaction
+}
+
+func NewRecordingHttpRequestor(requestor HttpRequestor, path string) *RecordingHttpRequestor {
+	return &RecordingHttpRequestor{
+		requestor:      requestor,
+		path:           path,
+		ScrubbedFields: DefaultScrubbedFields,
+	}
+}
+
+func (rr *RecordingHttpRequestor) Init(authCfg AuthConfig, trCfg TransportConfig) {
+	rr.requestor.Init(authCfg, trCfg)
+}
+
+func (rr *RecordingHttpRequestor) InitWithError(authCfg AuthConfig, trCfg TransportConfig) error {
+	return initRequestor(rr.requestor, authCfg, trCfg)
+}
+
+func (rr *RecordingHttpRequestor) SendRequest(req *http.Request) ([]byte, error) {
+	var reqBody []byte
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = ioutil.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	res, err := rr.requestor.SendRequest(req)
+
+	it := &Interaction{
+		Method:      req.Method,
+		Object:      wapiObjectFromURL(req.URL),
+		Query:       normalizeQuery(req.URL.RawQuery),
+		RequestBody: rr.scrub(reqBody),
+	}
+	respBody := res
+	if err != nil {
+		if wapiErr, ok := asWapiError(err); ok {
+			it.StatusCode = wapiErr.StatusCode
+			it.Status = wapiErr.Status
+			respBody = wapiErr.Body
+		} else {
+			it.Err = err.Error()
+		}
+	} else {
+		it.StatusCode = http.StatusOK
+	}
+	if scrubbed := rr.scrub(respBody); scrubbed != nil {
+		it.Response = scrubbed
+	} else {
+		it.ResponseText = string(respBody)
+	}
+
+	rr.mu.Lock()
+	rr.interactions = append(rr.interactions, it)
+	rr.mu.Unlock()
+
+	return res, err
+}
+
+// Interactions returns the interactions recorded so far
+func (rr *RecordingHttpRequestor) Interactions() []*Interaction {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return append([]*Interaction(nil), rr.interactions...)
+}
+
+// Save writes the recorded interactions to the fixture file.
+func (rr *RecordingHttpRequestor) Save() error {
+	data, err := json.MarshalIndent(fixture{Interactions: rr.Interactions()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(rr.path, data, 0644)
+}
+
+// scrub redacts the scrubbed fields of a JSON document,
+// it returns nil if data is not JSON.
+func (rr *RecordingHttpRequestor) scrub(data []byte) json.RawMessage {
+	return scrubJSON(data, rr.ScrubbedFields)
+}
+
+// scrubJSON returns data with the values of the given fields redacted,
+// nil when it isn't JSON
+func scrubJSON(data []byte, fields []string) json.RawMessage {
+	if len(bytes.TrimSpace(data)) == 0 || !json.Valid(data) {
+		return nil
+	}
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil
+	}
+	scrubbed, err := json.Marshal(scrubValue(doc, fields))
+	if err != nil {
+		return nil
+	}
+	return scrubbed
+}
+
+func scrubValue(v interface{}, fields []string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if isScrubbedField(k, fields) {
+				val[k] = ScrubbedValue
+			} else {
+				val[k] = scrubValue(item, fields)
+			}
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = scrubValue(item, fields)
+		}
+	}
+	return v
+}
+
+func isScrubbedField(name string, fields []string) bool {
+	for _, f := range fields {
+		if strings.EqualFold(name, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplayHttpRequestor is an HttpRequestor which serves the responses of a
+// fixture file instead of sending requests. Requests are matched on method,
+// object path and normalized query string; identical requests are served
+// in the order they were recorded.
+type ReplayHttpRequestor struct {
+	mu           sync.Mutex
+	interactions map[string][]*Interaction
+}
+
+func NewReplayHttpRequestor(path string) (*ReplayHttpRequestor, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f fixture
+	if err = json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("cannot load fixture file '%s': %s", path, err)
+	}
+
+	rr := &ReplayHttpRequestor{interactions: make(map[string][]*Interaction)}
+	for _, it := range f.Interactions {
+		key := interactionKey(it.Method, it.Object, normalizeQuery(it.Query))
+		rr.interactions[key] = append(rr.interactions[key], it)
+	}
+	return rr, nil
+}
+
+func (rr *ReplayHttpRequestor) Init(authCfg AuthConfig, trCfg TransportConfig) {}
+
+func (rr *ReplayHttpRequestor) SendRequest(req *http.Request) ([]byte, error) {
+	key := interactionKey(req.Method, wapiObjectFromURL(req.URL), normalizeQuery(req.URL.RawQuery))
+
+	rr.mu.Lock()
+	queue := rr.interactions[key]
+	if len(queue) == 0 {
+		rr.mu.Unlock()
+		return nil, fmt.Errorf("no recorded interaction for %s", key)
+	}
+	it := queue[0]
+	rr.interactions[key] = queue[1:]
+	rr.mu.Unlock()
+
+	body := []byte(it.ResponseText)
+	if it.Response != nil {
+		// fixture files are indented for readability
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, it.Response); err != nil {
+			return nil, err
+		}
+		body = buf.Bytes()
+	}
+
+	if it.Err != "" {
+		return nil, errors.New(it.Err)
+	}
+	if it.StatusCode != http.StatusOK && it.StatusCode != http.StatusCreated {
+		resp := &http.Response{
+			StatusCode: it.StatusCode,
+			Status:     it.Status,
+			Body:       ioutil.NopCloser(bytes.NewReader(body)),
+			Request:    req,
+		}
+		return nil, getHTTPResponseError(resp)
+	}
+	return body, nil
+}
+
+// Remaining returns the number of recorded interactions not served yet.
+func (rr *ReplayHttpRequestor) Remaining() int {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	n := 0
+	for _, queue := range rr.interactions {
+		n += len(queue)
+	}
+	return n
+}
@@ -0,0 +1,134 @@
+package ibclient
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+
+	"github.com/infobloxopen/infoblox-go-client/v2/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Record and replay", func() {
+	netviewRef := "networkview/ZG5zLm5ldHdvcmtfdmlldyQyMw:private-view/false"
+	userRef := "adminuser/b25lLmFkbWluJGpvaG4:john"
+
+	var (
+		server  *httptest.Server
+		fixture string
+	)
+
+	BeforeEach(func() {
+		fixture = filepath.Join(GinkgoT().TempDir(), "fixture.json")
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == "GET" && r.URL.Path == "/wapi/v2.12.1/networkview":
+				fmt.Fprintf(w, `[{"_ref":"%s","name":"private-view","comment":"first"}]`, netviewRef)
+			case r.Method == "POST" && r.URL.Path == "/wapi/v2.12.1/networkview":
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"Error": "AdmConDataError: None (IBDataConflictError: IB.Data.Conflict:Duplicate object)", "code": "Client.Ibap.Data.Conflict", "text": "Duplicate object"}`)
+			case r.Method == "POST" && r.URL.Path == "/wapi/v2.12.1/adminuser":
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, `"%s"`, userRef)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, "Not Found")
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newConnector := func(hr HttpRequestor) *Connector {
+		u, _ := http.NewRequest("GET", server.URL, nil)
+		hostCfg := HostConfig{Scheme: "http", Host: u.URL.Hostname(), Port: u.URL.Port(), Version: "2.12.1"}
+		authCfg := AuthConfig{Username: "admin", Password: "infoblox"}
+		wrb, _ := NewWapiRequestBuilder(hostCfg, authCfg)
+
+		OrigValidateConnector := ValidateConnector
+		ValidateConnector = MockValidateConnector
+		defer func() { ValidateConnector = OrigValidateConnector }()
+		conn, err := NewConnector(hostCfg, authCfg, NewTransportConfig("false", 10, 1), wrb, hr)
+		Expect(err).To(BeNil())
+		return conn
+	}
+
+	exercise := func(conn *Connector) ([]NetworkView, string, error, error) {
+		var netviews []NetworkView
+		qp := NewQueryParams(false, map[string]string{"name": "private-view", "comment": "first"})
+		err := conn.GetObject(NewEmptyNetworkView(), "", qp, &netviews)
+		Expect(err).To(BeNil())
+
+		_, createErr := conn.CreateObject(NewNetworkView("private-view", "", nil, ""))
+
+		user := &Adminuser{Name: utils.StringPtr("john"), Password: utils.StringPtr("s3cr3t!")}
+		ref, _ := conn.CreateObject(user)
+
+		var res []NetworkView
+		notFoundErr := conn.GetObject(nil, "networkview/missing", nil, &res)
+		return netviews, ref, createErr, notFoundErr
+	}
+
+	It("should replay recorded responses and errors", func() {
+		recorder := NewRecordingHttpRequestor(&WapiHttpRequestor{}, fixture)
+		netviews, ref, createErr, notFoundErr := exercise(newConnector(recorder))
+		Expect(netviews).To(HaveLen(1))
+		Expect(ref).To(Equal(userRef))
+		Expect(IsConflict(createErr)).To(BeTrue())
+		Expect(IsNotFound(notFoundErr)).To(BeTrue())
+		Expect(recorder.Save()).To(Succeed())
+
+		server.Close()
+
+		replayer, err := NewReplayHttpRequestor(fixture)
+		Expect(err).To(BeNil())
+		rNetviews, rRef, rCreateErr, rNotFoundErr := exercise(newConnector(replayer))
+		Expect(rNetviews).To(Equal(netviews))
+		Expect(rRef).To(Equal(ref))
+		var wapiErr, rWapiErr *WapiError
+		Expect(errors.As(createErr, &wapiErr)).To(BeTrue())
+		Expect(errors.As(rCreateErr, &rWapiErr)).To(BeTrue())
+		Expect(rWapiErr.StatusCode).To(Equal(wapiErr.StatusCode))
+		Expect(rWapiErr.Method).To(Equal(wapiErr.Method))
+		Expect(rWapiErr.Object).To(Equal(wapiErr.Object))
+		Expect(rWapiErr.Code).To(Equal(wapiErr.Code))
+		Expect(rWapiErr.Text).To(Equal(wapiErr.Text))
+		Expect(rNotFoundErr.Error()).To(Equal(notFoundErr.Error()))
+		Expect(IsNotFound(rNotFoundErr)).To(BeTrue())
+		Expect(replayer.Remaining()).To(BeZero())
+	})
+
+	It("should scrub credentials from the fixture file", func() {
+		recorder := NewRecordingHttpRequestor(&WapiHttpRequestor{}, fixture)
+		exercise(newConnector(recorder))
+		Expect(recorder.Save()).To(Succeed())
+
+		data, err := ioutil.ReadFile(fixture)
+		Expect(err).To(BeNil())
+		Expect(string(data)).NotTo(ContainSubstring("s3cr3t!"))
+		Expect(string(data)).NotTo(ContainSubstring("infoblox"))
+		Expect(string(data)).To(ContainSubstring(`"password": "REDACTED"`))
+	})
+
+	It("should match queries regardless of argument order", func() {
+		Expect(normalizeQuery("name=a&_return_fields=name%2Ccomment&view=b&view=a")).
+			To(Equal(normalizeQuery("view=a&_return_fields=name%2Ccomment&view=b&name=a")))
+	})
+
+	It("should fail requests which were not recorded", func() {
+		recorder := NewRecordingHttpRequestor(&WapiHttpRequestor{}, fixture)
+		Expect(recorder.Save()).To(Succeed())
+		replayer, err := NewReplayHttpRequestor(fixture)
+		Expect(err).To(BeNil())
+
+		_, err = newConnector(replayer).DeleteObject(netviewRef)
+		Expect(err).To(MatchError(ContainSubstring("no recorded interaction for DELETE " + netviewRef)))
+	})
+})
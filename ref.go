@@ -0,0 +1,112 @@
+package ibclient
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Ref is a parsed WAPI object reference, e.g.
+// network/ZG5zLm5ldHdvcmskODkuMC4wLjAvMjQvMjU:89.0.0.0/24/global_view
+// has the object type network, the ID ZG5zLm5ldHdvcmskODkuMC4wLjAvMjQvMjU,
+// the name 89.0.0.0/24 and the view global_view.
+//
+// The name and the view are only meant to be displayed: WAPI may change
+// them. The view is the last part of the name when it has several, which
+// is the DNS view or the network view of most objects, and the is_default
+// flag of network views. Name and View are unescaped, e.g. the name of
+// ipv6network/ZG5z:2001%3Adb8%3A%3A/64/default is 2001:db8::/64.
+type Ref struct {
+	ObjectType string
+	ID         string
+	Name       string
+	View       string
+
+	// raw is the parsed reference, which String returns unchanged
+	raw string
+}
+
+// ParseRef parses a WAPI object reference.
+func ParseRef(ref string) (Ref, error) {
+	i := strings.Index(ref, "/")
+	if i <= 0 || i == len(ref)-1 {
+		return Ref{}, fmt.Errorf("invalid reference '%s'", ref)
+	}
+	res := Ref{ObjectType: ref[:i], ID: ref[i+1:], raw: ref}
+
+	if j := strings.Index(res.ID, ":"); j >= 0 {
+		name := res.ID[j+1:]
+		res.ID = res.ID[:j]
+		if k := strings.LastIndex(name, "/"); k >= 0 {
+			res.View, name = name[k+1:], name[:k]
+		}
+		var err error
+		if res.Name, err = url.PathUnescape(name); err != nil {
+			return Ref{}, fmt.Errorf("invalid name in reference '%s': %s", ref, err)
+		}
+		if res.View, err = url.PathUnescape(res.View); err != nil {
+			return Ref{}, fmt.Errorf("invalid view in reference '%s': %s", ref, err)
+		}
+	}
+	if res.ID == "" {
+		return Ref{}, fmt.Errorf("invalid reference '%s'", ref)
+	}
+	return res, nil
+}
+
+// String returns the reference as WAPI expects it: the parsed reference
+// when the Ref is unchanged, otherwise the fields with ':' and '%' escaped
+// in the name and the view.
+func (r Ref) String() string {
+	if r.raw != "" {
+		if parsed, err := ParseRef(r.raw); err == nil && parsed == r {
+			return r.raw
+		}
+	}
+	res := r.ObjectType + "/" + r.ID
+	if r.Name != "" || r.View != "" {
+		res += ":" + escapeRefPart(r.Name)
+		if r.View != "" {
+			res += "/" + escapeRefPart(r.View)
+		}
+	}
+	return res
+}
+
+func escapeRefPart(s string) string {
+	return strings.NewReplacer("%", "%25", ":", "%3A").Replace(s)
+}
+
+// DecodeID returns the decoded ID of the reference, e.g. dns.network$89.0.0.0/24/25.
+// Its format is internal to NIOS and may change between versions.
+func (r Ref) DecodeID() (string, error) {
+	id := strings.TrimRight(r.ID, "=")
+	decoded, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		if decoded, err = base64.RawStdEncoding.DecodeString(id); err != nil {
+			return "", fmt.Errorf("cannot decode the ID of the reference '%s': %s", r, err)
+		}
+	}
+	return string(decoded), nil
+}
+
+// IsIPv6 reports whether the reference is to an IPv6 object, e.g. ipv6network.
+func (r Ref) IsIPv6() bool {
+	return strings.HasPrefix(r.ObjectType, "ipv6")
+}
+
+// NewObject returns an empty object of the reference's type, for the types
+// whose struct serves both address families: a *Network for network and
+// ipv6network references, a *NetworkContainer or a *FixedAddress.
+func (r Ref) NewObject() (IBObject, error) {
+	switch r.ObjectType {
+	case "network", "ipv6network":
+		return NewNetwork("", "", r.IsIPv6(), "", nil), nil
+	case "networkcontainer", "ipv6networkcontainer":
+		return NewNetworkContainer("", "", r.IsIPv6(), "", nil), nil
+	case "fixedaddress", "ipv6fixedaddress":
+		return NewEmptyFixedAddress(r.IsIPv6()), nil
+	}
+	return nil, fmt.Errorf("no object is known for the references of type %s", r.ObjectType)
+}
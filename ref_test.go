@@ -0,0 +1,82 @@
+package ibclient
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ref", func() {
+	DescribeTable("ParseRef",
+		func(ref string, expected Ref) {
+			r, err := ParseRef(ref)
+			Expect(err).To(BeNil())
+			expected.raw = ref
+			Expect(r).To(Equal(expected))
+			Expect(r.String()).To(Equal(ref))
+		},
+		Entry("network", "network/ZG5zLm5ldHdvcmskODkuMC4wLjAvMjQvMjU:89.0.0.0/24/global_view",
+			Ref{ObjectType: "network", ID: "ZG5zLm5ldHdvcmskODkuMC4wLjAvMjQvMjU", Name: "89.0.0.0/24", View: "global_view"}),
+		Entry("ipv6network", "ipv6network/ZG5zLm5ldHdvcmskODkuMC4wLjAvMjQvMjU:2001%3Adb8%3Aabcd%3A14%3A%3A/64/default",
+			Ref{ObjectType: "ipv6network", ID: "ZG5zLm5ldHdvcmskODkuMC4wLjAvMjQvMjU", Name: "2001:db8:abcd:14::/64", View: "default"}),
+		Entry("record:a", "record:a/ZG5zLmJpbmRfYSQuX2RlZmF1bHQuY29tLnRlc3QsYSwxLjEuMS4x:a.test.com/default",
+			Ref{ObjectType: "record:a", ID: "ZG5zLmJpbmRfYSQuX2RlZmF1bHQuY29tLnRlc3QsYSwxLjEuMS4x", Name: "a.test.com", View: "default"}),
+		Entry("networkview", "networkview/ZG5zLm5ldHdvcmtfdmlldyQyMw:global_view/false",
+			Ref{ObjectType: "networkview", ID: "ZG5zLm5ldHdvcmtfdmlldyQyMw", Name: "global_view", View: "false"}),
+		Entry("without view", "grid/b25lLmNsdXN0ZXIkMA:Infoblox",
+			Ref{ObjectType: "grid", ID: "b25lLmNsdXN0ZXIkMA", Name: "Infoblox"}),
+		Entry("without name", "network/ZG5zLm5ldHdvcmtfdmlldyQyMw",
+			Ref{ObjectType: "network", ID: "ZG5zLm5ldHdvcmtfdmlldyQyMw"}),
+	)
+
+	It("should reject invalid references", func() {
+		for _, ref := range []string{"", "network", "/ZG5z", "network/", "network/:name/view", "record:a/ZG5z:a%zz/default"} {
+			_, err := ParseRef(ref)
+			Expect(err).NotTo(BeNil(), ref)
+		}
+	})
+
+	It("should build references from their fields", func() {
+		r, err := ParseRef("ipv6fixedaddress/ZG5zLmZpeGVkX2FkZHJlc3MkMjAwMTpkYjg6OjE:2001%3Adb8%3A%3A1/default")
+		Expect(err).To(BeNil())
+		r.View = "other view"
+		Expect(r.String()).To(Equal("ipv6fixedaddress/ZG5zLmZpeGVkX2FkZHJlc3MkMjAwMTpkYjg6OjE:2001%3Adb8%3A%3A1/other view"))
+
+		r = Ref{ObjectType: "record:a", ID: "ZG5z"}
+		Expect(r.String()).To(Equal("record:a/ZG5z"))
+	})
+
+	It("should decode the ID", func() {
+		r, err := ParseRef("network/ZG5zLm5ldHdvcmskODkuMC4wLjAvMjQvMjU:89.0.0.0/24/global_view")
+		Expect(err).To(BeNil())
+		Expect(r.DecodeID()).To(Equal("dns.network$89.0.0.0/24/25"))
+
+		_, err = Ref{ObjectType: "network", ID: "not*base64"}.DecodeID()
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should pick the object of the reference's address family", func() {
+		for ref, expected := range map[string]IBObject{
+			"network/ZG5z:10.0.0.0/24/default":                      NewNetwork("", "", false, "", nil),
+			"ipv6network/ZG5z:2001%3Adb8%3A%3A/64/default":          NewNetwork("", "", true, "", nil),
+			"networkcontainer/ZG5z:10.0.0.0/16/default":             NewNetworkContainer("", "", false, "", nil),
+			"ipv6networkcontainer/ZG5z:2001%3Adb8%3A%3A/48/default": NewNetworkContainer("", "", true, "", nil),
+			"fixedaddress/ZG5z:10.0.0.1/default":                    NewEmptyFixedAddress(false),
+			"ipv6fixedaddress/ZG5z:2001%3Adb8%3A%3A1/default":       NewEmptyFixedAddress(true),
+		} {
+			r, err := ParseRef(ref)
+			Expect(err).To(BeNil())
+			Expect(r.NewObject()).To(Equal(expected), ref)
+		}
+
+		r, err := ParseRef("record:a/ZG5z:a.test.com/default")
+		Expect(err).To(BeNil())
+		_, err = r.NewObject()
+		Expect(err).To(MatchError("no object is known for the references of type record:a"))
+	})
+
+	It("should extract the addresses of fixed address references", func() {
+		Expect(GetIPAddressFromRef("fixedaddress/ZG5zLmJpbmRfY25h:12.0.10.1/external")).To(Equal("12.0.10.1"))
+		Expect(GetIPAddressFromRef("ipv6fixedaddress/ZG5zLmJpbmRfY25h:2001%3Adb8%3A%3A1/external")).To(Equal("2001:db8::1"))
+		Expect(GetIPAddressFromRef("record:a/ZG5zLmJpbmRfY25h:12.0.10.1/external")).To(BeEmpty())
+	})
+})
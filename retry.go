@@ -0,0 +1,220 @@
+package ibclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy decides whether a failed WAPI request is attempted again.
+// ShouldRetry is called after every failed attempt with the number of
+// attempts made so far and either the non-2xx response or the error
+// returned by the HTTP client. It returns the delay before the next
+// attempt and whether there should be one at all.
+type RetryPolicy interface {
+	ShouldRetry(req *http.Request, attempt int, resp *http.Response, err error) (time.Duration, bool)
+}
+
+// ExponentialBackoff is a RetryPolicy which retries with exponentially
+// growing, randomized delays. POST requests are never retried on network
+// errors, 502 or 504 responses, as the grid may have already created the
+// object: only the responses telling that it didn't process the request,
+// e.g. 429 and 503, are retried for them.
+type ExponentialBackoff struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	// Jitter randomizes each delay by up to this fraction of it, 0 <= Jitter <= 1
+	Jitter float64
+
+	RetryableStatusCodes []int
+	RetryNetworkErrors   bool
+	// HonorRetryAfter makes the Retry-After response header,
+	// when present, take precedence over the computed delay.
+	// The delay it gives is capped at MaxInterval.
+	HonorRetryAfter bool
+}
+
+// NewExponentialBackoff returns an ExponentialBackoff making up to maxAttempts
+// attempts, which retries network errors and responses telling that the
+// grid is overloaded or temporarily unavailable.
+func NewExponentialBackoff(maxAttempts int) *ExponentialBackoff {
+	return &ExponentialBackoff{
+		MaxAttempts:     maxAttempts,
+		InitialInterval: 500 * time.Millisecond,
+		MaxInterval:     30 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryNetworkErrors: true,
+		HonorRetryAfter:    true,
+	}
+}
+
+func (b *ExponentialBackoff) ShouldRetry(req *http.Request, attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= b.MaxAttempts {
+		return 0, false
+	}
+
+	if err != nil {
+		if !b.RetryNetworkErrors || req.Method == CREATE.toMethod() || !isRetryableNetworkError(err) {
+			return 0, false
+		}
+		return b.backoff(attempt), true
+	}
+
+	if resp == nil || !b.isRetryableStatus(resp.StatusCode) {
+		return 0, false
+	}
+	if req.Method == CREATE.toMethod() && !isUnprocessedStatus(resp.StatusCode) {
+		return 0, false
+	}
+	if b.HonorRetryAfter {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if b.MaxInterval > 0 && delay > b.MaxInterval {
+				delay = b.MaxInterval
+			}
+			return delay, true
+		}
+	}
+	return b.backoff(attempt), true
+}
+
+func (b *ExponentialBackoff) isRetryableStatus(code int) bool {
+	for _, c := range b.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnprocessedStatus reports whether a response with the given status
+// code tells that the grid didn't process the request, unlike a gateway
+// error which may come after the grid processed it
+func isUnprocessedStatus(code int) bool {
+	return code != http.StatusBadGateway && code != http.StatusGatewayTimeout
+}
+
+// backoff returns the delay after the given number of attempts
+func (b *ExponentialBackoff) backoff(attempt int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(b.InitialInterval) * math.Pow(multiplier, float64(attempt-1))
+	if b.MaxInterval > 0 && delay > float64(b.MaxInterval) {
+		delay = float64(b.MaxInterval)
+	}
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// isRetryableNetworkError reports whether err is a transient
+// connection problem, as opposed to a cancelled request or an error
+// which fails every attempt: an untrusted certificate, an unknown host
+// or a malformed URL.
+func isRetryableNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && (opErr.Op == "dial" || opErr.Op == "read") {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// parseRetryAfter parses a Retry-After header given either
+// in seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		delay := time.Until(t)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// sleepContext waits for the delay to pass or ctx to be done.
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// requestForAttempt returns the request to send for the given attempt,
+// a copy with a fresh body is needed for every attempt after the first one.
+func requestForAttempt(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("cannot retry a request with a non-rewindable body")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	attemptReq := req.Clone(req.Context())
+	attemptReq.Body = body
+	return attemptReq, nil
+}
+
+type noRetriesKey struct{}
+
+// withoutRetries returns ctx for requests which are sent once whatever
+// the retry policy, e.g. the health checks of the endpoints
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetriesKey{}, true)
+}
+
+// retriesDisabled reports whether req is sent once
+func retriesDisabled(req *http.Request) bool {
+	disabled, _ := req.Context().Value(noRetriesKey{}).(bool)
+	return disabled
+}
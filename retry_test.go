@@ -0,0 +1,287 @@
+package ibclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// proxyHttpRequestor fails every request without _proxy_search=GM with err.
+type proxyHttpRequestor struct {
+	err  error
+	urls []string
+}
+
+func (hr *proxyHttpRequestor) Init(authCfg AuthConfig, trCfg TransportConfig) {}
+
+func (hr *proxyHttpRequestor) SendRequest(req *http.Request) ([]byte, error) {
+	hr.urls = append(hr.urls, req.URL.String())
+	if req.URL.Query().Get("_proxy_search") != "GM" {
+		return nil, hr.err
+	}
+	return []byte(`"networkview/ZG5zLm5ldHdvcmtfdmlldyQyMw:global_view/false"`), nil
+}
+
+var _ = Describe("Retries", func() {
+	Describe("ExponentialBackoff", func() {
+		getReq, _ := http.NewRequest("GET", "https://grid/wapi/v2.12.1/network", nil)
+		postReq, _ := http.NewRequest("POST", "https://grid/wapi/v2.12.1/network", nil)
+		unavailable := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+
+		It("should grow the delay up to MaxInterval", func() {
+			b := NewExponentialBackoff(10)
+			b.Jitter = 0
+			b.InitialInterval = time.Second
+			b.MaxInterval = 5 * time.Second
+			Expect(b.backoff(1)).To(Equal(time.Second))
+			Expect(b.backoff(2)).To(Equal(2 * time.Second))
+			Expect(b.backoff(3)).To(Equal(4 * time.Second))
+			Expect(b.backoff(4)).To(Equal(5 * time.Second))
+		})
+
+		It("should keep the jittered delay within bounds", func() {
+			b := NewExponentialBackoff(10)
+			b.InitialInterval = time.Second
+			b.Jitter = 0.5
+			for i := 0; i < 100; i++ {
+				Expect(b.backoff(1)).To(BeNumerically(">=", 500*time.Millisecond))
+				Expect(b.backoff(1)).To(BeNumerically("<=", 1500*time.Millisecond))
+			}
+		})
+
+		It("should stop after MaxAttempts", func() {
+			b := NewExponentialBackoff(2)
+			_, retry := b.ShouldRetry(getReq, 1, unavailable, nil)
+			Expect(retry).To(BeTrue())
+			_, retry = b.ShouldRetry(getReq, 2, unavailable, nil)
+			Expect(retry).To(BeFalse())
+		})
+
+		It("should not retry validation errors", func() {
+			b := NewExponentialBackoff(3)
+			_, retry := b.ShouldRetry(getReq, 1, &http.Response{StatusCode: http.StatusBadRequest}, nil)
+			Expect(retry).To(BeFalse())
+		})
+
+		It("should honor Retry-After", func() {
+			b := NewExponentialBackoff(3)
+			resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"7"}}}
+			delay, retry := b.ShouldRetry(getReq, 1, resp, nil)
+			Expect(retry).To(BeTrue())
+			Expect(delay).To(Equal(7 * time.Second))
+
+			b.HonorRetryAfter = false
+			b.Jitter = 0
+			delay, _ = b.ShouldRetry(getReq, 1, resp, nil)
+			Expect(delay).To(Equal(b.InitialInterval))
+		})
+
+		It("should cap Retry-After at MaxInterval", func() {
+			b := NewExponentialBackoff(3)
+			b.MaxInterval = 5 * time.Second
+			resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": []string{"3600"}}}
+			delay, retry := b.ShouldRetry(getReq, 1, resp, nil)
+			Expect(retry).To(BeTrue())
+			Expect(delay).To(Equal(5 * time.Second))
+		})
+
+		It("should not retry gateway errors for POST requests", func() {
+			b := NewExponentialBackoff(3)
+			for _, code := range []int{http.StatusBadGateway, http.StatusGatewayTimeout} {
+				resp := &http.Response{StatusCode: code, Header: http.Header{}}
+				_, retry := b.ShouldRetry(getReq, 1, resp, nil)
+				Expect(retry).To(BeTrue())
+				_, retry = b.ShouldRetry(postReq, 1, resp, nil)
+				Expect(retry).To(BeFalse())
+			}
+			_, retry := b.ShouldRetry(postReq, 1, unavailable, nil)
+			Expect(retry).To(BeTrue())
+		})
+
+		It("should retry network errors for idempotent requests only", func() {
+			b := NewExponentialBackoff(3)
+			_, retry := b.ShouldRetry(getReq, 1, nil, syscall.ECONNREFUSED)
+			Expect(retry).To(BeTrue())
+			_, retry = b.ShouldRetry(postReq, 1, nil, syscall.ECONNREFUSED)
+			Expect(retry).To(BeFalse())
+			_, retry = b.ShouldRetry(getReq, 1, nil, errors.New("certificate signed by unknown authority"))
+			Expect(retry).To(BeFalse())
+		})
+
+		It("should retry dial, read and timeout errors only", func() {
+			b := NewExponentialBackoff(3)
+			urlErr := func(err error) error {
+				return &url.Error{Op: "Get", URL: "https://grid/wapi/v2.12.1/network", Err: err}
+			}
+			for _, err := range []error{
+				&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("no route to host")},
+				&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection timed out")},
+				&net.DNSError{Err: "server misbehaving", Name: "grid", IsTemporary: true},
+			} {
+				_, retry := b.ShouldRetry(getReq, 1, nil, urlErr(err))
+				Expect(retry).To(BeTrue(), err.Error())
+			}
+			for _, err := range []error{
+				&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}},
+				&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "grid", IsNotFound: true}},
+				&net.AddrError{Err: "missing port in address", Addr: "grid"},
+				errors.New("unsupported protocol scheme"),
+			} {
+				_, retry := b.ShouldRetry(getReq, 1, nil, urlErr(err))
+				Expect(retry).To(BeFalse(), err.Error())
+			}
+		})
+	})
+
+	Describe("WapiHttpRequestor", func() {
+		var (
+			server   *httptest.Server
+			statuses []int
+			bodies   []string
+		)
+
+		BeforeEach(func() {
+			bodies = nil
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				status := statuses[0]
+				statuses = statuses[1:]
+				w.WriteHeader(status)
+				fmt.Fprint(w, `"network/ZG5zLm5ldHdvcmskODkuMC4wLjAvMjQvMjU:89.0.0.0/24/global_view"`)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		newRequestor := func(policy RetryPolicy) *WapiHttpRequestor {
+			trCfg := NewTransportConfig("false", 10, 1)
+			trCfg.RetryPolicy = policy
+			whr := &WapiHttpRequestor{}
+			whr.Init(AuthConfig{}, trCfg)
+			return whr
+		}
+
+		It("should resend the body until the request succeeds", func() {
+			statuses = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusCreated}
+			policy := NewExponentialBackoff(3)
+			policy.InitialInterval = time.Millisecond
+			whr := newRequestor(policy)
+
+			req, _ := http.NewRequest("POST", server.URL+"/wapi/v2.12.1/network", strings.NewReader(`{"network":"89.0.0.0/24"}`))
+			res, err := whr.SendRequest(req)
+			Expect(err).To(BeNil())
+			Expect(string(res)).To(ContainSubstring("network/"))
+			Expect(bodies).To(Equal([]string{`{"network":"89.0.0.0/24"}`, `{"network":"89.0.0.0/24"}`, `{"network":"89.0.0.0/24"}`}))
+		})
+
+		It("should not retry certificate verification errors", func() {
+			var conns int32
+			tlsServer := httptest.NewUnstartedServer(server.Config.Handler)
+			tlsServer.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+			tlsServer.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt32(&conns, 1)
+				}
+			}
+			tlsServer.StartTLS()
+			defer tlsServer.Close()
+
+			policy := NewExponentialBackoff(3)
+			policy.InitialInterval = time.Millisecond
+			trCfg := NewTransportConfig("true", 10, 1)
+			trCfg.RetryPolicy = policy
+			whr := &WapiHttpRequestor{}
+			whr.Init(AuthConfig{}, trCfg)
+
+			req, _ := http.NewRequest("GET", tlsServer.URL+"/wapi/v2.12.1/network", nil)
+			_, err := whr.SendRequest(req)
+			var certErr *tls.CertificateVerificationError
+			Expect(errors.As(err, &certErr)).To(BeTrue())
+			Expect(atomic.LoadInt32(&conns)).To(Equal(int32(1)))
+			Expect(bodies).To(BeEmpty())
+		})
+
+		It("should return the last error when attempts are exhausted", func() {
+			statuses = []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}
+			policy := NewExponentialBackoff(2)
+			policy.InitialInterval = time.Millisecond
+			whr := newRequestor(policy)
+
+			req, _ := http.NewRequest("GET", server.URL+"/wapi/v2.12.1/network", nil)
+			_, err := whr.SendRequest(req)
+			var wapiErr *WapiError
+			Expect(errors.As(err, &wapiErr)).To(BeTrue())
+			Expect(wapiErr.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(statuses).To(BeEmpty())
+		})
+
+		It("should send requests once without a retry policy", func() {
+			statuses = []int{http.StatusServiceUnavailable, http.StatusOK}
+			whr := newRequestor(nil)
+
+			req, _ := http.NewRequest("GET", server.URL+"/wapi/v2.12.1/network", nil)
+			_, err := whr.SendRequest(req)
+			Expect(err).NotTo(BeNil())
+			Expect(statuses).To(HaveLen(1))
+		})
+	})
+
+	Describe("Grid Master proxy fallback", func() {
+		hostCfg := HostConfig{Host: "172.22.18.66", Version: "2.12.1", Port: "443"}
+		authCfg := AuthConfig{Username: "myname", Password: "mysecrete!"}
+
+		newConnector := func(hr HttpRequestor) *Connector {
+			wrb, _ := NewWapiRequestBuilder(hostCfg, authCfg)
+			OrigValidateConnector := ValidateConnector
+			ValidateConnector = MockValidateConnector
+			defer func() { ValidateConnector = OrigValidateConnector }()
+			conn, err := NewConnector(hostCfg, authCfg, NewTransportConfig("false", 10, 1), wrb, hr)
+			Expect(err).To(BeNil())
+			return conn
+		}
+
+		It("should proxy searches failing with a server error to the Grid Master", func() {
+			hr := &proxyHttpRequestor{err: &WapiError{StatusCode: http.StatusInternalServerError}}
+			conn := newConnector(hr)
+			_, err := conn.makeRequest(context.Background(), GET, NewEmptyNetworkView(), "", NewQueryParams(false, nil))
+			Expect(err).To(BeNil())
+			Expect(hr.urls).To(HaveLen(2))
+			Expect(hr.urls[1]).To(ContainSubstring("_proxy_search=GM"))
+		})
+
+		It("should not proxy validation errors", func() {
+			hr := &proxyHttpRequestor{err: &WapiError{StatusCode: http.StatusBadRequest}}
+			conn := newConnector(hr)
+			var res []NetworkView
+			err := conn.GetObject(NewEmptyNetworkView(), "", NewQueryParams(false, nil), &res)
+			Expect(err).To(Equal(hr.err))
+			Expect(hr.urls).To(HaveLen(1))
+		})
+
+		It("should not resend failed creates", func() {
+			hr := &proxyHttpRequestor{err: &WapiError{StatusCode: http.StatusInternalServerError}}
+			conn := newConnector(hr)
+			_, err := conn.CreateObject(NewNetworkView("private-view", "", nil, ""))
+			Expect(err).To(Equal(hr.err))
+			Expect(hr.urls).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,342 @@
+package ibclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// WapiSchema is the WAPI schema of a grid, as returned by ?_schema.
+type WapiSchema struct {
+	RequestedVersion  string   `json:"requested_version"`
+	SupportedObjects  []string `json:"supported_objects"`
+	SupportedVersions []string `json:"supported_versions"`
+}
+
+// ObjectSchema is the WAPI schema of an object type, as returned by ?_schema=<type>.
+type ObjectSchema struct {
+	Type         string        `json:"type"`
+	Version      string        `json:"version"`
+	Restrictions []string      `json:"restrictions"`
+	Fields       []FieldSchema `json:"fields"`
+}
+
+// FieldSchema describes a field of an object type. Supports holds the
+// operations the field supports: r(ead), w(rite), u(pdate), s(earch)
+// and d(elete). Function calls are fields with the "funccall" primitive.
+type FieldSchema struct {
+	Name          string   `json:"name"`
+	Type          []string `json:"type"`
+	Supports      string   `json:"supports"`
+	IsArray       bool     `json:"is_array"`
+	SearchableBy  string   `json:"searchable_by,omitempty"`
+	Standard      bool     `json:"standard_field,omitempty"`
+	WapiPrimitive string   `json:"wapi_primitive,omitempty"`
+}
+
+// SupportsOp reports whether the field supports an operation, e.g. 'u'.
+func (f *FieldSchema) SupportsOp(op byte) bool {
+	return strings.IndexByte(f.Supports, op) >= 0
+}
+
+// Field returns the schema of a field, functions excluded.
+func (s *ObjectSchema) Field(name string) (*FieldSchema, bool) {
+	for i := range s.Fields {
+		if s.Fields[i].Name == name && s.Fields[i].WapiPrimitive != "funccall" {
+			return &s.Fields[i], true
+		}
+	}
+	return nil, false
+}
+
+// Functions returns the names of the functions of the object type.
+func (s *ObjectSchema) Functions() []string {
+	var res []string
+	for _, f := range s.Fields {
+		if f.WapiPrimitive == "funccall" {
+			res = append(res, f.Name)
+		}
+	}
+	return res
+}
+
+// SchemaPolicy tells a Connector what to do with the fields of a request
+// which the grid's schema does not support, see Connector.SetSchemaPolicy.
+type SchemaPolicy int
+
+const (
+	// SchemaPolicyNone sends the requests as they are.
+	SchemaPolicyNone SchemaPolicy = iota
+	// SchemaPolicyStrip removes the unsupported fields from the requests.
+	SchemaPolicyStrip
+	// SchemaPolicyReject fails the requests with unsupported fields
+	// with an UnsupportedFieldsError, without sending them.
+	SchemaPolicyReject
+)
+
+// UnsupportedFieldsError is returned for requests with fields
+// which the grid does not support, under SchemaPolicyReject.
+type UnsupportedFieldsError struct {
+	ObjectType string
+	Version    string
+	Fields     []string
+}
+
+func (e *UnsupportedFieldsError) Error() string {
+	return fmt.Sprintf("fields not supported by %s objects in WAPI %s: %s",
+		e.ObjectType, e.Version, strings.Join(e.Fields, ", "))
+}
+
+// schemaCache holds the object schemas fetched by a Connector
+// and its schema policy, behind a pointer like its middlewares.
+type schemaCache struct {
+	mu      sync.Mutex
+	policy  SchemaPolicy
+	objects map[string]*ObjectSchema
+}
+
+func (c *Connector) getSchemaCache() *schemaCache {
+	if c.schemas == nil {
+		c.schemas = &schemaCache{}
+	}
+	return c.schemas
+}
+
+// SetSchemaPolicy sets what the connector does with the fields the grid
+// does not support. Under a policy other than SchemaPolicyNone, the schema
+// of each object type is fetched once, before its first request. It is
+// expected to be called before the connector is shared.
+func (c *Connector) SetSchemaPolicy(policy SchemaPolicy) {
+	cache := c.getSchemaCache()
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.policy = policy
+}
+
+func (c *Connector) GetSchema() (*WapiSchema, error) {
+	return c.GetSchemaCtx(context.Background())
+}
+
+// GetSchemaCtx returns the WAPI schema of the grid, with its supported versions.
+func (c *Connector) GetSchemaCtx(ctx context.Context) (*WapiSchema, error) {
+	var res WapiSchema
+	if err := c.getSchema(ctx, "", &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Connector) GetObjectSchema(objType string) (*ObjectSchema, error) {
+	return c.GetObjectSchemaCtx(context.Background(), objType)
+}
+
+// GetObjectSchemaCtx returns the schema of an object type in the WAPI
+// version of the connector. Schemas are cached until the version changes.
+func (c *Connector) GetObjectSchemaCtx(ctx context.Context, objType string) (*ObjectSchema, error) {
+	cache := c.getSchemaCache()
+	cache.mu.Lock()
+	schema, ok := cache.objects[objType]
+	cache.mu.Unlock()
+	if ok {
+		return schema, nil
+	}
+
+	schema = &ObjectSchema{}
+	if err := c.getSchema(ctx, objType, schema); err != nil {
+		return nil, err
+	}
+
+	cache.mu.Lock()
+	if cache.objects == nil {
+		cache.objects = make(map[string]*ObjectSchema)
+	}
+	cache.objects[objType] = schema
+	cache.mu.Unlock()
+	return schema, nil
+}
+
+// getSchema fetches the schema of objType from <objType>?_schema, or the
+// WAPI schema from ?_schema when objType is empty.
+func (c *Connector) getSchema(ctx context.Context, objType string, res interface{}) error {
+	queryParams := NewQueryParams(false, nil)
+	queryParams.schema = true
+	var obj IBObject
+	if objType != "" {
+		obj = &rawObject{objType: objType}
+	}
+	resp, err := c.makeRequest(ctx, GET, obj, "", queryParams)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(resp, res); err != nil {
+		return fmt.Errorf("cannot unmarshall schema '%s': %s", string(resp), err)
+	}
+	return nil
+}
+
+func (c *Connector) NegotiateVersion(maxVersion string) (string, error) {
+	return c.NegotiateVersionCtx(context.Background(), maxVersion)
+}
+
+// NegotiateVersionCtx switches the connector to the highest WAPI version
+// supported by the grid which is not above maxVersion, WAPI_VERSION when
+// it is empty, and returns it. The version of the connector's HostConfig
+// is only used to fetch the grid's schema. NegotiateVersionCtx is expected
+// to be called before the connector is shared.
+func (c *Connector) NegotiateVersionCtx(ctx context.Context, maxVersion string) (string, error) {
+	if maxVersion == "" {
+		maxVersion = WAPI_VERSION
+	}
+	schema, err := c.GetSchemaCtx(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	version := ""
+	for _, v := range schema.SupportedVersions {
+		if compareVersions(v, maxVersion) <= 0 && (version == "" || compareVersions(v, version) > 0) {
+			version = v
+		}
+	}
+	if version == "" {
+		return "", fmt.Errorf("the grid supports no WAPI version up to %s, only %s",
+			maxVersion, strings.Join(schema.SupportedVersions, ", "))
+	}
+
+	if version != c.hostCfg.Version {
+		c.hostCfg.Version = version
+		c.requestBuilder.Init(c.hostCfg, c.authCfg)
+
+		cache := c.getSchemaCache()
+		cache.mu.Lock()
+		cache.objects = nil
+		cache.mu.Unlock()
+	}
+	return version, nil
+}
+
+// compareVersions compares WAPI versions such as 2.12.1 and 2.9
+func compareVersions(a string, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// applySchemaPolicy strips or rejects the fields of req which the
+// schema of obj does not support: the body fields of creations and
+// updates, and the return fields of searches.
+func (c *Connector) applySchemaPolicy(ctx context.Context, t RequestType, obj IBObject, req *http.Request) error {
+	cache := c.getSchemaCache()
+	cache.mu.Lock()
+	policy := cache.policy
+	cache.mu.Unlock()
+	if policy == SchemaPolicyNone || obj == nil || obj.ObjectType() == "" || obj.ObjectType() == "request" {
+		return nil
+	}
+
+	var unsupported []string
+	switch t {
+	case CREATE, UPDATE:
+		op := byte('w')
+		if t == UPDATE {
+			op = 'u'
+		}
+		body, err := readRequestBody(req)
+		if err != nil {
+			return err
+		}
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(body, &fields) != nil {
+			return nil
+		}
+		schema, err := c.GetObjectSchemaCtx(ctx, obj.ObjectType())
+		if err != nil {
+			return err
+		}
+		for name := range fields {
+			// extattrs+ and extattrs- update extattrs
+			f, ok := schema.Field(strings.TrimRight(name, "+-"))
+			if !ok || !f.SupportsOp(op) {
+				unsupported = append(unsupported, name)
+				delete(fields, name)
+			}
+		}
+		if len(unsupported) > 0 && policy == SchemaPolicyStrip {
+			if body, err = json.Marshal(fields); err != nil {
+				return err
+			}
+			setRequestBody(req, body)
+		}
+	case GET:
+		vals := req.URL.Query()
+		for _, arg := range []string{"_return_fields", "_return_fields+"} {
+			if vals.Get(arg) == "" {
+				continue
+			}
+			schema, err := c.GetObjectSchemaCtx(ctx, obj.ObjectType())
+			if err != nil {
+				return err
+			}
+			var supported []string
+			for _, name := range strings.Split(vals.Get(arg), ",") {
+				if f, ok := schema.Field(name); ok && f.SupportsOp('r') {
+					supported = append(supported, name)
+				} else {
+					unsupported = append(unsupported, name)
+				}
+			}
+			vals.Set(arg, strings.Join(supported, ","))
+		}
+		if len(unsupported) > 0 && policy == SchemaPolicyStrip {
+			req.URL.RawQuery = vals.Encode()
+		}
+	}
+
+	if len(unsupported) > 0 && policy == SchemaPolicyReject {
+		sort.Strings(unsupported)
+		return &UnsupportedFieldsError{ObjectType: obj.ObjectType(), Version: c.hostCfg.Version, Fields: unsupported}
+	}
+	return nil
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil {
+		return nil, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+func setRequestBody(req *http.Request, body []byte) {
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+}
@@ -0,0 +1,114 @@
+package ibclient_test
+
+import (
+	"errors"
+	"net/http"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WAPI schema", func() {
+	var (
+		server *fakewapi.Server
+		conn   *ibclient.Connector
+	)
+
+	BeforeEach(func() {
+		server = fakewapi.NewServer()
+		DeferCleanup(server.Close)
+		server.SupportedVersions = []string{"2.5", "2.10", "2.12", "2.13"}
+		server.SetSchema("nsgroup", map[string]string{"name": "rwus", "comment": "r", "extattrs": "rwu"})
+		conn = connectToFake(server, ibclient.AuthConfig{}, "2.5")
+	})
+
+	It("should return the supported versions and object schemas", func() {
+		schema, err := conn.GetSchema()
+		Expect(err).To(BeNil())
+		Expect(schema.RequestedVersion).To(Equal("2.5"))
+		Expect(schema.SupportedVersions).To(Equal([]string{"2.5", "2.10", "2.12", "2.13"}))
+		Expect(schema.SupportedObjects).To(ContainElement("nsgroup"))
+
+		nsgSchema, err := conn.GetObjectSchema("nsgroup")
+		Expect(err).To(BeNil())
+		Expect(nsgSchema.Type).To(Equal("nsgroup"))
+		comment, ok := nsgSchema.Field("comment")
+		Expect(ok).To(BeTrue())
+		Expect(comment.SupportsOp('r')).To(BeTrue())
+		Expect(comment.SupportsOp('w')).To(BeFalse())
+		_, ok = nsgSchema.Field("grid_primary")
+		Expect(ok).To(BeFalse())
+
+		_, err = conn.GetObjectSchema("unknown")
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should request the schemas from ?_schema and <type>?_schema", func() {
+		var urls []string
+		conn.Use(func(next ibclient.RequestHandler) ibclient.RequestHandler {
+			return func(req *http.Request) ([]byte, error) {
+				urls = append(urls, req.URL.RequestURI())
+				return next(req)
+			}
+		})
+		_, err := conn.GetSchema()
+		Expect(err).To(BeNil())
+		_, err = conn.GetObjectSchema("nsgroup")
+		Expect(err).To(BeNil())
+		Expect(urls).To(Equal([]string{"/wapi/v2.5/?_schema", "/wapi/v2.5/nsgroup?_schema"}))
+	})
+
+	It("should negotiate the highest mutually supported version", func() {
+		version, err := conn.NegotiateVersion("")
+		Expect(err).To(BeNil())
+		Expect(version).To(Equal("2.12"))
+		schema, err := conn.GetSchema()
+		Expect(err).To(BeNil())
+		Expect(schema.RequestedVersion).To(Equal("2.12"))
+
+		version, err = conn.NegotiateVersion("2.11")
+		Expect(err).To(BeNil())
+		Expect(version).To(Equal("2.10"))
+
+		_, err = conn.NegotiateVersion("2.1")
+		Expect(err).To(MatchError("the grid supports no WAPI version up to 2.1, only 2.5, 2.10, 2.12, 2.13"))
+	})
+
+	It("should strip the fields the grid does not support", func() {
+		conn.SetSchemaPolicy(ibclient.SchemaPolicyStrip)
+		nsg := &ibclient.Nsgroup{Name: utils.StringPtr("ns-group"), Comment: utils.StringPtr("not writable")}
+		ref, err := conn.CreateObject(nsg)
+		Expect(err).To(BeNil())
+
+		stored, ok := server.Get(ref)
+		Expect(ok).To(BeTrue())
+		Expect(stored).NotTo(HaveKey("comment"))
+
+		// the cached schema is dropped when the version changes
+		server.SetSchema("nsgroup", map[string]string{"name": "rwus"})
+		_, err = conn.NegotiateVersion("2.10")
+		Expect(err).To(BeNil())
+		res, err := ibclient.Get[ibclient.Nsgroup](conn, ref, "name", "comment")
+		Expect(err).To(BeNil())
+		Expect(*res.Name).To(Equal("ns-group"))
+	})
+
+	It("should reject requests with fields the grid does not support", func() {
+		conn.SetSchemaPolicy(ibclient.SchemaPolicyReject)
+		nsg := &ibclient.Nsgroup{Name: utils.StringPtr("ns-group"), Comment: utils.StringPtr("not writable")}
+		_, err := conn.CreateObject(nsg)
+
+		var unsupported *ibclient.UnsupportedFieldsError
+		Expect(errors.As(err, &unsupported)).To(BeTrue())
+		Expect(unsupported.ObjectType).To(Equal("nsgroup"))
+		Expect(unsupported.Fields).To(Equal([]string{"comment"}))
+		Expect(server.Objects("nsgroup")).To(BeEmpty())
+
+		_, err = conn.CreateObject(&ibclient.Nsgroup{Name: utils.StringPtr("ns-group")})
+		Expect(err).To(BeNil())
+	})
+})
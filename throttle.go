@@ -0,0 +1,209 @@
+package ibclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ThrottleStats reports how much a Connector's requests were held back by
+// its rate limit and concurrency cap, see TransportConfig.
+type ThrottleStats struct {
+	// Requests is the number of requests which went through the throttle
+	Requests uint64
+
+	// Retries is the number of attempts after the first one of these
+	// requests, which are subject to the rate limit too
+	Retries uint64
+
+	// RateLimited is the number of requests delayed by the rate limit,
+	// RateLimitWait is the total time they waited.
+	RateLimited   uint64
+	RateLimitWait time.Duration
+
+	// ConcurrencyLimited is the number of requests delayed by the
+	// concurrency cap, ConcurrencyWait is the total time they waited.
+	ConcurrencyLimited uint64
+	ConcurrencyWait    time.Duration
+
+	// InFlight is the number of requests being sent right now
+	InFlight int
+}
+
+// throttle applies the rate limit and the concurrency cap of a Connector.
+type throttle struct {
+	bucket *tokenBucket
+	slots  chan struct{}
+
+	mu    sync.Mutex
+	stats ThrottleStats
+}
+
+func newThrottle(trCfg TransportConfig) *throttle {
+	t := &throttle{}
+	if trCfg.RateLimit > 0 {
+		t.bucket = newTokenBucket(trCfg.RateLimit, trCfg.RateBurst)
+	}
+	if trCfg.MaxConcurrentRequests > 0 {
+		t.slots = make(chan struct{}, trCfg.MaxConcurrentRequests)
+	}
+	return t
+}
+
+// acquire waits until a request may be sent. The returned function
+// must be called once the request is done.
+func (t *throttle) acquire(ctx context.Context) (release func(), err error) {
+	var rateWait, slotWait time.Duration
+
+	if t.bucket != nil {
+		if rateWait, err = t.bucket.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		default:
+			start := time.Now()
+			select {
+			case t.slots <- struct{}{}:
+			case <-ctx.Done():
+				// the request won't be sent, give its token back
+				if t.bucket != nil {
+					t.bucket.cancel()
+				}
+				return nil, ctx.Err()
+			}
+			slotWait = time.Since(start)
+		}
+	}
+
+	t.mu.Lock()
+	t.stats.Requests++
+	if rateWait > 0 {
+		t.stats.RateLimited++
+		t.stats.RateLimitWait += rateWait
+	}
+	if slotWait > 0 {
+		t.stats.ConcurrencyLimited++
+		t.stats.ConcurrencyWait += slotWait
+	}
+	t.stats.InFlight++
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		t.stats.InFlight--
+		t.mu.Unlock()
+		if t.slots != nil {
+			<-t.slots
+		}
+	}, nil
+}
+
+type retryThrottleKey struct{}
+
+// withRetries returns ctx which makes the retries of the requests bound to
+// it wait for the rate limit, see waitForRetry. The retries keep the slot
+// of the first attempt, so the concurrency cap doesn't apply to them.
+func (t *throttle) withRetries(ctx context.Context) context.Context {
+	if t.bucket == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, retryThrottleKey{}, t)
+}
+
+// waitForRetry waits until req may be attempted again,
+// when it is subject to the rate limit of a connector
+func waitForRetry(req *http.Request) error {
+	t, ok := req.Context().Value(retryThrottleKey{}).(*throttle)
+	if !ok {
+		return nil
+	}
+	rateWait, err := t.bucket.wait(req.Context())
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Retries++
+	if rateWait > 0 {
+		t.stats.RateLimited++
+		t.stats.RateLimitWait += rateWait
+	}
+	return nil
+}
+
+func (t *throttle) snapshot() ThrottleStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+// tokenBucket is a rate limiter allowing rate requests per second
+// on average, with bursts of up to burst requests.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// reserve takes a token, possibly in advance,
+// and returns the time to wait until it is available.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel gives back a token taken by reserve
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+}
+
+// wait blocks until a token is available and returns how long it waited.
+func (b *tokenBucket) wait(ctx context.Context) (time.Duration, error) {
+	delay := b.reserve()
+	if delay == 0 {
+		return 0, nil
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		b.cancel()
+		return 0, err
+	}
+	return delay, nil
+}
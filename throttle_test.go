@@ -0,0 +1,214 @@
+package ibclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// blockingHttpRequestor holds every request until it is released.
+type blockingHttpRequestor struct {
+	mu       sync.Mutex
+	inFlight int
+	maxSeen  int
+	release  chan struct{}
+}
+
+func (hr *blockingHttpRequestor) Init(authCfg AuthConfig, trCfg TransportConfig) {}
+
+func (hr *blockingHttpRequestor) SendRequest(req *http.Request) ([]byte, error) {
+	hr.mu.Lock()
+	hr.inFlight++
+	if hr.inFlight > hr.maxSeen {
+		hr.maxSeen = hr.inFlight
+	}
+	hr.mu.Unlock()
+
+	<-hr.release
+
+	hr.mu.Lock()
+	hr.inFlight--
+	hr.mu.Unlock()
+	return []byte(`"networkview/ZG5zLm5ldHdvcmtfdmlldyQyMw:global_view/false"`), nil
+}
+
+var _ = Describe("Throttle", func() {
+	Describe("tokenBucket", func() {
+		var (
+			now    time.Time
+			bucket *tokenBucket
+		)
+
+		BeforeEach(func() {
+			now = time.Unix(1700000000, 0)
+			bucket = newTokenBucket(10, 2)
+			bucket.now = func() time.Time { return now }
+		})
+
+		It("should allow a burst and then space requests out", func() {
+			Expect(bucket.reserve()).To(BeZero())
+			Expect(bucket.reserve()).To(BeZero())
+			Expect(bucket.reserve()).To(Equal(100 * time.Millisecond))
+			Expect(bucket.reserve()).To(Equal(200 * time.Millisecond))
+		})
+
+		It("should refill over time up to the burst", func() {
+			bucket.reserve()
+			bucket.reserve()
+			now = now.Add(time.Hour)
+			Expect(bucket.reserve()).To(BeZero())
+			Expect(bucket.reserve()).To(BeZero())
+			Expect(bucket.reserve()).To(Equal(100 * time.Millisecond))
+		})
+
+		It("should give the token back when the wait is cancelled", func() {
+			bucket.reserve()
+			bucket.reserve()
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := bucket.wait(ctx)
+			Expect(err).To(Equal(context.Canceled))
+			Expect(bucket.reserve()).To(Equal(100 * time.Millisecond))
+		})
+	})
+
+	Describe("throttle", func() {
+		It("should give the token back when the wait for a slot is cancelled", func() {
+			trCfg := NewTransportConfig("false", 10, 1)
+			trCfg.RateLimit = 10
+			trCfg.RateBurst = 2
+			trCfg.MaxConcurrentRequests = 1
+			t := newThrottle(trCfg)
+			now := time.Unix(1700000000, 0)
+			t.bucket.now = func() time.Time { return now }
+
+			release, err := t.acquire(context.Background())
+			Expect(err).To(BeNil())
+			defer release()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			_, err = t.acquire(ctx)
+			Expect(err).To(Equal(context.DeadlineExceeded))
+			Expect(t.bucket.reserve()).To(BeZero())
+			Expect(t.snapshot().Requests).To(Equal(uint64(1)))
+		})
+	})
+
+	Describe("Connector", func() {
+		hostCfg := HostConfig{Host: "172.22.18.66", Version: "2.12.1", Port: "443"}
+		authCfg := AuthConfig{Username: "myname", Password: "mysecrete!"}
+
+		newConnector := func(trCfg TransportConfig, hr HttpRequestor) *Connector {
+			wrb, _ := NewWapiRequestBuilder(hostCfg, authCfg)
+			OrigValidateConnector := ValidateConnector
+			ValidateConnector = MockValidateConnector
+			defer func() { ValidateConnector = OrigValidateConnector }()
+			conn, err := NewConnector(hostCfg, authCfg, trCfg, wrb, hr)
+			Expect(err).To(BeNil())
+			return conn
+		}
+
+		It("should cap the number of requests in flight", func() {
+			trCfg := NewTransportConfig("false", 10, 1)
+			trCfg.MaxConcurrentRequests = 2
+			hr := &blockingHttpRequestor{release: make(chan struct{})}
+			conn := newConnector(trCfg, hr)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					_, err := conn.DeleteObject("networkview/ZG5zLm5ldHdvcmtfdmlldyQyMw:global_view/false")
+					Expect(err).To(BeNil())
+				}()
+			}
+			Eventually(func() int { return conn.ThrottleStats().InFlight }).Should(Equal(2))
+			Consistently(func() int { return conn.ThrottleStats().InFlight }, 50*time.Millisecond).Should(Equal(2))
+			close(hr.release)
+			wg.Wait()
+
+			stats := conn.ThrottleStats()
+			Expect(hr.maxSeen).To(Equal(2))
+			Expect(stats.Requests).To(Equal(uint64(5)))
+			Expect(stats.ConcurrencyLimited).To(Equal(uint64(3)))
+			Expect(stats.ConcurrencyWait).To(BeNumerically(">", 0))
+			Expect(stats.InFlight).To(BeZero())
+		})
+
+		It("should rate limit requests", func() {
+			trCfg := NewTransportConfig("false", 10, 1)
+			trCfg.RateLimit = 10
+			trCfg.RateBurst = 1
+			hr := &blockingHttpRequestor{release: make(chan struct{})}
+			close(hr.release)
+			conn := newConnector(trCfg, hr)
+
+			for i := 0; i < 3; i++ {
+				_, err := conn.DeleteObject("networkview/ZG5zLm5ldHdvcmtfdmlldyQyMw:global_view/false")
+				Expect(err).To(BeNil())
+			}
+
+			stats := conn.ThrottleStats()
+			Expect(stats.Requests).To(Equal(uint64(3)))
+			Expect(stats.RateLimited).To(Equal(uint64(2)))
+			Expect(stats.RateLimitWait).To(BeNumerically(">", 0))
+		})
+
+		It("should rate limit retries", func() {
+			statuses := []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := statuses[0]
+				statuses = statuses[1:]
+				w.WriteHeader(status)
+				fmt.Fprint(w, `"networkview/ZG5zLm5ldHdvcmtfdmlldyQyMw:global_view/false"`)
+			}))
+			defer server.Close()
+			u, _ := url.Parse(server.URL)
+			host, port, _ := net.SplitHostPort(u.Host)
+
+			trCfg := NewTransportConfig("false", 10, 1)
+			trCfg.RateLimit = 10
+			trCfg.RateBurst = 1
+			policy := NewExponentialBackoff(3)
+			policy.InitialInterval = time.Millisecond
+			policy.Jitter = 0
+			trCfg.RetryPolicy = policy
+			serverCfg := HostConfig{Scheme: "http", Host: host, Port: port, Version: "2.12.1"}
+			wrb, _ := NewWapiRequestBuilder(serverCfg, authCfg)
+			OrigValidateConnector := ValidateConnector
+			ValidateConnector = MockValidateConnector
+			defer func() { ValidateConnector = OrigValidateConnector }()
+			conn, err := NewConnector(serverCfg, authCfg, trCfg, wrb, &WapiHttpRequestor{})
+			Expect(err).To(BeNil())
+
+			start := time.Now()
+			_, err = conn.DeleteObject("networkview/ZG5zLm5ldHdvcmtfdmlldyQyMw:global_view/false")
+			Expect(err).To(BeNil())
+			Expect(time.Since(start)).To(BeNumerically(">=", 150*time.Millisecond))
+
+			stats := conn.ThrottleStats()
+			Expect(stats.Requests).To(Equal(uint64(1)))
+			Expect(stats.Retries).To(Equal(uint64(2)))
+			Expect(stats.RateLimited).To(Equal(uint64(2)))
+		})
+
+		It("should not wait without limits", func() {
+			hr := &blockingHttpRequestor{release: make(chan struct{})}
+			close(hr.release)
+			conn := newConnector(NewTransportConfig("false", 10, 1), hr)
+			_, err := conn.DeleteObject("networkview/ZG5zLm5ldHdvcmtfdmlldyQyMw:global_view/false")
+			Expect(err).To(BeNil())
+			Expect(conn.ThrottleStats()).To(Equal(ThrottleStats{Requests: 1}))
+		})
+	})
+})
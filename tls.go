@@ -0,0 +1,166 @@
+package ibclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSConfig configures the TLS connections to the grid,
+// see TransportConfig.TLS.
+type TLSConfig struct {
+	// CAFiles are PEM bundles of the CAs the grid's certificate is verified
+	// against, in addition to the CA file given to NewTransportConfig.
+	// The system's CAs are used when there are none.
+	CAFiles []string
+
+	// MinVersion is the minimum TLS version, e.g. tls.VersionTLS13,
+	// and CipherSuites the cipher suites of TLS 1.2 and below.
+	// Go's defaults are used when they are not set.
+	MinVersion   uint16
+	CipherSuites []uint16
+
+	// ServerName is the name the grid's certificate is verified against
+	// instead of HostConfig.Host, e.g. when the grid is reached by IP.
+	ServerName string
+}
+
+// HttpRequestorInitializer is implemented by the HttpRequestors whose
+// configuration may fail: NewConnector calls InitWithError instead of Init
+// and returns its error.
+type HttpRequestorInitializer interface {
+	HttpRequestor
+	InitWithError(AuthConfig, TransportConfig) error
+}
+
+// initRequestor initializes requestor, returning the configuration
+// errors of the requestors which report them
+func initRequestor(requestor HttpRequestor, authCfg AuthConfig, trCfg TransportConfig) error {
+	if r, ok := requestor.(HttpRequestorInitializer); ok {
+		return r.InitWithError(authCfg, trCfg)
+	}
+	requestor.Init(authCfg, trCfg)
+	return nil
+}
+
+// newTLSConfig returns the TLS configuration of the connections to the grid
+func newTLSConfig(authCfg AuthConfig, trCfg TransportConfig, log func() *slog.Logger) (*tls.Config, error) {
+	cfg := &tls.Config{
+		InsecureSkipVerify: !trCfg.SslVerify,
+		Renegotiation:      tls.RenegotiateOnceAsClient,
+		MinVersion:         trCfg.TLS.MinVersion,
+		CipherSuites:       trCfg.TLS.CipherSuites,
+		ServerName:         trCfg.TLS.ServerName,
+	}
+
+	cfg.RootCAs = trCfg.certPool
+	if len(trCfg.TLS.CAFiles) > 0 {
+		if cfg.RootCAs == nil {
+			cfg.RootCAs = x509.NewCertPool()
+		} else {
+			cfg.RootCAs = cfg.RootCAs.Clone()
+		}
+		for _, path := range trCfg.TLS.CAFiles {
+			if err := appendCAFile(cfg.RootCAs, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch {
+	case authCfg.ClientCertFile != "" || authCfg.ClientKeyFile != "":
+		reloader, err := newCertReloader(authCfg.ClientCertFile, authCfg.ClientKeyFile, log)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = reloader.getClientCertificate
+	case authCfg.ClientCert != nil || authCfg.ClientKey != nil:
+		cert, err := tls.X509KeyPair(authCfg.ClientCert, authCfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate key pair (PEM format error): %s", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func appendCAFile(pool *x509.CertPool, path string) error {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot load the CA file '%s': %s", path, err)
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no certificate found in the CA file '%s'", path)
+	}
+	return nil
+}
+
+// certReloader loads a client certificate from files, and loads it
+// again when the files change, so that it can be rotated
+type certReloader struct {
+	certFile string
+	keyFile  string
+	log      func() *slog.Logger
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func newCertReloader(certFile string, keyFile string, log func() *slog.Logger) (*certReloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both the client certificate file and the client key file are required")
+	}
+	r := &certReloader{certFile: certFile, keyFile: keyFile, log: log}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload loads the certificate when the files changed since it was loaded
+func (r *certReloader) reload() error {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+	if r.cert != nil && modTime.Equal(r.modTime) {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("cannot load the client certificate '%s': %s", r.certFile, err)
+	}
+	r.cert, r.modTime = &cert, modTime
+	return nil
+}
+
+func (r *certReloader) lastModified() (time.Time, error) {
+	var last time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot load the client certificate: %s", err)
+		}
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last, nil
+}
+
+func (r *certReloader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// the certificate being rotated may be half written,
+	// the one loaded before is used until it can be loaded
+	if err := r.reload(); err != nil {
+		r.log().Warn("keeping the previous client certificate", "error", err)
+	}
+	return r.cert, nil
+}
@@ -0,0 +1,214 @@
+package ibclient_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// testCA issues the certificates of the TLS tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA() *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	Expect(err).To(BeNil())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).To(BeNil())
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of a server or client certificate
+func (ca *testCA) issue(name string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	Expect(err).To(BeNil())
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	Expect(err).To(BeNil())
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	Expect(err).To(BeNil())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+var _ = Describe("TLS", func() {
+	var (
+		ca      *testCA
+		dir     string
+		server  *httptest.Server
+		hostCfg ibclient.HostConfig
+
+		mu          sync.Mutex
+		clientNames []string
+	)
+
+	writeFile := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, data, 0600)).To(Succeed())
+		return path
+	}
+
+	getViews := func(conn *ibclient.Connector) error {
+		_, err := ibclient.List[ibclient.View](conn, nil)
+		return err
+	}
+
+	BeforeEach(func() {
+		ca = newTestCA()
+		dir = GinkgoT().TempDir()
+		clientNames = nil
+
+		fake := fakewapi.NewServer()
+		DeferCleanup(fake.Close)
+		serverCert, serverKey := ca.issue("wapi.example.com", x509.ExtKeyUsageServerAuth)
+		cert, err := tls.X509KeyPair(serverCert, serverKey)
+		Expect(err).To(BeNil())
+		clientCAs := x509.NewCertPool()
+		clientCAs.AddCert(ca.cert)
+
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			clientNames = append(clientNames, r.TLS.PeerCertificates[0].Subject.CommonName)
+			mu.Unlock()
+			fake.ServeHTTP(w, r)
+		}))
+		server.TLS = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    clientCAs,
+			MaxVersion:   tls.VersionTLS12,
+		}
+		server.StartTLS()
+		DeferCleanup(server.Close)
+
+		host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+		Expect(err).To(BeNil())
+		hostCfg = ibclient.HostConfig{Scheme: "https", Host: host, Port: port, Version: fake.Version}
+	})
+
+	newTransportConfig := func() ibclient.TransportConfig {
+		trCfg := ibclient.NewTransportConfig("true", 10, 1)
+		trCfg.TLS = ibclient.TLSConfig{
+			CAFiles:    []string{writeFile("ca.pem", ca.pem)},
+			ServerName: "wapi.example.com",
+		}
+		return trCfg
+	}
+
+	It("should authenticate with a client certificate read from files", func() {
+		cert, key := ca.issue("client-1", x509.ExtKeyUsageClientAuth)
+		authCfg := ibclient.AuthConfig{
+			ClientCertFile: writeFile("client.pem", cert),
+			ClientKeyFile:  writeFile("client.key", key),
+		}
+		conn, err := ibclient.NewConnector(hostCfg, authCfg, newTransportConfig(),
+			&ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+		Expect(err).To(BeNil())
+		Expect(getViews(conn)).To(Succeed())
+
+		// the rotated certificate is used for the next connections
+		cert, key = ca.issue("client-2", x509.ExtKeyUsageClientAuth)
+		writeFile("client.pem", cert)
+		writeFile("client.key", key)
+		later := time.Now().Add(time.Minute)
+		Expect(os.Chtimes(authCfg.ClientCertFile, later, later)).To(Succeed())
+		server.CloseClientConnections()
+		Eventually(func() error { return getViews(conn) }).Should(Succeed())
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(clientNames[0]).To(Equal("client-1"))
+		Expect(clientNames[len(clientNames)-1]).To(Equal("client-2"))
+	})
+
+	It("should authenticate with a client certificate given as PEM", func() {
+		cert, key := ca.issue("client-1", x509.ExtKeyUsageClientAuth)
+		conn, err := ibclient.NewConnector(hostCfg, ibclient.AuthConfig{ClientCert: cert, ClientKey: key},
+			newTransportConfig(), &ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+		Expect(err).To(BeNil())
+		Expect(getViews(conn)).To(Succeed())
+	})
+
+	It("should verify the grid's certificate against the server name", func() {
+		cert, key := ca.issue("client-1", x509.ExtKeyUsageClientAuth)
+		trCfg := newTransportConfig()
+		trCfg.TLS.ServerName = "other.example.com"
+		conn, err := ibclient.NewConnector(hostCfg, ibclient.AuthConfig{ClientCert: cert, ClientKey: key},
+			trCfg, &ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+		Expect(err).To(BeNil())
+		Expect(getViews(conn)).NotTo(Succeed())
+	})
+
+	It("should enforce the minimum TLS version", func() {
+		cert, key := ca.issue("client-1", x509.ExtKeyUsageClientAuth)
+		trCfg := newTransportConfig()
+		trCfg.TLS.MinVersion = tls.VersionTLS13
+		conn, err := ibclient.NewConnector(hostCfg, ibclient.AuthConfig{ClientCert: cert, ClientKey: key},
+			trCfg, &ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+		Expect(err).To(BeNil())
+		Expect(getViews(conn)).To(MatchError(ContainSubstring("protocol version")))
+	})
+
+	It("should return the configuration errors from NewConnector", func() {
+		_, err := ibclient.NewConnector(hostCfg, ibclient.AuthConfig{ClientCert: []byte("cert"), ClientKey: []byte("key")},
+			newTransportConfig(), &ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+		Expect(err).To(MatchError(ContainSubstring("invalid client certificate key pair")))
+
+		_, err = ibclient.NewConnector(hostCfg, ibclient.AuthConfig{ClientCertFile: filepath.Join(dir, "missing.pem")},
+			newTransportConfig(), &ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+		Expect(err).To(MatchError("both the client certificate file and the client key file are required"))
+
+		_, err = ibclient.NewConnector(hostCfg, ibclient.AuthConfig{},
+			ibclient.NewTransportConfig(filepath.Join(dir, "missing-ca.pem"), 10, 1),
+			&ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+		Expect(err).To(MatchError(ContainSubstring("cannot load the CA file")))
+
+		trCfg := newTransportConfig()
+		trCfg.TLS.CAFiles = []string{writeFile("empty.pem", []byte("no certificate"))}
+		_, err = ibclient.NewConnector(hostCfg, ibclient.AuthConfig{}, trCfg,
+			&ibclient.WapiRequestBuilder{}, &ibclient.WapiHttpRequestor{})
+		Expect(err).To(MatchError(ContainSubstring("no certificate found in the CA file")))
+	})
+})
@@ -0,0 +1,83 @@
+package ibclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer starts a span for each WAPI request of a connector, see
+// Connector.SetTracer. The ibotel package implements it with OpenTelemetry.
+type Tracer interface {
+	// StartSpan starts the span of a request, returning the context
+	// which carries it
+	StartSpan(ctx context.Context, info SpanInfo) (context.Context, Span)
+
+	// Inject adds the trace context of ctx to the headers of a request,
+	// e.g. the W3C traceparent header
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span is the span of a WAPI request, started by a Tracer.
+type Span interface {
+	AddEvent(name string, attrs map[string]string)
+	RecordError(err error)
+	End()
+}
+
+// SpanInfo describes the WAPI request a span is started for.
+type SpanInfo struct {
+	Method      string
+	ObjectType  string
+	Ref         string
+	WapiVersion string
+
+	// ForceProxy is set when the request is sent through the Grid Master
+	ForceProxy bool
+}
+
+// ProxyRetryEvent is the event added to the span of a request sent
+// again through the Grid Master, because it failed or found no object.
+const ProxyRetryEvent = "wapi.proxy_retry"
+
+// SetTracer sets the tracer starting the spans of the connector's
+// requests, no span is started when it is nil.
+func (c *Connector) SetTracer(tracer Tracer) {
+	c.tracer = tracer
+}
+
+// startSpan starts the span of a request sent by makeRequest,
+// the returned span is nil when the connector has no tracer
+func (c *Connector) startSpan(ctx context.Context, t RequestType, obj IBObject, ref string, queryParams *QueryParams) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, nil
+	}
+	return c.tracer.StartSpan(ctx, SpanInfo{
+		Method:      t.toMethod(),
+		ObjectType:  requestObjectType(obj, ref),
+		Ref:         ref,
+		WapiVersion: c.hostCfg.Version,
+		ForceProxy:  queryParams != nil && queryParams.forceProxy,
+	})
+}
+
+// endSpan records err, when the request failed, and ends span
+func endSpan(span Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// injectTraceContext adds the trace context of ctx to the headers of req
+func (c *Connector) injectTraceContext(ctx context.Context, req *http.Request) {
+	if c.tracer == nil {
+		return
+	}
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	c.tracer.Inject(ctx, req.Header)
+}
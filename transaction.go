@@ -0,0 +1,307 @@
+package ibclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Transaction is a unit of work: it records object creations, updates and
+// deletions, and applies them all or none of them on Commit.
+//
+//	tx := objMgr.NewTransaction()
+//	tx.Create(&HostRecord{...})
+//	tx.Create(&RecordCNAME{...})
+//	tx.Update(&Ipv4FixedAddress{Comment: utils.StringPtr("web")}, faRef)
+//	refs, err := tx.Commit()
+//
+// When every step is a Create, Update or Delete and the object manager's
+// connector is a *Connector, the steps are sent in a single atomic WAPI
+// multi-request. Otherwise, e.g. when a step is a Do, they are applied one
+// by one, and the steps already applied are undone in reverse order when one
+// fails: created objects are deleted, updated fields get their prior values
+// back and deleted objects are created again. Their fields are read before
+// they are deleted, according to the grid's schema, and a Delete step fails
+// when the schema can't be fetched.
+type Transaction struct {
+	objMgr    *ObjectManager
+	steps     []*txStep
+	committed bool
+}
+
+type txStep struct {
+	t   RequestType
+	obj IBObject
+	ref string
+
+	// custom steps, see Transaction.Do
+	apply func(objMgr IBObjectManager) (string, error)
+	undo  func(objMgr IBObjectManager, ref string) error
+
+	// the reference returned when the step was applied, and the fields
+	// of the object before it was updated or deleted. partial is set when
+	// they are the object's default fields only, see recreateFields.
+	result  string
+	prior   *rawObject
+	partial bool
+}
+
+// TransactionError is returned by Transaction.Commit when a step fails.
+// RollbackErr is set when the steps already applied could not all be undone.
+type TransactionError struct {
+	Step        int
+	Err         error
+	RollbackErr error
+}
+
+func (e *TransactionError) Error() string {
+	msg := fmt.Sprintf("step %d of the transaction failed: %s", e.Step, e.Err)
+	if e.RollbackErr != nil {
+		msg += fmt.Sprintf("; rollback failed: %s", e.RollbackErr)
+	}
+	return msg
+}
+
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// rawObject is an object of any type with the given fields,
+// used to restore objects
+type rawObject struct {
+	IBBase
+	objType string
+	fields  map[string]interface{}
+}
+
+func (obj *rawObject) ObjectType() string {
+	return obj.objType
+}
+
+func (obj *rawObject) MarshalJSON() ([]byte, error) {
+	return json.Marshal(obj.fields)
+}
+
+// NewTransaction returns an empty transaction applied through objMgr.
+func (objMgr *ObjectManager) NewTransaction() *Transaction {
+	return &Transaction{objMgr: objMgr}
+}
+
+// Create records the creation of obj.
+func (tx *Transaction) Create(obj IBObject) *Transaction {
+	tx.steps = append(tx.steps, &txStep{t: CREATE, obj: obj})
+	return tx
+}
+
+// Update records the update of the object with the given reference
+// with the fields set in obj.
+func (tx *Transaction) Update(obj IBObject, ref string) *Transaction {
+	tx.steps = append(tx.steps, &txStep{t: UPDATE, obj: obj, ref: ref})
+	return tx
+}
+
+// Delete records the deletion of the object with the given reference.
+func (tx *Transaction) Delete(ref string) *Transaction {
+	tx.steps = append(tx.steps, &txStep{t: DELETE, ref: ref})
+	return tx
+}
+
+// Do records a custom step, e.g. a call to ObjectManager.CreateHostRecord.
+// apply returns the reference of the object it changed, which undo is given
+// when the transaction is rolled back. A transaction with custom steps is
+// applied step by step.
+func (tx *Transaction) Do(apply func(objMgr IBObjectManager) (string, error),
+	undo func(objMgr IBObjectManager, ref string) error) *Transaction {
+	tx.steps = append(tx.steps, &txStep{apply: apply, undo: undo})
+	return tx
+}
+
+// Commit applies the steps of the transaction and returns the references
+// returned by each of them. On failure, it returns a *TransactionError
+// once the steps already applied have been undone.
+func (tx *Transaction) Commit() ([]string, error) {
+	if tx.committed {
+		return nil, fmt.Errorf("the transaction is already committed")
+	}
+	if len(tx.steps) == 0 {
+		return nil, fmt.Errorf("the transaction has no steps")
+	}
+	tx.committed = true
+
+	if tx.isAtomic() {
+		return tx.commitAtomic()
+	}
+	return tx.commitSteps()
+}
+
+// isAtomic reports whether the steps can be sent in a multi-request
+func (tx *Transaction) isAtomic() bool {
+	base, _ := unwrapConnector(tx.objMgr.connector)
+	if _, ok := base.(*Connector); !ok {
+		return false
+	}
+	for _, s := range tx.steps {
+		if s.apply != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (tx *Transaction) commitAtomic() ([]string, error) {
+	b := NewMultiRequestBuilder()
+	steps := make([]*MultiRequestStep, len(tx.steps))
+	for i, s := range tx.steps {
+		switch s.t {
+		case CREATE:
+			steps[i] = b.Create(s.obj)
+		case UPDATE:
+			steps[i] = b.Update(s.obj, s.ref)
+		case DELETE:
+			steps[i] = b.Delete(s.ref)
+		}
+	}
+
+	res, err := tx.objMgr.ExecuteMultiRequest(b)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]string, len(steps))
+	for i, step := range steps {
+		if refs[i], err = res.Ref(step); err != nil {
+			return nil, err
+		}
+	}
+	return refs, nil
+}
+
+func (tx *Transaction) commitSteps() ([]string, error) {
+	refs := make([]string, len(tx.steps))
+	for i, s := range tx.steps {
+		err := tx.applyStep(s)
+		if err != nil {
+			txErr := &TransactionError{Step: i, Err: err}
+			txErr.RollbackErr = tx.rollback(tx.steps[:i])
+			return nil, txErr
+		}
+		refs[i] = s.result
+	}
+	return refs, nil
+}
+
+func (tx *Transaction) applyStep(s *txStep) error {
+	conn := tx.objMgr.connector
+	var err error
+	switch {
+	case s.apply != nil:
+		s.result, err = s.apply(tx.objMgr)
+	case s.t == CREATE:
+		s.result, err = conn.CreateObject(s.obj)
+	case s.t == UPDATE:
+		if s.prior, _, err = tx.priorFields(s.ref, s.obj); err == nil {
+			s.result, err = conn.UpdateObject(s.obj, s.ref)
+		}
+	case s.t == DELETE:
+		if s.prior, s.partial, err = tx.priorFields(s.ref, nil); err == nil {
+			s.result, err = conn.DeleteObject(s.ref)
+		}
+	}
+	return err
+}
+
+// rollback undoes the applied steps in reverse order, and returns
+// the errors met while undoing them
+func (tx *Transaction) rollback(applied []*txStep) error {
+	conn := tx.objMgr.connector
+	var errs []string
+	for i := len(applied) - 1; i >= 0; i-- {
+		s := applied[i]
+		var err error
+		switch {
+		case s.apply != nil:
+			if s.undo != nil {
+				err = s.undo(tx.objMgr, s.result)
+			}
+		case s.t == CREATE:
+			_, err = conn.DeleteObject(s.result)
+		case s.t == UPDATE:
+			if len(s.prior.fields) > 0 {
+				_, err = conn.UpdateObject(s.prior, s.result)
+			}
+		case s.t == DELETE:
+			_, err = conn.CreateObject(s.prior)
+			if err == nil && s.partial {
+				err = fmt.Errorf("the object was restored with its default fields only")
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("step %d: %s", i, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// priorFields returns the fields of the object with the given reference
+// which obj updates, or which are needed to create it again when obj is nil.
+// It reports whether these are the object's default fields only.
+func (tx *Transaction) priorFields(ref string, obj IBObject) (*rawObject, bool, error) {
+	objType, err := refObjectType(ref)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var fields []string
+	partial := false
+	if obj != nil {
+		data, err := multiRequestData(obj)
+		if err != nil {
+			return nil, false, err
+		}
+		for name := range data {
+			// extattrs+ and extattrs- update extattrs
+			fields = append(fields, strings.TrimRight(name, "+-"))
+		}
+		sort.Strings(fields)
+		if len(fields) == 0 {
+			return &rawObject{objType: objType}, false, nil
+		}
+	} else if fields, partial, err = tx.recreateFields(objType); err != nil {
+		return nil, false, err
+	}
+
+	query := &rawObject{objType: objType}
+	query.SetReturnFields(fields)
+	var res map[string]interface{}
+	if err = tx.objMgr.connector.GetObject(query, ref, NewQueryParams(false, nil), &res); err != nil {
+		return nil, false, err
+	}
+	delete(res, "_ref")
+	return &rawObject{objType: objType, fields: res}, partial, nil
+}
+
+// recreateFields returns the fields to read to create an object again:
+// the fields which can be read and written according to the grid's schema.
+// It fails when the schema can't be fetched, so that the object is not
+// deleted. When the connector is not a *Connector, which has no schema,
+// it reports that only the object's default fields can be read.
+func (tx *Transaction) recreateFields(objType string) (fields []string, partial bool, err error) {
+	base, ctx := unwrapConnector(tx.objMgr.connector)
+	conn, ok := base.(*Connector)
+	if !ok {
+		return nil, true, nil
+	}
+	schema, err := conn.GetObjectSchemaCtx(ctx, objType)
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot read the schema of %s objects to restore them: %s", objType, err)
+	}
+	for _, f := range schema.Fields {
+		if f.WapiPrimitive != "funccall" && f.SupportsOp('r') && f.SupportsOp('w') {
+			fields = append(fields, f.Name)
+		}
+	}
+	return fields, false, nil
+}
@@ -0,0 +1,150 @@
+package ibclient_test
+
+import (
+	"errors"
+
+	ibclient "github.com/infobloxopen/infoblox-go-client/v2"
+	"github.com/infobloxopen/infoblox-go-client/v2/fakewapi"
+	"github.com/infobloxopen/infoblox-go-client/v2/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transaction", func() {
+	var (
+		server *fakewapi.Server
+		conn   *ibclient.Connector
+		objMgr *ibclient.ObjectManager
+	)
+
+	BeforeEach(func() {
+		server, conn = newFakeConnector()
+		objMgr = ibclient.NewObjectManager(conn, "cmpType", "tenantID").(*ibclient.ObjectManager)
+	})
+
+	It("should apply the steps in a single multi-request", func() {
+		roleRef := server.Add("adminrole", map[string]interface{}{"name": "role", "comment": "before"})
+		oldRef := server.Add("nsgroup", map[string]interface{}{"name": "old"})
+
+		refs, err := objMgr.NewTransaction().
+			Create(&ibclient.Nsgroup{Name: utils.StringPtr("ns-group")}).
+			Update(&ibclient.Adminrole{Comment: utils.StringPtr("after")}, roleRef).
+			Delete(oldRef).
+			Commit()
+		Expect(err).To(BeNil())
+		Expect(refs).To(HaveLen(3))
+		Expect(refs[0]).To(HavePrefix("nsgroup/"))
+		Expect(refs[2]).To(Equal(oldRef))
+
+		role, ok := server.Get(refs[1])
+		Expect(ok).To(BeTrue())
+		Expect(role).To(HaveKeyWithValue("comment", "after"))
+		nsgroups := server.Objects("nsgroup")
+		Expect(nsgroups).To(HaveLen(1))
+		Expect(nsgroups[0]).To(HaveKeyWithValue("name", "ns-group"))
+	})
+
+	It("should apply none of the steps of a failed multi-request", func() {
+		_, err := objMgr.NewTransaction().
+			Create(&ibclient.Nsgroup{Name: utils.StringPtr("ns-group")}).
+			Delete("nsgroup/ZmFrZS5uc2dyb3VwJDQy:missing").
+			Commit()
+		Expect(ibclient.IsNotFound(err)).To(BeTrue())
+		Expect(server.Objects("nsgroup")).To(BeEmpty())
+	})
+
+	It("should undo the applied steps when a step fails", func() {
+		roleRef := server.Add("adminrole", map[string]interface{}{"name": "role", "comment": "before"})
+		oldRef := server.Add("nsgroup", map[string]interface{}{"name": "old", "comment": "deleted"})
+		server.SetSchema("nsgroup", map[string]string{"name": "rwus", "comment": "rwu"})
+
+		undone := ""
+		tx := objMgr.NewTransaction().
+			Do(func(objMgr ibclient.IBObjectManager) (string, error) {
+				nv, err := objMgr.CreateNetworkView("nv", "", nil)
+				if err != nil {
+					return "", err
+				}
+				return nv.Ref, nil
+			}, func(objMgr ibclient.IBObjectManager, ref string) error {
+				undone = ref
+				_, err := objMgr.DeleteNetworkView(ref)
+				return err
+			}).
+			Update(&ibclient.Adminrole{Comment: utils.StringPtr("after")}, roleRef).
+			Delete(oldRef).
+			Create(&ibclient.Nsgroup{Name: utils.StringPtr("ns-group")}).
+			Delete("nsgroup/ZmFrZS5uc2dyb3VwJDQy:missing")
+		_, err := tx.Commit()
+
+		var txErr *ibclient.TransactionError
+		Expect(errors.As(err, &txErr)).To(BeTrue())
+		Expect(txErr.Step).To(Equal(4))
+		Expect(txErr.RollbackErr).To(BeNil())
+		Expect(ibclient.IsNotFound(err)).To(BeTrue())
+
+		Expect(undone).To(HavePrefix("networkview/"))
+		Expect(server.Objects("networkview")).To(HaveLen(1)) // the default network view
+		roles := server.Objects("adminrole")
+		Expect(roles).To(HaveLen(1))
+		Expect(roles[0]).To(HaveKeyWithValue("comment", "before"))
+		nsgroups := server.Objects("nsgroup")
+		Expect(nsgroups).To(HaveLen(1))
+		Expect(nsgroups[0]).To(HaveKeyWithValue("name", "old"))
+		Expect(nsgroups[0]).To(HaveKeyWithValue("comment", "deleted"))
+
+		_, err = tx.Commit()
+		Expect(err).To(MatchError("the transaction is already committed"))
+	})
+
+	createNetworkView := func(objMgr ibclient.IBObjectManager) (string, error) {
+		nv, err := objMgr.CreateNetworkView("nv", "", nil)
+		if err != nil {
+			return "", err
+		}
+		return nv.Ref, nil
+	}
+	deleteNetworkView := func(objMgr ibclient.IBObjectManager, ref string) error {
+		_, err := objMgr.DeleteNetworkView(ref)
+		return err
+	}
+
+	It("should not delete objects when their schema can't be read", func() {
+		oldRef := server.Add("nsgroup", map[string]interface{}{"name": "old", "comment": "kept"})
+
+		_, err := objMgr.NewTransaction().
+			Do(createNetworkView, deleteNetworkView).
+			Delete(oldRef).
+			Commit()
+		var txErr *ibclient.TransactionError
+		Expect(errors.As(err, &txErr)).To(BeTrue())
+		Expect(txErr.Step).To(Equal(1))
+		Expect(txErr.Err).To(MatchError(ContainSubstring("cannot read the schema of nsgroup objects to restore them")))
+		Expect(txErr.RollbackErr).To(BeNil())
+
+		Expect(server.Objects("networkview")).To(HaveLen(1))
+		old, ok := server.Get(oldRef)
+		Expect(ok).To(BeTrue())
+		Expect(old).To(HaveKeyWithValue("comment", "kept"))
+	})
+
+	It("should report the objects restored with their default fields only", func() {
+		oldRef := server.Add("nsgroup", map[string]interface{}{"name": "old"})
+		// the connector is not a *Connector, so no schema can be fetched
+		objMgr := ibclient.NewObjectManager(struct{ *ibclient.Connector }{conn}, "cmpType", "tenantID").(*ibclient.ObjectManager)
+
+		_, err := objMgr.NewTransaction().
+			Delete(oldRef).
+			Delete("nsgroup/ZmFrZS5uc2dyb3VwJDQy:missing").
+			Commit()
+		var txErr *ibclient.TransactionError
+		Expect(errors.As(err, &txErr)).To(BeTrue())
+		Expect(txErr.Step).To(Equal(1))
+		Expect(txErr.RollbackErr).To(MatchError("step 0: the object was restored with its default fields only"))
+
+		nsgroups := server.Objects("nsgroup")
+		Expect(nsgroups).To(HaveLen(1))
+		Expect(nsgroups[0]).To(HaveKeyWithValue("name", "old"))
+	})
+})
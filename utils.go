@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"net/url"
 	"reflect"
 	"regexp"
 	"strings"
@@ -14,12 +13,18 @@ import (
 
 type NotFoundError struct {
 	msg string
+	err error
 }
 
 func (e *NotFoundError) Error() string {
 	return e.msg
 }
 
+// Unwrap returns the WapiError the NotFoundError was built from, if any.
+func (e *NotFoundError) Unwrap() error {
+	return e.err
+}
+
 func NewNotFoundError(msg string) *NotFoundError {
 	return &NotFoundError{msg: msg}
 }
@@ -103,16 +108,14 @@ func (ns *NullableNameServers) UnmarshalJSON(data []byte) error {
 
 func BuildNetworkViewFromRef(ref string) *NetworkView {
 	// networkview/ZG5zLm5ldHdvcmtfdmlldyQyMw:global_view/false
-	r := regexp.MustCompile(`networkview/\w+:([^/]+)/\w+`)
-	m := r.FindStringSubmatch(ref)
-
-	if m == nil {
+	r, err := ParseRef(ref)
+	if err != nil || r.ObjectType != "networkview" || r.Name == "" {
 		return nil
 	}
 
 	return &NetworkView{
 		Ref:  ref,
-		Name: &m[1],
+		Name: &r.Name,
 	}
 }
 
@@ -125,67 +128,67 @@ func getNetworkObjectType(isIPv6 bool, ipv4Object string, ipv6Object string) str
 
 func BuildNetworkFromRef(ref string) (*Network, error) {
 	// network/ZG5zLm5ldHdvcmskODkuMC4wLjAvMjQvMjU:89.0.0.0/24/global_view
-	r := regexp.MustCompile(`network/\w+:(\d+\.\d+\.\d+\.\d+/\d+)/(.+)`)
-	m := r.FindStringSubmatch(ref)
-
-	if m == nil {
-		return nil, fmt.Errorf("CIDR format not matched")
+	cidr, netview, err := networkFromRef(ref, "network")
+	if err != nil {
+		return nil, err
 	}
 
-	newNet := NewNetwork(m[2], m[1], false, "", nil)
+	newNet := NewNetwork(netview, cidr, false, "", nil)
 	newNet.Ref = ref
 	return newNet, nil
 }
 
 func BuildNetworkContainerFromRef(ref string) (*NetworkContainer, error) {
 	// networkcontainer/ZG5zLm5ldHdvcmskODkuMC4wLjAvMjQvMjU:89.0.0.0/24/global_view
-	r := regexp.MustCompile(`networkcontainer/\w+:(\d+\.\d+\.\d+\.\d+/\d+)/(.+)`)
-	m := r.FindStringSubmatch(ref)
-
-	if m == nil {
-		return nil, fmt.Errorf("CIDR format not matched")
+	cidr, netview, err := networkFromRef(ref, "networkcontainer")
+	if err != nil {
+		return nil, err
 	}
 
-	newNet := NewNetworkContainer(m[2], m[1], false, "", nil)
+	newNet := NewNetworkContainer(netview, cidr, false, "", nil)
 	newNet.Ref = ref
 	return newNet, nil
 }
 
 func BuildIPv6NetworkContainerFromRef(ref string) (*NetworkContainer, error) {
 	// ipv6networkcontainer/ZG5zLm5ldHdvcmskODkuMC4wLjAvMjQvMjU:2001%3Adb8%3Aabcd%3A0012%3A%3A0/64/global_view
-	r := regexp.MustCompile(`ipv6networkcontainer/[^:]+:(([^\/]+)\/\d+)\/(.+)`)
-	m := r.FindStringSubmatch(ref)
-
-	if m == nil {
-		return nil, fmt.Errorf("CIDR format not matched")
-	}
-
-	cidr, err := url.QueryUnescape(m[1])
+	cidr, netview, err := networkFromRef(ref, "ipv6networkcontainer")
 	if err != nil {
-		return nil, fmt.Errorf(
-			"cannot extract network CIDR information from the reference '%s': %s",
-			ref, err.Error())
-	}
-
-	if _, _, err = net.ParseCIDR(cidr); err != nil {
-		return nil, fmt.Errorf("CIDR format not matched")
+		return nil, err
 	}
 
-	newNet := NewNetworkContainer(m[3], cidr, true, "", nil)
+	newNet := NewNetworkContainer(netview, cidr, true, "", nil)
 	newNet.Ref = ref
 
 	return newNet, nil
 }
 
+// networkFromRef returns the CIDR and the network view of a reference
+// to a network or a network container of the given type
+func networkFromRef(ref string, objType string) (cidr string, netview string, err error) {
+	r, err := ParseRef(ref)
+	if err != nil || r.ObjectType != objType || r.View == "" {
+		return "", "", fmt.Errorf("CIDR format not matched")
+	}
+	ip, _, err := net.ParseCIDR(r.Name)
+	if err != nil || (ip.To4() != nil) == r.IsIPv6() {
+		return "", "", fmt.Errorf("CIDR format not matched")
+	}
+	return r.Name, r.View, nil
+}
+
+// GetIPAddressFromRef returns the address of a fixedaddress
+// or ipv6fixedaddress reference, an empty string for other references.
 func GetIPAddressFromRef(ref string) string {
 	// fixedaddress/ZG5zLmJpbmRfY25h:12.0.10.1/external
-	r := regexp.MustCompile(`fixedaddress/\w+:(\d+\.\d+\.\d+\.\d+)/.+`)
-	m := r.FindStringSubmatch(ref)
-
-	if m != nil {
-		return m[1]
+	r, err := ParseRef(ref)
+	if err != nil || (r.ObjectType != "fixedaddress" && r.ObjectType != "ipv6fixedaddress") {
+		return ""
+	}
+	if net.ParseIP(r.Name) == nil {
+		return ""
 	}
-	return ""
+	return r.Name
 }
 
 // validation  for match_client
@@ -207,25 +210,12 @@ func validateMatchClient(value string) bool {
 
 func BuildIPv6NetworkFromRef(ref string) (*Network, error) {
 	// ipv6network/ZG5zLm5ldHdvcmskODkuMC4wLjAvMjQvMjU:2001%3Adb8%3Aabcd%3A0012%3A%3A0/64/global_view
-	r := regexp.MustCompile(`ipv6network/[^:]+:(([^\/]+)\/\d+)\/(.+)`)
-	m := r.FindStringSubmatch(ref)
-
-	if m == nil {
-		return nil, fmt.Errorf("CIDR format not matched")
-	}
-
-	cidr, err := url.QueryUnescape(m[1])
+	cidr, netview, err := networkFromRef(ref, "ipv6network")
 	if err != nil {
-		return nil, fmt.Errorf(
-			"cannot extract network CIDR information from the reference '%s': %s",
-			ref, err.Error())
-	}
-
-	if _, _, err = net.ParseCIDR(cidr); err != nil {
-		return nil, fmt.Errorf("CIDR format not matched")
+		return nil, err
 	}
 
-	newNet := NewNetwork(m[3], cidr, true, "", nil)
+	newNet := NewNetwork(netview, cidr, true, "", nil)
 	newNet.Ref = ref
 
 	return newNet, nil